@@ -13,7 +13,15 @@ type Activation interface {
 }
 
 // Activations is the map of implemented activation functions
-var Activations = map[string]Activation{"identity": Identity{}, "logistic": Logistic{}, "relu": ReLU{}, "tanh": Tanh{}}
+var Activations = map[string]Activation{"identity": Identity{}, "logistic": Logistic{}, "relu": ReLU{}, "tanh": Tanh{},
+	"leaky_relu": LeakyReLU{Slope: .01}, "elu": ELU{Alpha: 1}, "selu": SELU{}, "gelu": GELU{}, "softplus": Softplus{},
+	"swish": Swish{}, "silu": Swish{}, "hard_sigmoid": HardSigmoid{}, "exp": Exp{}}
+
+// InputDerivable is implemented by activations whose derivative is not a function of their output (GELU, Swish).
+// FprimeX returns the derivative at input x. their Fprime inverts F on the branch where it's increasing
+type InputDerivable interface {
+	FprimeX(x float64) float64
+}
 
 // see https://en.wikipedia.org/wiki/Activation_function
 
@@ -66,6 +74,156 @@ func (ReLU) Fprime(y float64) float64 {
 	return 1.
 }
 
+// Exp is the inverse of the log link function of Poisson, Gamma and Tweedie regressions
+type Exp struct{}
+
+// F ...
+func (Exp) F(x float64) float64 { return math.Exp(x) }
+
+// Fprime ...
+func (Exp) Fprime(y float64) float64 { return y }
+
+// LeakyReLU has slope Slope for x<0
+type LeakyReLU struct{ Slope float64 }
+
+// F ...
+func (a LeakyReLU) F(x float64) float64 {
+	if x < 0. {
+		return a.Slope * x
+	}
+	return x
+}
+
+// Fprime ...
+func (a LeakyReLU) Fprime(y float64) float64 {
+	if y <= 0. {
+		return a.Slope
+	}
+	return 1.
+}
+
+// ELU is Alpha*(exp(x)-1) for x<0. https://arxiv.org/abs/1511.07289
+type ELU struct{ Alpha float64 }
+
+// F ...
+func (a ELU) F(x float64) float64 {
+	if x < 0. {
+		return a.Alpha * math.Expm1(x)
+	}
+	return x
+}
+
+// Fprime ...
+func (a ELU) Fprime(y float64) float64 {
+	if y <= 0. {
+		return y + a.Alpha
+	}
+	return 1.
+}
+
+// SELU constants from Klambauer et al. https://arxiv.org/abs/1706.02515
+const (
+	SELUAlpha = 1.6732632423543772848170429916717
+	SELUScale = 1.0507009873554804934193349852946
+)
+
+// SELU is SELUScale*ELU{Alpha:SELUAlpha}
+type SELU struct{}
+
+// F ...
+func (SELU) F(x float64) float64 { return SELUScale * ELU{Alpha: SELUAlpha}.F(x) }
+
+// Fprime ...
+func (SELU) Fprime(y float64) float64 {
+	if y <= 0. {
+		return y + SELUScale*SELUAlpha
+	}
+	return SELUScale
+}
+
+// GELU is x*Phi(x) where Phi is the standard normal cumulative distribution. https://arxiv.org/abs/1606.08415
+type GELU struct{}
+
+// geluArgMin is where GELU is minimal
+const geluArgMin = -0.75179164
+
+// F ...
+func (GELU) F(x float64) float64 { return x * .5 * (1. + math.Erf(x/math.Sqrt2)) }
+
+// FprimeX ...
+func (GELU) FprimeX(x float64) float64 {
+	return .5*(1.+math.Erf(x/math.Sqrt2)) + x*math.Exp(-x*x/2.)/math.Sqrt(2.*math.Pi)
+}
+
+// Fprime ...
+func (a GELU) Fprime(y float64) float64 { return a.FprimeX(invertIncreasing(a.F, y, geluArgMin)) }
+
+// Softplus is log(1+exp(x))
+type Softplus struct{}
+
+// F ...
+func (Softplus) F(x float64) float64 {
+	if x > 0. {
+		return x + math.Log1p(math.Exp(-x))
+	}
+	return math.Log1p(math.Exp(x))
+}
+
+// Fprime ...
+func (Softplus) Fprime(y float64) float64 { return -math.Expm1(-y) }
+
+// Swish (or SiLU) is x*logistic(x). https://arxiv.org/abs/1710.05941
+type Swish struct{}
+
+// swishArgMin is where Swish is minimal
+const swishArgMin = -1.27846454
+
+// F ...
+func (Swish) F(x float64) float64 { return x / (1. + math.Exp(-x)) }
+
+// FprimeX ...
+func (Swish) FprimeX(x float64) float64 {
+	s := 1. / (1. + math.Exp(-x))
+	return s * (1. + x*(1.-s))
+}
+
+// Fprime ...
+func (a Swish) Fprime(y float64) float64 { return a.FprimeX(invertIncreasing(a.F, y, swishArgMin)) }
+
+// HardSigmoid is the piecewise linear approximation of Logistic max(0,min(1,.2*x+.5))
+type HardSigmoid struct{}
+
+// F ...
+func (HardSigmoid) F(x float64) float64 { return math.Max(0., math.Min(1., .2*x+.5)) }
+
+// Fprime ...
+func (HardSigmoid) Fprime(y float64) float64 {
+	if y <= 0. || y >= 1. {
+		return 0.
+	}
+	return .2
+}
+
+// invertIncreasing returns x>=xmin such as f(x)=y by bisection, f being increasing on [xmin,+Inf). it returns xmin if y<=f(xmin)
+func invertIncreasing(f func(float64) float64, y, xmin float64) float64 {
+	if y <= f(xmin) {
+		return xmin
+	}
+	lo, hi := xmin, math.Max(1., 2.*y)
+	for f(hi) < y {
+		lo, hi = hi, 2.*hi
+	}
+	for i := 0; i < 100 && hi-lo > 1e-15*math.Max(1., math.Abs(hi)); i++ {
+		mid := (lo + hi) / 2.
+		if f(mid) < y {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2.
+}
+
 // Softmax ...
 type Softmax struct{}
 
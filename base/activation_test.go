@@ -23,6 +23,40 @@ func TestReLU(t *testing.T) {
 	testActivationDerivatives(t, ReLU{})
 }
 
+func TestExp(t *testing.T) {
+	testActivationDerivatives(t, Exp{})
+}
+
+func TestLeakyReLU(t *testing.T) {
+	testActivationDerivatives(t, LeakyReLU{Slope: .2})
+}
+
+func TestELU(t *testing.T) {
+	testActivationDerivatives(t, ELU{Alpha: 1})
+}
+
+func TestSELU(t *testing.T) {
+	testActivationDerivatives(t, SELU{})
+}
+
+func TestGELU(t *testing.T) {
+	testActivationDerivatives(t, GELU{})
+	testInputDerivatives(t, GELU{})
+}
+
+func TestSoftplus(t *testing.T) {
+	testActivationDerivatives(t, Softplus{})
+}
+
+func TestSwish(t *testing.T) {
+	testActivationDerivatives(t, Swish{})
+	testInputDerivatives(t, Swish{})
+}
+
+func TestHardSigmoid(t *testing.T) {
+	testActivationDerivatives(t, HardSigmoid{})
+}
+
 func testActivationDerivatives(t *testing.T, activation Activation) {
 	for pass := 0; pass < 5; pass++ {
 		x := rand.NormFloat64()
@@ -31,6 +65,10 @@ func testActivationDerivatives(t *testing.T, activation Activation) {
 		var expected = fd.Derivative(activation.F, x*theta, &fd.Settings{Step: 1e-6})
 
 		actual := activation.Fprime(y)
+		if d, ok := activation.(InputDerivable); ok {
+			// Fprime(y) can't tell the branch of a non monotonic activation
+			actual = d.FprimeX(x * theta)
+		}
 
 		if !floats.EqualWithinAbs(expected, actual, 1e-3) {
 			t.Errorf("testActivationDerivatives %T x:%g theta:%g y:%g expected:%g actual:%g", activation, x, theta, y, expected, actual)
@@ -40,3 +78,15 @@ func testActivationDerivatives(t *testing.T, activation Activation) {
 		}
 	}
 }
+
+func testInputDerivatives(t *testing.T, activation interface {
+	Activation
+	InputDerivable
+}) {
+	for _, x := range []float64{-4, -1.5, -.5, 0, .3, 2} {
+		expected := fd.Derivative(activation.F, x, &fd.Settings{Step: 1e-6})
+		if actual := activation.FprimeX(x); !floats.EqualWithinAbs(expected, actual, 1e-6) {
+			t.Errorf("%T x:%g expected:%g actual:%g", activation, x, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package base
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/optimize"
+)
+
+// NesterovOptimizer is SGD with Nesterov momentum
+type NesterovOptimizer struct {
+	BaseOptimizer
+	Momentum float64
+	// running Parameters (don't set them yourself)
+	Velocity *mat.Dense
+}
+
+// NewNesterovOptimizer returns a *NesterovOptimizer with stepsize .01 and momentum .9
+func NewNesterovOptimizer() *NesterovOptimizer {
+	return &NesterovOptimizer{BaseOptimizer: BaseOptimizer{StepSize: .01}, Momentum: .9}
+}
+
+func (o *NesterovOptimizer) String() string { return "nesterov" }
+
+// GetUpdate compute the update from grad
+func (o *NesterovOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
+	stepSize := o.nextTimeStep()
+	g := o.clippedGradient(grad)
+	if o.Velocity == nil {
+		r, c := g.Dims()
+		o.Velocity = mat.NewDense(r, c, nil)
+	}
+	// v ← μ·v − η·g, θ ← θ + μ·v − η·g
+	o.Velocity.Apply(func(i, j int, v float64) float64 { return o.Momentum*v - stepSize*g.At(i, j) }, o.Velocity)
+	update.Apply(func(i, j int, v float64) float64 { return o.Momentum*v - stepSize*g.At(i, j) }, o.Velocity)
+}
+
+// UpdateParams updates theta from gradient. first call allocates required temporary storage
+func (o *NesterovOptimizer) UpdateParams(grad mat.Matrix) { o.updateParams(o.GetUpdate, grad) }
+
+// Iterate is for optimize.Method
+func (o *NesterovOptimizer) Iterate(loc *optimize.Location) (optimize.Operation, error) {
+	return o.iterate(o.GetUpdate, loc)
+}
+
+// adamMoments are the moment estimates of adam variants
+type adamMoments struct {
+	Beta1, Beta2 float64
+	// running Parameters (don't set them yourself)
+	Mt, Vt *mat.Dense
+}
+
+// updateFirstMoment updates Mt from g and returns its bias correction 1-β1^t
+func (m *adamMoments) updateFirstMoment(g *mat.Dense, timeStep float64) float64 {
+	if m.Mt == nil {
+		r, c := g.Dims()
+		m.Mt = mat.NewDense(r, c, nil)
+	}
+	m.Mt.Apply(func(i, j int, mt float64) float64 { return m.Beta1*mt + (1-m.Beta1)*g.At(i, j) }, m.Mt)
+	return 1 - math.Pow(m.Beta1, timeStep)
+}
+
+// updateSecondMoment updates Vt from g and returns its bias correction 1-β2^t
+func (m *adamMoments) updateSecondMoment(g *mat.Dense, timeStep float64) float64 {
+	if m.Vt == nil {
+		r, c := g.Dims()
+		m.Vt = mat.NewDense(r, c, nil)
+	}
+	m.Vt.Apply(func(i, j int, vt float64) float64 {
+		gij := g.At(i, j)
+		return m.Beta2*vt + (1-m.Beta2)*gij*gij
+	}, m.Vt)
+	return 1 - math.Pow(m.Beta2, timeStep)
+}
+
+// NadamOptimizer is adam with Nesterov momentum https://openreview.net/pdf?id=OM0jvwB8jIp57ZJjtNEZ
+type NadamOptimizer struct {
+	BaseOptimizer
+	adamMoments
+}
+
+// NewNadamOptimizer returns a *NadamOptimizer with stepsize .002
+func NewNadamOptimizer() *NadamOptimizer {
+	return &NadamOptimizer{BaseOptimizer: BaseOptimizer{StepSize: .002, Epsilon: 1e-8}, adamMoments: adamMoments{Beta1: .9, Beta2: .999}}
+}
+
+func (o *NadamOptimizer) String() string { return "nadam" }
+
+// GetUpdate compute the update from grad
+func (o *NadamOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
+	stepSize := o.nextTimeStep()
+	g := o.clippedGradient(grad)
+	MtDen, VtDen := o.updateFirstMoment(g, o.TimeStep), o.updateSecondMoment(g, o.TimeStep)
+	// θ ← θ − η·(β1·m̂ + (1−β1)·g/(1−β1^t))/(√v̂ + ε)
+	update.Apply(func(i, j int, mt float64) float64 {
+		mNesterov := o.Beta1*mt/MtDen + (1-o.Beta1)*g.At(i, j)/MtDen
+		return -stepSize * mNesterov / (math.Sqrt(o.Vt.At(i, j)/VtDen) + o.Epsilon)
+	}, o.Mt)
+}
+
+// UpdateParams updates theta from gradient. first call allocates required temporary storage
+func (o *NadamOptimizer) UpdateParams(grad mat.Matrix) { o.updateParams(o.GetUpdate, grad) }
+
+// Iterate is for optimize.Method
+func (o *NadamOptimizer) Iterate(loc *optimize.Location) (optimize.Operation, error) {
+	return o.iterate(o.GetUpdate, loc)
+}
+
+// AdamWOptimizer is adam with decoupled weight decay https://arxiv.org/abs/1711.05101.
+// weight decay needs Theta, set by SetTheta
+type AdamWOptimizer struct {
+	BaseOptimizer
+	adamMoments
+	WeightDecay float64
+}
+
+// NewAdamWOptimizer returns a *AdamWOptimizer with stepsize .001 and weight decay .01
+func NewAdamWOptimizer() *AdamWOptimizer {
+	return &AdamWOptimizer{BaseOptimizer: BaseOptimizer{StepSize: .001, Epsilon: 1e-8}, adamMoments: adamMoments{Beta1: .9, Beta2: .999}, WeightDecay: .01}
+}
+
+func (o *AdamWOptimizer) String() string { return "adamw" }
+
+// GetUpdate compute the update from grad
+func (o *AdamWOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
+	stepSize := o.nextTimeStep()
+	g := o.clippedGradient(grad)
+	MtDen, VtDen := o.updateFirstMoment(g, o.TimeStep), o.updateSecondMoment(g, o.TimeStep)
+	// θ ← θ − η·(m̂/(√v̂ + ε) + λ·θ)
+	update.Apply(func(i, j int, mt float64) float64 {
+		upd := mt / MtDen / (math.Sqrt(o.Vt.At(i, j)/VtDen) + o.Epsilon)
+		if o.Theta != nil {
+			upd += o.WeightDecay * o.Theta.At(i, j)
+		}
+		return -stepSize * upd
+	}, o.Mt)
+}
+
+// UpdateParams updates theta from gradient. first call allocates required temporary storage
+func (o *AdamWOptimizer) UpdateParams(grad mat.Matrix) { o.updateParams(o.GetUpdate, grad) }
+
+// Iterate is for optimize.Method
+func (o *AdamWOptimizer) Iterate(loc *optimize.Location) (optimize.Operation, error) {
+	return o.iterate(o.GetUpdate, loc)
+}
+
+// AMSGradOptimizer is adam using the max of past second moment estimates https://openreview.net/forum?id=ryQu7f-RZ
+type AMSGradOptimizer struct {
+	BaseOptimizer
+	adamMoments
+	// running Parameters (don't set them yourself)
+	VtMax *mat.Dense
+}
+
+// NewAMSGradOptimizer returns a *AMSGradOptimizer with stepsize .001
+func NewAMSGradOptimizer() *AMSGradOptimizer {
+	return &AMSGradOptimizer{BaseOptimizer: BaseOptimizer{StepSize: .001, Epsilon: 1e-8}, adamMoments: adamMoments{Beta1: .9, Beta2: .999}}
+}
+
+func (o *AMSGradOptimizer) String() string { return "amsgrad" }
+
+// GetUpdate compute the update from grad
+func (o *AMSGradOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
+	stepSize := o.nextTimeStep()
+	g := o.clippedGradient(grad)
+	MtDen, VtDen := o.updateFirstMoment(g, o.TimeStep), o.updateSecondMoment(g, o.TimeStep)
+	if o.VtMax == nil {
+		r, c := g.Dims()
+		o.VtMax = mat.NewDense(r, c, nil)
+	}
+	o.VtMax.Apply(func(i, j int, vtMax float64) float64 { return math.Max(vtMax, o.Vt.At(i, j)) }, o.VtMax)
+	update.Apply(func(i, j int, mt float64) float64 {
+		return -stepSize * mt / MtDen / (math.Sqrt(o.VtMax.At(i, j)/VtDen) + o.Epsilon)
+	}, o.Mt)
+}
+
+// UpdateParams updates theta from gradient. first call allocates required temporary storage
+func (o *AMSGradOptimizer) UpdateParams(grad mat.Matrix) { o.updateParams(o.GetUpdate, grad) }
+
+// Iterate is for optimize.Method
+func (o *AMSGradOptimizer) Iterate(loc *optimize.Location) (optimize.Operation, error) {
+	return o.iterate(o.GetUpdate, loc)
+}
+
+// AdamaxOptimizer is the infinity norm variant of adam https://arxiv.org/abs/1412.6980
+type AdamaxOptimizer struct {
+	BaseOptimizer
+	adamMoments
+	// running Parameters (don't set them yourself)
+	Ut *mat.Dense
+}
+
+// NewAdamaxOptimizer returns a *AdamaxOptimizer with stepsize .002
+func NewAdamaxOptimizer() *AdamaxOptimizer {
+	return &AdamaxOptimizer{BaseOptimizer: BaseOptimizer{StepSize: .002, Epsilon: 1e-8}, adamMoments: adamMoments{Beta1: .9, Beta2: .999}}
+}
+
+func (o *AdamaxOptimizer) String() string { return "adamax" }
+
+// GetUpdate compute the update from grad
+func (o *AdamaxOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
+	stepSize := o.nextTimeStep()
+	g := o.clippedGradient(grad)
+	MtDen := o.updateFirstMoment(g, o.TimeStep)
+	if o.Ut == nil {
+		r, c := g.Dims()
+		o.Ut = mat.NewDense(r, c, nil)
+	}
+	// ut ← max(β2·ut−1, |g|)
+	o.Ut.Apply(func(i, j int, ut float64) float64 { return math.Max(o.Beta2*ut, math.Abs(g.At(i, j))) }, o.Ut)
+	update.Apply(func(i, j int, mt float64) float64 {
+		return -stepSize / MtDen * mt / (o.Ut.At(i, j) + o.Epsilon)
+	}, o.Mt)
+}
+
+// UpdateParams updates theta from gradient. first call allocates required temporary storage
+func (o *AdamaxOptimizer) UpdateParams(grad mat.Matrix) { o.updateParams(o.GetUpdate, grad) }
+
+// Iterate is for optimize.Method
+func (o *AdamaxOptimizer) Iterate(loc *optimize.Location) (optimize.Operation, error) {
+	return o.iterate(o.GetUpdate, loc)
+}
+
+// LAMBOptimizer is adam with weight decay and a layer-wise trust ratio ||θ||/||update|| https://arxiv.org/abs/1904.00962.
+// an optimizer is used for each layer, so the trust ratio is computed on the whole Theta, which must be set by SetTheta
+type LAMBOptimizer struct {
+	BaseOptimizer
+	adamMoments
+	WeightDecay float64
+}
+
+// NewLAMBOptimizer returns a *LAMBOptimizer with stepsize .001 and weight decay .01.
+// updates are proportional to ||θ||, so from a θ near 0 (as in linearModel.LinFitGOM) a larger StepSize is needed to converge
+func NewLAMBOptimizer() *LAMBOptimizer {
+	return &LAMBOptimizer{BaseOptimizer: BaseOptimizer{StepSize: .001, Epsilon: 1e-6}, adamMoments: adamMoments{Beta1: .9, Beta2: .999}, WeightDecay: .01}
+}
+
+func (o *LAMBOptimizer) String() string { return "lamb" }
+
+// GetUpdate compute the update from grad
+func (o *LAMBOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
+	stepSize := o.nextTimeStep()
+	g := o.clippedGradient(grad)
+	MtDen, VtDen := o.updateFirstMoment(g, o.TimeStep), o.updateSecondMoment(g, o.TimeStep)
+	// r ← m̂/(√v̂ + ε) + λ·θ
+	update.Apply(func(i, j int, mt float64) float64 {
+		r := mt / MtDen / (math.Sqrt(o.Vt.At(i, j)/VtDen) + o.Epsilon)
+		if o.Theta != nil {
+			r += o.WeightDecay * o.Theta.At(i, j)
+		}
+		return r
+	}, o.Mt)
+	trustRatio := 1.
+	if o.Theta != nil {
+		if thetaNorm, rNorm := mat.Norm(o.Theta, 2), mat.Norm(update, 2); thetaNorm > 0 && rNorm > 0 {
+			trustRatio = thetaNorm / rNorm
+		}
+	}
+	update.Scale(-stepSize*trustRatio, update)
+}
+
+// UpdateParams updates theta from gradient. first call allocates required temporary storage
+func (o *LAMBOptimizer) UpdateParams(grad mat.Matrix) { o.updateParams(o.GetUpdate, grad) }
+
+// Iterate is for optimize.Method
+func (o *LAMBOptimizer) Iterate(loc *optimize.Location) (optimize.Operation, error) {
+	return o.iterate(o.GetUpdate, loc)
+}
@@ -0,0 +1,182 @@
+package base
+
+import (
+	"context"
+	"errors"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/optimize"
+)
+
+// CallbackInfo is the training state passed to callbacks
+type CallbackInfo struct {
+	// Epoch and MiniBatch are 0-based
+	Epoch, MiniBatch int
+	// Loss is the mini-batch loss for OnMiniBatchEnd, the epoch loss for OnEpochEnd and the previous epoch loss for OnEpochBegin
+	Loss, GradNorm float64
+	// StepSize is the learning rate of the optimizer, 0 if it has none. changing it changes the step size of the estimator optimizers
+	StepSize float64
+	// Theta is the weights being fitted. it can be copied to checkpoint them, or overwritten to restore them
+	Theta []float64
+	// Stop can be set by a callback to end training
+	Stop bool
+}
+
+// Callback is called by iterative estimators during training
+type Callback interface {
+	OnEpochBegin(info *CallbackInfo)
+	OnEpochEnd(info *CallbackInfo)
+	OnMiniBatchEnd(info *CallbackInfo)
+}
+
+// Callbacks is a Callback calling each of its elements in order
+type Callbacks []Callback
+
+// OnEpochBegin is called before each epoch
+func (cbs Callbacks) OnEpochBegin(info *CallbackInfo) {
+	for _, cb := range cbs {
+		cb.OnEpochBegin(info)
+	}
+}
+
+// OnEpochEnd is called after each epoch
+func (cbs Callbacks) OnEpochEnd(info *CallbackInfo) {
+	for _, cb := range cbs {
+		cb.OnEpochEnd(info)
+	}
+}
+
+// OnMiniBatchEnd is called after each weights update
+func (cbs Callbacks) OnMiniBatchEnd(info *CallbackInfo) {
+	for _, cb := range cbs {
+		cb.OnMiniBatchEnd(info)
+	}
+}
+
+// CallbackFuncs is a Callback from funcs. nil funcs are not called
+type CallbackFuncs struct {
+	EpochBegin, EpochEnd, MiniBatchEnd func(info *CallbackInfo)
+}
+
+// OnEpochBegin calls EpochBegin
+func (cb CallbackFuncs) OnEpochBegin(info *CallbackInfo) {
+	if cb.EpochBegin != nil {
+		cb.EpochBegin(info)
+	}
+}
+
+// OnEpochEnd calls EpochEnd
+func (cb CallbackFuncs) OnEpochEnd(info *CallbackInfo) {
+	if cb.EpochEnd != nil {
+		cb.EpochEnd(info)
+	}
+}
+
+// OnMiniBatchEnd calls MiniBatchEnd
+func (cb CallbackFuncs) OnMiniBatchEnd(info *CallbackInfo) {
+	if cb.MiniBatchEnd != nil {
+		cb.MiniBatchEnd(info)
+	}
+}
+
+// Checkpoint is a Callback keeping a copy of the weights at the end of the epoch with the lowest loss
+type Checkpoint struct {
+	Theta []float64
+	Epoch int
+	Loss  float64
+}
+
+// OnEpochBegin does nothing
+func (c *Checkpoint) OnEpochBegin(info *CallbackInfo) {}
+
+// OnEpochEnd copies info.Theta if info.Loss is the lowest seen
+func (c *Checkpoint) OnEpochEnd(info *CallbackInfo) {
+	if c.Theta == nil || info.Loss < c.Loss {
+		c.Theta = append(c.Theta[:0], info.Theta...)
+		c.Epoch, c.Loss = info.Epoch, info.Loss
+	}
+}
+
+// OnMiniBatchEnd does nothing
+func (c *Checkpoint) OnMiniBatchEnd(info *CallbackInfo) {}
+
+// ContextCallback returns a Callback stopping training at the next epoch boundary once ctx is done
+func ContextCallback(ctx context.Context) Callback {
+	stop := func(info *CallbackInfo) {
+		if ctx.Err() != nil {
+			info.Stop = true
+		}
+	}
+	return CallbackFuncs{EpochBegin: stop, EpochEnd: stop}
+}
+
+// WithContext returns cbs with a ContextCallback appended, or cbs if ctx can't be done
+func (cbs Callbacks) WithContext(ctx context.Context) Callbacks {
+	if ctx.Done() == nil {
+		return cbs
+	}
+	return append(cbs[:len(cbs):len(cbs)], ContextCallback(ctx))
+}
+
+// StepSizer is implemented by optimizers whose step size can be changed during training
+type StepSizer interface {
+	GetStepSize() float64
+	SetStepSize(stepSize float64)
+}
+
+// ErrTrainingStopped is the error of gonum/optimize methods stopped by a callback
+var ErrTrainingStopped = errors.New("training stopped by a callback")
+
+// CallbackRecorder is an optimize.Recorder calling Callbacks for gonum/optimize methods, for which an epoch is a major iteration.
+// a callback setting Stop ends the optimization with ErrTrainingStopped and the best location found so far.
+// Theta is a copy as optimize.Recorder must not modify the location. Recorder, if not nil, is called first
+type CallbackRecorder struct {
+	Callbacks Callbacks
+	Recorder  optimize.Recorder
+	epoch     int
+	stop      bool
+}
+
+// Init is for optimize.Recorder
+func (r *CallbackRecorder) Init() error {
+	r.epoch, r.stop = 0, false
+	if r.Recorder != nil {
+		return r.Recorder.Init()
+	}
+	return nil
+}
+
+// Record is for optimize.Recorder
+func (r *CallbackRecorder) Record(loc *optimize.Location, op optimize.Operation, stats *optimize.Stats) error {
+	if r.Recorder != nil {
+		if err := r.Recorder.Record(loc, op, stats); err != nil {
+			return err
+		}
+	}
+	if r.stop {
+		return ErrTrainingStopped
+	}
+	info := func() *CallbackInfo {
+		return &CallbackInfo{Epoch: r.epoch, Loss: loc.F, GradNorm: floats.Norm(loc.Gradient, 2), Theta: append([]float64(nil), loc.X...)}
+	}
+	switch op {
+	case optimize.InitIteration:
+		// an error here would prevent optimize from returning a result, so stopping is deferred to the next Record
+		i := info()
+		r.Callbacks.OnEpochBegin(i)
+		r.stop = i.Stop
+	case optimize.MajorIteration:
+		i := info()
+		r.Callbacks.OnEpochEnd(i)
+		if i.Stop {
+			return ErrTrainingStopped
+		}
+		r.epoch++
+		i = info()
+		r.Callbacks.OnEpochBegin(i)
+		if i.Stop {
+			return ErrTrainingStopped
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package base
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/diff/fd"
+)
+
+// GradientCheck is the comparison of an analytic gradient with its central differences estimate
+type GradientCheck struct {
+	Analytic, Numeric []float64
+	// RelErr is |Analytic-Numeric|/max(|Analytic|,|Numeric|,1e-6) for each parameter, +Inf if either is NaN.
+	// the 1e-6 floor has gradients near zero, where finite differences are dominated by rounding errors, compared absolutely
+	RelErr []float64
+	// MaxRelErr is the max of RelErr, found for parameter ArgMax
+	MaxRelErr float64
+	ArgMax    int
+}
+
+// CheckGradient compares grad with the central differences of f at x.
+// grad must put the gradient of f at x in dst. step is the finite differences step, 0 for the default one.
+// x is not modified
+func CheckGradient(f func(x []float64) float64, grad func(dst, x []float64), x []float64, step float64) *GradientCheck {
+	n := len(x)
+	gc := &GradientCheck{Analytic: make([]float64, n), Numeric: make([]float64, n), RelErr: make([]float64, n)}
+	x0 := append([]float64(nil), x...)
+	grad(gc.Analytic, x0)
+	copy(x0, x)
+	fd.Gradient(gc.Numeric, f, x0, &fd.Settings{Formula: fd.Central, Step: step})
+	for i, a := range gc.Analytic {
+		num := gc.Numeric[i]
+		gc.RelErr[i] = math.Abs(a-num) / math.Max(math.Max(math.Abs(a), math.Abs(num)), 1e-6)
+		if math.IsNaN(gc.RelErr[i]) {
+			gc.RelErr[i] = math.Inf(1)
+		}
+		if gc.RelErr[i] > gc.MaxRelErr {
+			gc.MaxRelErr, gc.ArgMax = gc.RelErr[i], i
+		}
+	}
+	return gc
+}
@@ -394,7 +394,7 @@ func MatParallelGemm(tA, tB blas.Transpose, alpha float64, a, b blas64.General,
 	}
 	if n < 64 {
 		wg.Add(1)
-		fn(0, 0, a.Rows, wg)
+		fn(0, 0, n, wg)
 
 	} else {
 		for job := 0; job < nJobs; job++ {
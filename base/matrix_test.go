@@ -3,6 +3,7 @@ package base
 import (
 	"testing"
 
+	"gonum.org/v1/gonum/blas"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -18,3 +19,16 @@ func TestMatrixFirstColumnRemoved(t *testing.T) {
 		t.Errorf("Theta1.At(0,0):%g expected:%g", Theta1.At(0, 0), 2.)
 	}
 }
+
+func TestMatParallelGemm(t *testing.T) {
+	// a has fewer rows than columns, so a.T() dot b has more rows than a
+	a := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	b := mat.NewDense(2, 1, []float64{1, -1})
+	c := mat.NewDense(3, 1, nil)
+	MatParallelGemm(blas.Trans, blas.NoTrans, 1, a.RawMatrix(), b.RawMatrix(), 0, c.RawMatrix())
+	expected := &mat.Dense{}
+	expected.Mul(a.T(), b)
+	if !mat.Equal(c, expected) {
+		t.Errorf("expected %g got %g", mat.Formatted(expected.T()), mat.Formatted(c.T()))
+	}
+}
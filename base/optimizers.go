@@ -21,13 +21,19 @@ type Optimizer interface {
 // OptimCreator is the type for functions returning an Optimizer
 type OptimCreator func() Optimizer
 
-// Solvers is the map for common Optimizer creators agd,adagrad,rmsprop,adadelta,adam
+// Solvers is the map for common Optimizer creators sgd,adagrad,rmsprop,adadelta,adam,nesterov,nadam,adamw,amsgrad,adamax,lamb
 var Solvers = map[string]OptimCreator{
 	"sgd":      func() Optimizer { return NewSGDOptimizer() },
 	"adagrad":  func() Optimizer { return NewAdagradOptimizer() },
 	"rmsprop":  func() Optimizer { return NewRMSPropOptimizer() },
 	"adadelta": func() Optimizer { return NewAdadeltaOptimizer() },
 	"adam":     func() Optimizer { return NewAdamOptimizer() },
+	"nesterov": func() Optimizer { return NewNesterovOptimizer() },
+	"nadam":    func() Optimizer { return NewNadamOptimizer() },
+	"adamw":    func() Optimizer { return NewAdamWOptimizer() },
+	"amsgrad":  func() Optimizer { return NewAMSGradOptimizer() },
+	"adamax":   func() Optimizer { return NewAdamaxOptimizer() },
+	"lamb":     func() Optimizer { return NewLAMBOptimizer() },
 }
 
 // GOMethodCreator is a func that creates a gonum/optimize.Method
@@ -40,6 +46,12 @@ var GOMethodCreators = map[string]GOMethodCreator{
 	"rmsprop":         func() optimize.Method { return NewRMSPropOptimizer() },
 	"adadelta":        func() optimize.Method { return NewAdadeltaOptimizer() },
 	"adam":            func() optimize.Method { return NewAdamOptimizer() },
+	"nesterov":        func() optimize.Method { return NewNesterovOptimizer() },
+	"nadam":           func() optimize.Method { return NewNadamOptimizer() },
+	"adamw":           func() optimize.Method { return NewAdamWOptimizer() },
+	"amsgrad":         func() optimize.Method { return NewAMSGradOptimizer() },
+	"adamax":          func() optimize.Method { return NewAdamaxOptimizer() },
+	"lamb":            func() optimize.Method { return NewLAMBOptimizer() },
 	"bfgs":            func() optimize.Method { return &optimize.BFGS{} },
 	"cg":              func() optimize.Method { return &optimize.CG{} },
 	"gradientdescent": func() optimize.Method { return &optimize.GradientDescent{} },
@@ -47,32 +59,153 @@ var GOMethodCreators = map[string]GOMethodCreator{
 	"neldermead":      func() optimize.Method { return &optimize.NelderMead{} },
 }
 
+// BaseOptimizer holds what's common to optimizers: the step size and its scheduler, gradient clipping, the time step
+// and theta, and the optimize.Method plumbing. optimizers embedding it implement GetUpdate, UpdateParams and Iterate
+type BaseOptimizer struct {
+	// StepSize is the learning rate
+	// GradientClipping is used if >0 to limit gradient L2 norm
+	// Epsilon is used to avoid division by zero
+	StepSize, GradientClipping, Epsilon float64
+	// Scheduler, if not nil, computes the learning rate from StepSize and TimeStep
+	Scheduler LRScheduler
+	// NFeature,NOutputs need only to be initialized wher the optimizer is used as an optimize.Method
+	NFeatures, NOutputs int
+
+	// running Parameters (don't set them yourself)
+	GtNorm, Theta, Update *mat.Dense
+	TimeStep              float64
+	// clipped is the clipped gradient
+	clipped *mat.Dense
+	// lastOp is for Iterate when used as optimize.Method
+	lastOp optimize.Operation
+}
+
+// SetTheta should be called before first call to UpdateParams to let the solver know the theta pointer
+func (o *BaseOptimizer) SetTheta(Theta *mat.Dense) {
+	o.NFeatures, o.NOutputs = Theta.Dims()
+	o.Theta = Theta
+}
+
+// GetTheta can be called anytime after SetTheta to get read access to theta
+func (o *BaseOptimizer) GetTheta() *mat.Dense { return o.Theta }
+
+// GetTimeStep return the number of theta updates already occurred
+func (o *BaseOptimizer) GetTimeStep() uint64 { return uint64(o.TimeStep) }
+
+// GetStepSize is for StepSizer
+func (o *BaseOptimizer) GetStepSize() float64 { return o.StepSize }
+
+// SetStepSize is for StepSizer
+func (o *BaseOptimizer) SetStepSize(stepSize float64) { o.StepSize = stepSize }
+
+// SetLRScheduler is for LRScheduled
+func (o *BaseOptimizer) SetLRScheduler(scheduler LRScheduler) { o.Scheduler = scheduler }
+
+// nextTimeStep increments TimeStep and returns the learning rate for it
+func (o *BaseOptimizer) nextTimeStep() float64 {
+	o.TimeStep++
+	if o.Scheduler != nil {
+		return o.Scheduler.LearningRate(o.StepSize, o.TimeStep)
+	}
+	return o.StepSize
+}
+
+// clippedGradient returns grad with columns of norm above GradientClipping scaled down to it
+func (o *BaseOptimizer) clippedGradient(grad mat.Matrix) *mat.Dense {
+	r, c := grad.Dims()
+	if o.clipped == nil {
+		o.clipped = mat.NewDense(r, c, nil)
+	}
+	o.clipped.Copy(grad)
+	if o.GradientClipping > 0 {
+		for j := 0; j < c; j++ {
+			if norm := colNorm(grad, j); norm > o.GradientClipping {
+				col := o.clipped.ColView(j).(*mat.VecDense)
+				col.ScaleVec(o.GradientClipping/norm, col)
+			}
+		}
+	}
+	return o.clipped
+}
+
+// updateParams updates Theta with the update from getUpdate
+func (o *BaseOptimizer) updateParams(getUpdate func(update *mat.Dense, grad mat.Matrix), grad mat.Matrix) {
+	r, c := grad.Dims()
+	if o.Update == nil {
+		o.Update = mat.NewDense(r, c, nil)
+	}
+	getUpdate(o.Update, grad)
+	o.Theta.Add(o.Theta, o.Update)
+}
+
+// Init initializes the method based on the initial data in loc, updates it
+// and returns the first operation to be carried out by the caller.
+// The initial location must be valid as specified by Needs.
+func (o *BaseOptimizer) Init(loc *optimize.Location) (op optimize.Operation, err error) {
+	if o.NFeatures == 0 || o.NOutputs == 0 {
+		o.NFeatures, o.NOutputs = len(loc.X), 1
+	}
+	if len(loc.X) == o.NFeatures {
+		o.NOutputs = 1
+	}
+	if len(loc.X) != o.NFeatures*o.NOutputs {
+		err = fmt.Errorf("Size error. expected %d,%d got %d", o.NFeatures, o.NOutputs, len(loc.X))
+		return
+	}
+	o.Update = mat.NewDense(o.NFeatures, o.NOutputs, nil)
+	op = optimize.FuncEvaluation | optimize.GradEvaluation
+	return
+}
+
+// iterate retrieves data from loc, applies the update from getUpdate and returns the next operation
+func (o *BaseOptimizer) iterate(getUpdate func(update *mat.Dense, grad mat.Matrix), loc *optimize.Location) (op optimize.Operation, err error) {
+	theta := mat.NewDense(o.NFeatures, o.NOutputs, loc.X)
+	o.Theta = theta
+	getUpdate(o.Update, mat.NewDense(o.NFeatures, o.NOutputs, loc.Gradient))
+	theta.Add(theta, o.Update)
+	if o.lastOp == optimize.FuncEvaluation|optimize.GradEvaluation {
+		op = optimize.MajorIteration
+	} else {
+		op = optimize.FuncEvaluation | optimize.GradEvaluation
+	}
+	o.lastOp = op
+	return
+}
+
+// Needs is for when the optimizer is used as an optimize.Method
+func (*BaseOptimizer) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{
+		Gradient: true,
+		Hessian:  false,
+	}
+}
+
 // SGDOptimizer is struct for SGD solver v https://en.wikipedia.org/wiki/Stochastic_gradient_descent
+// Adagrad, Adadelta, RMSProp and Adam are variants of it. other optimizers are separate implementations of Optimizer
 type SGDOptimizer struct {
-	// StepSize is used for all variants
+	BaseOptimizer
 	// Momentum can be used for all variants
-	// GradientClipping is used if >0 to limit gradient L2 norm
 	// RMSPropGamma is the momentum for rmsprop and adadelta
-	// Epsilon is used to avoid division by zero in adagrad,rmsprop,adadelta,adam
-	StepSize, Momentum, GradientClipping, RMSPropGamma, Epsilon, BatchPart float64
+	Momentum, RMSPropGamma, BatchPart float64
 	// Adagrad, Adadelta, RMSProp, Adam are variants. At most one should be true
 	Adagrad, Adadelta, RMSProp, Adam bool
-	// NFeature,NOutputs need only to be initialized wher SGDOptimizer is used as an optimize.Method
-	NFeatures, NOutputs int
 
 	// running Parameters (don't set them yourself)
-	GtNorm, Theta, PrevUpdate, Update, AdagradG, AdadeltaU *mat.Dense
-	TimeStep                                               float64
+	PrevUpdate, AdagradG, AdadeltaU *mat.Dense
 	// Adam specific
 	Beta1, Beta2 float64
 	Mt, Vt       *mat.Dense
-	// lastOp is for Iterate when used as optimize.Method
-	lastOp optimize.Operation
 }
 
 // NewSGDOptimizer returns an initialized *SGDOptimizer with stepsize 1e-4 and momentum 0.9
 func NewSGDOptimizer() *SGDOptimizer {
-	s := &SGDOptimizer{StepSize: 1e-4, Momentum: .9, RMSPropGamma: .9, Epsilon: 1e-8, BatchPart: 1.0}
+	s := &SGDOptimizer{BaseOptimizer: BaseOptimizer{StepSize: 1e-4, Epsilon: 1e-8}, Momentum: .9, RMSPropGamma: .9, BatchPart: 1.0}
 
 	return s
 }
@@ -107,7 +240,7 @@ func NewRMSPropOptimizer() *SGDOptimizer {
 
 // NewAdamOptimizer returns an initialized adam solver
 func NewAdamOptimizer() *SGDOptimizer {
-	s := &SGDOptimizer{StepSize: .5, Beta1: .9, Beta2: .999, Epsilon: 1e-8, BatchPart: 1.0, Adam: true}
+	s := &SGDOptimizer{BaseOptimizer: BaseOptimizer{StepSize: .5, Epsilon: 1e-8}, Beta1: .9, Beta2: .999, BatchPart: 1.0, Adam: true}
 	return s
 }
 
@@ -127,45 +260,17 @@ func (s *SGDOptimizer) String() string {
 
 }
 
-// NewOptimizer only accepts SGD|adagrad|adadelta|rmsprop|adam
+// NewOptimizer accepts the keys of Solvers
 func NewOptimizer(name string) Optimizer {
-	switch name {
-	case "sgd":
-		return NewSGDOptimizer()
-	case "adagrad":
-		return NewAdagradOptimizer()
-	case "adadelta":
-		return NewAdadeltaOptimizer()
-	case "rmsprop":
-		return NewRMSPropOptimizer()
-	case "adam":
-		return NewAdamOptimizer()
-	default:
-		panic("NewOptimizer only accepts SGD|adagrad|adadelta|rmsprop|adam")
+	creator, ok := Solvers[name]
+	if !ok {
+		panic(fmt.Errorf("unknown optimizer %s", name))
 	}
+	return creator()
 }
 
-// SetTheta should be called before first call to UpdateParams to let the solver know the theta pointer
-func (s *SGDOptimizer) SetTheta(Theta *mat.Dense) {
-	s.NFeatures, s.NOutputs = Theta.Dims()
-	s.Theta = Theta
-}
-
-// GetTheta can be called anytime after SetTheta to get read access to theta
-func (s *SGDOptimizer) GetTheta() *mat.Dense { return s.Theta }
-
-// GetTimeStep return the number of theta updates already occurred
-func (s *SGDOptimizer) GetTimeStep() uint64 { return uint64(s.TimeStep) }
-
 // UpdateParams updates theta from gradient. first call allocates required temporary storage
-func (s *SGDOptimizer) UpdateParams(grad mat.Matrix) {
-	r, c := grad.Dims()
-	if s.Update == nil {
-		s.Update = mat.NewDense(r, c, nil)
-	}
-	s.GetUpdate(s.Update, grad)
-	s.Theta.Add(s.Theta, s.Update)
-}
+func (s *SGDOptimizer) UpdateParams(grad mat.Matrix) { s.updateParams(s.GetUpdate, grad) }
 
 // GetUpdate compute the update from grad
 func (s *SGDOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
@@ -190,10 +295,10 @@ func (s *SGDOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
 			s.Vt = mat.NewDense(NFeatures, NOutputs, nil)
 		}
 	}
-	s.TimeStep += 1.
 	// gt ← ∇θft(θt−1) (Get gradients w.r.t. stochastic objective at timestep t)
 
-	eta := s.StepSize * 100. / (100. + s.TimeStep)
+	stepSize := s.nextTimeStep()
+	eta := stepSize * 100. / (100. + s.TimeStep)
 	if s.GradientClipping > 0. {
 		for j := 0; j < NOutputs; j++ {
 			s.GtNorm.Set(j, 0, colNorm(grad, j))
@@ -211,7 +316,7 @@ func (s *SGDOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
 
 	if s.RMSProp {
 		update.Apply(func(j, o int, v float64) float64 {
-			etajo := s.StepSize
+			etajo := stepSize
 			if s.TimeStep > 1 && math.Abs(s.AdagradG.At(j, o)) > 1. {
 				etajo /= math.Sqrt(s.AdagradG.At(j, o) + s.Epsilon)
 			}
@@ -225,7 +330,7 @@ func (s *SGDOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
 		}, s.AdagradG)
 	} else if s.Adagrad {
 		update.Apply(func(j, o int, v float64) float64 {
-			etajo := s.StepSize
+			etajo := stepSize
 			Gjo := s.AdagradG.At(j, o)
 			if s.TimeStep > 1 {
 				etajo /= math.Sqrt(Gjo) + s.Epsilon
@@ -276,7 +381,7 @@ func (s *SGDOptimizer) GetUpdate(update *mat.Dense, grad mat.Matrix) {
 		MtDen := 1. - math.Pow(s.Beta1, s.TimeStep)
 		VtDen := 1. - math.Pow(s.Beta2, s.TimeStep)
 		update.Apply(func(i, j int, Mtij float64) float64 {
-			return -s.StepSize * Mtij / MtDen / (math.Sqrt(s.Vt.At(i, j)/VtDen) + s.Epsilon)
+			return -stepSize * Mtij / MtDen / (math.Sqrt(s.Vt.At(i, j)/VtDen) + s.Epsilon)
 		}, s.Mt)
 	} else {
 		// normal SGD with momentum
@@ -304,56 +409,10 @@ func colNorm(m mat.Matrix, o int) float64 {
 	return math.Sqrt(s)
 }
 
-// Init initializes the method based on the initial data in loc, updates it
-// and returns the first operation to be carried out by the caller.
-// The initial location must be valid as specified by Needs.
-func (s *SGDOptimizer) Init(loc *optimize.Location) (op optimize.Operation, err error) {
-	//fmt.Println("SGDOptimizer.Init called with len(loc.X)=", len(loc.X))
-	if s.NFeatures == 0 || s.NOutputs == 0 {
-		s.NFeatures = len(loc.X)
-		return
-	}
-	if len(loc.X) == s.NFeatures {
-		s.NOutputs = 1
-	}
-	if len(loc.X) != s.NFeatures*s.NOutputs {
-		err = fmt.Errorf("Size error. expected %d,%d got %d", s.NFeatures, s.NOutputs, len(loc.X))
-		return
-	}
-	s.Update = mat.NewDense(s.NFeatures, s.NOutputs, nil)
-	op = optimize.FuncEvaluation | optimize.GradEvaluation
-	return
-}
-
 // Iterate retrieves data from loc, performs one iteration of the method,
 // updates loc and returns the next operation.
 func (s *SGDOptimizer) Iterate(loc *optimize.Location) (op optimize.Operation, err error) {
-	theta := mat.NewDense(s.NFeatures, s.NOutputs, loc.X)
-
-	s.GetUpdate(s.Update, mat.NewDense(s.NFeatures, s.NOutputs, loc.Gradient))
-	theta.Add(theta, s.Update)
-	//op = optimize.FuncEvaluation | optimize.GradEvaluation
-	if s.lastOp == optimize.FuncEvaluation|optimize.GradEvaluation {
-		op = optimize.MajorIteration
-	} else {
-		op = optimize.FuncEvaluation | optimize.GradEvaluation
-	}
-	s.lastOp = op
-	return
-}
-
-// Needs is for when SGDOptimizer is used as an optimize.Method
-func (*SGDOptimizer) Needs() struct {
-	Gradient bool
-	Hessian  bool
-} {
-	return struct {
-		Gradient bool
-		Hessian  bool
-	}{
-		Gradient: true,
-		Hessian:  false,
-	}
+	return s.iterate(s.GetUpdate, loc)
 }
 
 type matDense struct {
@@ -0,0 +1,166 @@
+package base
+
+import (
+	"fmt"
+	"math"
+)
+
+// LRScheduler computes the learning rate of an optimizer from its StepSize.
+// LearningRate must not change the scheduler state, so that a scheduler can be shared by the optimizers of several layers
+type LRScheduler interface {
+	// LearningRate returns the learning rate for the timeStep-th update (starting at 1)
+	LearningRate(stepSize, timeStep float64) float64
+}
+
+// LRScheduled is implemented by optimizers whose learning rate can follow an LRScheduler
+type LRScheduled interface {
+	SetLRScheduler(scheduler LRScheduler)
+}
+
+// LossObserver is implemented by schedulers adapting to a loss (the validation loss when available), observed once per epoch
+type LossObserver interface {
+	ObserveLoss(loss float64)
+}
+
+// LRSchedulerCreator is the type for functions returning an LRScheduler
+type LRSchedulerCreator func() LRScheduler
+
+// LRSchedulers is the map of LRScheduler creators with their defaults step,exponential,invscaling,cosine,onecycle,plateau
+var LRSchedulers = map[string]LRSchedulerCreator{
+	"step":        func() LRScheduler { return &StepDecay{Steps: 1000, Gamma: .5} },
+	"exponential": func() LRScheduler { return &ExponentialDecay{Gamma: .999} },
+	"invscaling":  func() LRScheduler { return &InverseScaling{PowerT: .5} },
+	"cosine":      func() LRScheduler { return &CosineAnnealingWarmRestarts{T0: 1000, TMult: 2} },
+	"onecycle":    func() LRScheduler { return NewOneCycle(10000) },
+	"plateau":     func() LRScheduler { return NewReduceOnPlateau() },
+}
+
+// NewLRScheduler returns the scheduler created by LRSchedulers[name]
+func NewLRScheduler(name string) LRScheduler {
+	creator, ok := LRSchedulers[name]
+	if !ok {
+		panic(fmt.Errorf("unknown learning rate scheduler %s", name))
+	}
+	return creator()
+}
+
+// StepDecay multiplies the learning rate by Gamma every Steps updates
+type StepDecay struct {
+	Steps int
+	Gamma float64
+}
+
+// LearningRate is for LRScheduler
+func (sch *StepDecay) LearningRate(stepSize, timeStep float64) float64 {
+	return stepSize * math.Pow(sch.Gamma, math.Floor((timeStep-1)/float64(sch.Steps)))
+}
+
+// ExponentialDecay multiplies the learning rate by Gamma at each update
+type ExponentialDecay struct {
+	Gamma float64
+}
+
+// LearningRate is for LRScheduler
+func (sch *ExponentialDecay) LearningRate(stepSize, timeStep float64) float64 {
+	return stepSize * math.Pow(sch.Gamma, timeStep-1)
+}
+
+// InverseScaling divides the learning rate by timeStep^PowerT
+type InverseScaling struct {
+	PowerT float64
+}
+
+// LearningRate is for LRScheduler
+func (sch *InverseScaling) LearningRate(stepSize, timeStep float64) float64 {
+	return stepSize / math.Pow(timeStep, sch.PowerT)
+}
+
+// cosineAnnealing returns the learning rate going from start to end for pct in [0,1]
+func cosineAnnealing(start, end, pct float64) float64 {
+	return end + (start-end)*(1+math.Cos(math.Pi*pct))/2
+}
+
+// CosineAnnealingWarmRestarts anneals the learning rate from StepSize to MinStepSize with a cosine over T0 updates, then restarts
+// with periods multiplied by TMult (SGDR https://arxiv.org/abs/1608.03983)
+type CosineAnnealingWarmRestarts struct {
+	T0                 int
+	TMult, MinStepSize float64
+}
+
+// LearningRate is for LRScheduler
+func (sch *CosineAnnealingWarmRestarts) LearningRate(stepSize, timeStep float64) float64 {
+	t, T0 := timeStep-1, float64(sch.T0)
+	var tCur, period float64
+	if sch.TMult <= 1 {
+		tCur, period = math.Mod(t, T0), T0
+	} else {
+		n := math.Floor(math.Log(1+t/T0*(sch.TMult-1)) / math.Log(sch.TMult))
+		period = T0 * math.Pow(sch.TMult, n)
+		tCur = t - T0*(math.Pow(sch.TMult, n)-1)/(sch.TMult-1)
+	}
+	return cosineAnnealing(stepSize, sch.MinStepSize, tCur/period)
+}
+
+// OneCycle is the 1cycle policy (https://arxiv.org/abs/1708.07120) where StepSize is the max learning rate:
+// the learning rate anneals from StepSize/DivFactor to StepSize over the first PctStart of TotalSteps updates,
+// then to StepSize/DivFactor/FinalDivFactor
+type OneCycle struct {
+	TotalSteps                          int
+	PctStart, DivFactor, FinalDivFactor float64
+}
+
+// NewOneCycle returns a *OneCycle with PctStart .3, DivFactor 25 and FinalDivFactor 1e4
+func NewOneCycle(totalSteps int) *OneCycle {
+	return &OneCycle{TotalSteps: totalSteps, PctStart: .3, DivFactor: 25, FinalDivFactor: 1e4}
+}
+
+// LearningRate is for LRScheduler
+func (sch *OneCycle) LearningRate(stepSize, timeStep float64) float64 {
+	initial := stepSize / sch.DivFactor
+	total := float64(sch.TotalSteps)
+	up := sch.PctStart * total
+	t := math.Min(timeStep, total)
+	if t <= up {
+		return cosineAnnealing(initial, stepSize, t/up)
+	}
+	return cosineAnnealing(stepSize, initial/sch.FinalDivFactor, (t-up)/(total-up))
+}
+
+// ReduceOnPlateau multiplies the learning rate by Factor when the observed loss has not decreased by at least Threshold (relative)
+// for more than Patience epochs, then waits Cooldown epochs before counting again. the learning rate is at least MinStepSize
+type ReduceOnPlateau struct {
+	Factor, Threshold, MinStepSize float64
+	Patience, Cooldown             int
+
+	// running values
+	best                                  float64
+	hasBest                               bool
+	badEpochs, cooldownEpochs, reductions int
+}
+
+// NewReduceOnPlateau returns a *ReduceOnPlateau with Factor .1, Threshold 1e-4 and Patience 10
+func NewReduceOnPlateau() *ReduceOnPlateau {
+	return &ReduceOnPlateau{Factor: .1, Threshold: 1e-4, Patience: 10}
+}
+
+// LearningRate is for LRScheduler
+func (sch *ReduceOnPlateau) LearningRate(stepSize, timeStep float64) float64 {
+	return math.Max(stepSize*math.Pow(sch.Factor, float64(sch.reductions)), sch.MinStepSize)
+}
+
+// ObserveLoss is for LossObserver
+func (sch *ReduceOnPlateau) ObserveLoss(loss float64) {
+	if !sch.hasBest || loss < sch.best-math.Abs(sch.best)*sch.Threshold {
+		sch.best, sch.hasBest, sch.badEpochs = loss, true, 0
+	} else {
+		sch.badEpochs++
+	}
+	if sch.cooldownEpochs > 0 {
+		sch.cooldownEpochs--
+		sch.badEpochs = 0
+	}
+	if sch.badEpochs > sch.Patience {
+		sch.reductions++
+		sch.cooldownEpochs, sch.badEpochs = sch.Cooldown, 0
+	}
+}
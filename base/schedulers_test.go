@@ -0,0 +1,44 @@
+package base
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestLRSchedulers(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		scheduler LRScheduler
+		timeStep  float64
+		expected  float64
+	}{
+		{"step", &StepDecay{Steps: 10, Gamma: .5}, 10, 1},
+		{"step", &StepDecay{Steps: 10, Gamma: .5}, 21, .25},
+		{"exponential", &ExponentialDecay{Gamma: .9}, 3, .81},
+		{"invscaling", &InverseScaling{PowerT: .5}, 4, .5},
+		{"cosine", &CosineAnnealingWarmRestarts{T0: 10, TMult: 1}, 6, .5},
+		{"cosine restart", &CosineAnnealingWarmRestarts{T0: 10, TMult: 1}, 11, 1},
+		{"cosine tmult", &CosineAnnealingWarmRestarts{T0: 10, TMult: 2}, 11, 1},
+		{"cosine tmult", &CosineAnnealingWarmRestarts{T0: 10, TMult: 2, MinStepSize: .2}, 21, .6},
+		{"onecycle", NewOneCycle(100), 30, 1},
+		{"onecycle", NewOneCycle(100), 65, (1 + 4e-6) / 2},
+		{"onecycle end", NewOneCycle(100), 200, 4e-6},
+	} {
+		if lr := tc.scheduler.LearningRate(1, tc.timeStep); math.Abs(lr-tc.expected) > 1e-12 {
+			t.Errorf("%s at %g: expected %g, got %g", tc.name, tc.timeStep, tc.expected, lr)
+		}
+	}
+
+	plateau := &ReduceOnPlateau{Factor: .5, Patience: 1, Cooldown: 1}
+	var lrs []float64
+	for _, loss := range []float64{3, 2, 2, 2, 2, 2, 2} {
+		plateau.ObserveLoss(loss)
+		lrs = append(lrs, plateau.LearningRate(1, 1))
+	}
+	// reduced after 2 epochs without improvement, then after a cooldown epoch and 2 more
+	if expected := []float64{1, 1, 1, .5, .5, .5, .25}; !floats.Equal(lrs, expected) {
+		t.Errorf("plateau: expected %v, got %v", expected, lrs)
+	}
+}
@@ -0,0 +1,321 @@
+package base
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// CSR is a compressed sparse row matrix. it implements mat.Matrix.
+// non-zero values of row i are Data[Indptr[i]:Indptr[i+1]], their columns are Indices[Indptr[i]:Indptr[i+1]] in increasing order
+type CSR struct {
+	Rows, Columns int
+	Indptr        []int
+	Indices       []int
+	Data          []float64
+}
+
+// NewCSR returns a *CSR. indptr,indices and data can be nil to create an empty matrix
+func NewCSR(r, c int, indptr, indices []int, data []float64) *CSR {
+	if indptr == nil {
+		indptr = make([]int, r+1)
+	}
+	if len(indptr) != r+1 || len(indices) != len(data) {
+		panic("ErrShape")
+	}
+	return &CSR{Rows: r, Columns: c, Indptr: indptr, Indices: indices, Data: data}
+}
+
+// NewCSRFromMatrix returns a *CSR with the non-zero elements of m
+func NewCSRFromMatrix(m mat.Matrix) *CSR {
+	r, c := m.Dims()
+	s := NewCSR(r, c, nil, nil, nil)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if v := m.At(i, j); v != 0. {
+				s.Indices = append(s.Indices, j)
+				s.Data = append(s.Data, v)
+			}
+		}
+		s.Indptr[i+1] = len(s.Data)
+	}
+	return s
+}
+
+// Dims for CSR
+func (m *CSR) Dims() (int, int) { return m.Rows, m.Columns }
+
+// At for CSR
+func (m *CSR) At(i, j int) float64 {
+	if i < 0 || i >= m.Rows || j < 0 || j >= m.Columns {
+		panic(mat.ErrIndexOutOfRange)
+	}
+	start, end := m.Indptr[i], m.Indptr[i+1]
+	k := start + sort.SearchInts(m.Indices[start:end], j)
+	if k < end && m.Indices[k] == j {
+		return m.Data[k]
+	}
+	return 0.
+}
+
+// T for CSR returns a *CSC sharing the same data
+func (m *CSR) T() mat.Matrix {
+	return &CSC{Rows: m.Columns, Columns: m.Rows, Indptr: m.Indptr, Indices: m.Indices, Data: m.Data}
+}
+
+// NNZ returns the number of stored values
+func (m *CSR) NNZ() int { return m.Indptr[m.Rows] - m.Indptr[0] }
+
+// AppendRow adds a row. indices must be in increasing order
+func (m *CSR) AppendRow(indices []int, data []float64) {
+	if len(indices) != len(data) {
+		panic("ErrShape")
+	}
+	m.Indices = append(m.Indices, indices...)
+	m.Data = append(m.Data, data...)
+	m.Indptr = append(m.Indptr, m.Indptr[m.Rows]+len(data))
+	m.Rows++
+}
+
+// AppendMapRow adds a row from the non-zero values of a map of column index to value
+func (m *CSR) AppendMapRow(values map[int]float64) {
+	indices := make([]int, 0, len(values))
+	for j, v := range values {
+		if v != 0 {
+			indices = append(indices, j)
+		}
+	}
+	sort.Ints(indices)
+	data := make([]float64, len(indices))
+	for k, j := range indices {
+		data[k] = values[j]
+	}
+	m.AppendRow(indices, data)
+}
+
+// RowSlice returns a *CSR with rows i..k-1 sharing the same data
+func (m *CSR) RowSlice(i, k int) *CSR {
+	if i < 0 || k > m.Rows || k < i {
+		panic(fmt.Errorf("RowSlice %d,%d out of range (%d rows)", i, k, m.Rows))
+	}
+	return &CSR{Rows: k - i, Columns: m.Columns, Indptr: m.Indptr[i : k+1], Indices: m.Indices, Data: m.Data}
+}
+
+// RowsSubset returns a new *CSR made of rows of m
+func (m *CSR) RowsSubset(rows []int) *CSR {
+	s := NewCSR(0, m.Columns, nil, nil, nil)
+	for _, i := range rows {
+		start, end := m.Indptr[i], m.Indptr[i+1]
+		s.AppendRow(m.Indices[start:end], m.Data[start:end])
+	}
+	return s
+}
+
+// Copy returns a copy of m
+func (m *CSR) Copy() *CSR {
+	start, end := m.Indptr[0], m.Indptr[m.Rows]
+	s := &CSR{Rows: m.Rows, Columns: m.Columns, Indptr: make([]int, m.Rows+1)}
+	for i, p := range m.Indptr {
+		s.Indptr[i] = p - start
+	}
+	s.Indices = append([]int(nil), m.Indices[start:end]...)
+	s.Data = append([]float64(nil), m.Data[start:end]...)
+	return s
+}
+
+// OnesPrepended returns a new *CSR with an initial column of ones added
+func (m *CSR) OnesPrepended() *CSR {
+	s := NewCSR(0, m.Columns+1, nil, nil, nil)
+	indices := make([]int, 0, m.Columns+1)
+	data := make([]float64, 0, m.Columns+1)
+	for i := 0; i < m.Rows; i++ {
+		start, end := m.Indptr[i], m.Indptr[i+1]
+		indices, data = append(indices[:0], 0), append(data[:0], 1.)
+		for k := start; k < end; k++ {
+			indices = append(indices, 1+m.Indices[k])
+			data = append(data, m.Data[k])
+		}
+		s.AppendRow(indices, data)
+	}
+	return s
+}
+
+// ToDense returns a *mat.Dense copy of m
+func (m *CSR) ToDense() *mat.Dense {
+	d := mat.NewDense(m.Rows, m.Columns, nil)
+	for i := 0; i < m.Rows; i++ {
+		row := d.RawRowView(i)
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			row[m.Indices[k]] = m.Data[k]
+		}
+	}
+	return d
+}
+
+// ToCSC returns a compressed sparse column copy of m
+func (m *CSR) ToCSC() *CSC {
+	return m.T().(*CSC).transposeCopy()
+}
+
+// ColumnsMeanAndVar returns mean and (biased) variance of each column of m
+func (m *CSR) ColumnsMeanAndVar() (mean, variance []float64) {
+	mean, variance = make([]float64, m.Columns), make([]float64, m.Columns)
+	nnz := make([]int, m.Columns)
+	for k := m.Indptr[0]; k < m.Indptr[m.Rows]; k++ {
+		mean[m.Indices[k]] += m.Data[k]
+		nnz[m.Indices[k]]++
+	}
+	n := float64(m.Rows)
+	for j := range mean {
+		mean[j] /= n
+	}
+	for k := m.Indptr[0]; k < m.Indptr[m.Rows]; k++ {
+		d := m.Data[k] - mean[m.Indices[k]]
+		variance[m.Indices[k]] += d * d
+	}
+	for j := range variance {
+		// implicit zeros contribute mean² each
+		variance[j] = (variance[j] + float64(m.Rows-nnz[j])*mean[j]*mean[j]) / n
+	}
+	return
+}
+
+// ScaleColumns multiplies each column j of m by scale[j] in place
+func (m *CSR) ScaleColumns(scale []float64) {
+	for k := m.Indptr[0]; k < m.Indptr[m.Rows]; k++ {
+		m.Data[k] *= scale[m.Indices[k]]
+	}
+}
+
+// CSC is a compressed sparse column matrix. it implements mat.Matrix.
+// non-zero values of column j are Data[Indptr[j]:Indptr[j+1]], their rows are Indices[Indptr[j]:Indptr[j+1]] in increasing order
+type CSC struct {
+	Rows, Columns int
+	Indptr        []int
+	Indices       []int
+	Data          []float64
+}
+
+// Dims for CSC
+func (m *CSC) Dims() (int, int) { return m.Rows, m.Columns }
+
+// At for CSC
+func (m *CSC) At(i, j int) float64 {
+	if i < 0 || i >= m.Rows || j < 0 || j >= m.Columns {
+		panic(mat.ErrIndexOutOfRange)
+	}
+	start, end := m.Indptr[j], m.Indptr[j+1]
+	k := start + sort.SearchInts(m.Indices[start:end], i)
+	if k < end && m.Indices[k] == i {
+		return m.Data[k]
+	}
+	return 0.
+}
+
+// T for CSC returns a *CSR sharing the same data
+func (m *CSC) T() mat.Matrix {
+	return &CSR{Rows: m.Columns, Columns: m.Rows, Indptr: m.Indptr, Indices: m.Indices, Data: m.Data}
+}
+
+// NNZ returns the number of stored values
+func (m *CSC) NNZ() int { return m.Indptr[m.Columns] - m.Indptr[0] }
+
+// ToCSR returns a compressed sparse row copy of m
+func (m *CSC) ToCSR() *CSR {
+	return m.transposeCopy().T().(*CSR)
+}
+
+// ToDense returns a *mat.Dense copy of m
+func (m *CSC) ToDense() *mat.Dense {
+	d := mat.NewDense(m.Rows, m.Columns, nil)
+	for j := 0; j < m.Columns; j++ {
+		for k := m.Indptr[j]; k < m.Indptr[j+1]; k++ {
+			d.Set(m.Indices[k], j, m.Data[k])
+		}
+	}
+	return d
+}
+
+// transposeCopy returns m.T() stored as a new *CSC
+func (m *CSC) transposeCopy() *CSC {
+	t := &CSC{Rows: m.Columns, Columns: m.Rows, Indptr: make([]int, m.Rows+1)}
+	nnz := m.NNZ()
+	t.Indices, t.Data = make([]int, nnz), make([]float64, nnz)
+	for k := m.Indptr[0]; k < m.Indptr[m.Columns]; k++ {
+		t.Indptr[m.Indices[k]+1]++
+	}
+	for i := 0; i < m.Rows; i++ {
+		t.Indptr[i+1] += t.Indptr[i]
+	}
+	next := append([]int(nil), t.Indptr[:m.Rows]...)
+	for j := 0; j < m.Columns; j++ {
+		for k := m.Indptr[j]; k < m.Indptr[j+1]; k++ {
+			i := m.Indices[k]
+			t.Indices[next[i]] = j
+			t.Data[next[i]] = m.Data[k]
+			next[i]++
+		}
+	}
+	return t
+}
+
+// MatMul puts a dot b into dst. it uses sparse kernels when a is a *CSR or a *CSC (as is X.T() for a *CSR X), else dst.Mul
+func MatMul(dst *mat.Dense, a, b mat.Matrix) {
+	switch A := a.(type) {
+	case *CSR:
+		MatDimsCheck(".", dst, a, b)
+		B := denseOf(b)
+		for i := 0; i < A.Rows; i++ {
+			row := dst.RawRowView(i)
+			for o := range row {
+				row[o] = 0.
+			}
+			for k := A.Indptr[i]; k < A.Indptr[i+1]; k++ {
+				v := A.Data[k]
+				for o, bjo := range B.RawRowView(A.Indices[k]) {
+					row[o] += v * bjo
+				}
+			}
+		}
+	case *CSC:
+		MatDimsCheck(".", dst, a, b)
+		B := denseOf(b)
+		dst.Apply(func(_, _ int, _ float64) float64 { return 0. }, dst)
+		for j := 0; j < A.Columns; j++ {
+			brow := B.RawRowView(j)
+			for k := A.Indptr[j]; k < A.Indptr[j+1]; k++ {
+				row := dst.RawRowView(A.Indices[k])
+				v := A.Data[k]
+				for o, bjo := range brow {
+					row[o] += v * bjo
+				}
+			}
+		}
+	default:
+		dst.Mul(a, b)
+	}
+}
+
+// denseOf returns m if it's a *mat.Dense else a dense copy of m
+func denseOf(m mat.Matrix) *mat.Dense {
+	if d, ok := m.(*mat.Dense); ok {
+		return d
+	}
+	return mat.DenseCopyOf(m)
+}
+
+// MatShuffleCSR shuffles the rows of X and Y matrices, moving row perm[i] to row i. perm defaults to a rand.Perm
+func MatShuffleCSR(X *CSR, Y *mat.Dense, perm []int) {
+	if perm == nil {
+		perm = rand.Perm(X.Rows)
+	}
+	*X = *X.RowsSubset(perm)
+	_, nOutputs := Y.Dims()
+	Yperm := mat.NewDense(X.Rows, nOutputs, nil)
+	for i, src := range perm {
+		Yperm.SetRow(i, Y.RawRowView(src))
+	}
+	Y.Copy(Yperm)
+}
@@ -0,0 +1,53 @@
+package base
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCSR(t *testing.T) {
+	D := mat.NewDense(3, 4, []float64{1, 0, 2, 0, 0, 0, 0, 3, 4, 5, 0, 0})
+	X := NewCSRFromMatrix(D)
+	if X.NNZ() != 5 {
+		t.Errorf("NNZ:%d expected:%d", X.NNZ(), 5)
+	}
+	if !mat.Equal(X, D) || !mat.Equal(X.ToDense(), D) || !mat.Equal(X.ToCSC(), D) || !mat.Equal(X.ToCSC().ToCSR(), D) {
+		t.Errorf("CSR/CSC conversion failed")
+	}
+	if !mat.Equal(X.T(), D.T()) {
+		t.Errorf("CSR.T failed")
+	}
+	if !mat.Equal(X.RowSlice(1, 3), D.Slice(1, 3, 0, 4)) || !mat.Equal(X.RowSlice(1, 3).Copy(), D.Slice(1, 3, 0, 4)) {
+		t.Errorf("RowSlice failed")
+	}
+	B := mat.NewDense(4, 2, []float64{1, 2, 3, 4, 5, 6, 7, 8})
+	expected, actual := &mat.Dense{}, mat.NewDense(3, 2, nil)
+	expected.Mul(D, B)
+	MatMul(actual, X, B)
+	if !mat.Equal(expected, actual) {
+		t.Errorf("MatMul CSR expected:\n%g\ngot:\n%g", mat.Formatted(expected), mat.Formatted(actual))
+	}
+	C := mat.NewDense(3, 2, []float64{1, 2, 3, 4, 5, 6})
+	expected, actual = &mat.Dense{}, mat.NewDense(4, 2, nil)
+	expected.Mul(D.T(), C)
+	MatMul(actual, X.T(), C)
+	if !mat.Equal(expected, actual) {
+		t.Errorf("MatMul CSC expected:\n%g\ngot:\n%g", mat.Formatted(expected), mat.Formatted(actual))
+	}
+	mean, variance := X.ColumnsMeanAndVar()
+	if !floats.EqualApprox(mean, []float64{5. / 3, 5. / 3, 2. / 3, 1}, 1e-12) ||
+		!floats.EqualApprox(variance, []float64{26. / 9, 50. / 9, 8. / 9, 2}, 1e-12) {
+		t.Errorf("ColumnsMeanAndVar got %g %g", mean, variance)
+	}
+}
+
+func ExampleCSR_OnesPrepended() {
+	X := NewCSRFromMatrix(mat.NewDense(2, 3, []float64{0, 2, 0, 3, 0, 0}))
+	fmt.Printf("%g\n", mat.Formatted(X.OnesPrepended()))
+	// Output:
+	// ⎡1  0  2  0⎤
+	// ⎣1  3  0  0⎦
+}
@@ -16,6 +16,7 @@ import (
 // bias : float64 or []float64 or mat.Matrix, optional (default=0.0) The bias term in the underlying linear model.
 // effective_rank : int , optional (default=None) currently unused
 // tail_strength : float between 0.0 and 1.0, optional (default=0.5) currently unused
+// noise : float64, optional (default=0.0) The standard deviation of the gaussian noise applied to the output.
 // shuffle : boolean, optional (default=True)
 // coef : boolean. the coefficients of the underlying linear model are returned regardless its value.
 // random_state : *math.Rand optional (default=nil)
@@ -90,6 +91,14 @@ func MakeRegression(kwargs map[string]interface{}) (X, y, Coef *mat.Dense) {
 		}
 
 	}
+	if v, ok := kwargs["noise"]; ok {
+		ymat := y.RawMatrix()
+		for yi := 0; yi < ymat.Rows*ymat.Stride; yi += ymat.Stride {
+			for yj := 0; yj < ymat.Cols; yj++ {
+				ymat.Data[yi+yj] += v.(float64) * rnd()
+			}
+		}
+	}
 	return
 }
 
@@ -0,0 +1,165 @@
+package featureExtraction
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/mat"
+)
+
+// DictVectorizer transforms records (maps of feature name to value) to matrices.
+// numeric and bool values are kept as is, string values (and elements of []string values) are one-hot encoded as "name=value" features
+type DictVectorizer struct {
+	Separator    string
+	FeatureNames []string
+	Vocabulary   map[string]int
+
+	// oneHot maps one-hot features indices to their original key and string value
+	oneHot map[int][2]string
+}
+
+// NewDictVectorizer returns a *DictVectorizer using "=" separator
+func NewDictVectorizer() *DictVectorizer {
+	return &DictVectorizer{Separator: "="}
+}
+
+// Fit learns FeatureNames and Vocabulary from records. FeatureNames are sorted and can be used as datasets.MLDataset.FeatureNames
+func (m *DictVectorizer) Fit(records []map[string]interface{}) *DictVectorizer {
+	oneHot := make(map[string][2]string)
+	seen := make(map[string]bool)
+	for _, record := range records {
+		for k, v := range record {
+			m.eachFeature(k, v, func(name string, _ float64, strValue string, isOneHot bool) {
+				seen[name] = true
+				if isOneHot {
+					oneHot[name] = [2]string{k, strValue}
+				}
+			})
+		}
+	}
+	m.FeatureNames = make([]string, 0, len(seen))
+	for name := range seen {
+		m.FeatureNames = append(m.FeatureNames, name)
+	}
+	sort.Strings(m.FeatureNames)
+	m.Vocabulary = make(map[string]int, len(m.FeatureNames))
+	m.oneHot = make(map[int][2]string)
+	for j, name := range m.FeatureNames {
+		m.Vocabulary[name] = j
+		if kv, ok := oneHot[name]; ok {
+			m.oneHot[j] = kv
+		}
+	}
+	return m
+}
+
+// eachFeature calls f for each feature of the record entry k:v. features unseen at Fit are not filtered here
+func (m *DictVectorizer) eachFeature(k string, v interface{}, f func(name string, value float64, strValue string, isOneHot bool)) {
+	switch vv := v.(type) {
+	case string:
+		f(k+m.Separator+vv, 1, vv, true)
+	case []string:
+		for _, s := range vv {
+			f(k+m.Separator+s, 1, s, true)
+		}
+	case nil:
+	default:
+		f(k, toFloat(k, v), "", false)
+	}
+}
+
+// toFloat converts numeric and bool values to float64
+func toFloat(k string, v interface{}) float64 {
+	switch vv := v.(type) {
+	case float64:
+		return vv
+	case float32:
+		return float64(vv)
+	case int:
+		return float64(vv)
+	case int64:
+		return float64(vv)
+	case int32:
+		return float64(vv)
+	case uint:
+		return float64(vv)
+	case uint64:
+		return float64(vv)
+	case uint32:
+		return float64(vv)
+	case bool:
+		if vv {
+			return 1
+		}
+		return 0
+	default:
+		panic(fmt.Errorf("unsupported type %T for feature %s", v, k))
+	}
+}
+
+// TransformSparse returns records as a *base.CSR. features unseen during Fit are ignored
+func (m *DictVectorizer) TransformSparse(records []map[string]interface{}) *base.CSR {
+	if m.Vocabulary == nil {
+		panic("DictVectorizer: Vocabulary is nil. call Fit first")
+	}
+	X := base.NewCSR(0, len(m.FeatureNames), nil, nil, nil)
+	for _, record := range records {
+		values := make(map[int]float64)
+		for k, v := range record {
+			m.eachFeature(k, v, func(name string, value float64, _ string, _ bool) {
+				if j, ok := m.Vocabulary[name]; ok {
+					values[j] += value
+				}
+			})
+		}
+		X.AppendMapRow(values)
+	}
+	return X
+}
+
+// Transform returns records as a *mat.Dense
+func (m *DictVectorizer) Transform(records []map[string]interface{}) *mat.Dense {
+	return m.TransformSparse(records).ToDense()
+}
+
+// FitTransform fits and transforms records
+func (m *DictVectorizer) FitTransform(records []map[string]interface{}) *mat.Dense {
+	return m.Fit(records).Transform(records)
+}
+
+// GetFeatureNames returns FeatureNames
+func (m *DictVectorizer) GetFeatureNames() []string { return m.FeatureNames }
+
+// InverseTransform converts X back to records. one-hot features are restored as string values (or []string if several are set for the same key). zeros are omitted
+func (m *DictVectorizer) InverseTransform(X mat.Matrix) []map[string]interface{} {
+	nSamples, nFeatures := X.Dims()
+	if nFeatures != len(m.FeatureNames) {
+		panic(fmt.Errorf("DictVectorizer: X has %d features, expected %d", nFeatures, len(m.FeatureNames)))
+	}
+	records := make([]map[string]interface{}, nSamples)
+	for i := range records {
+		record := make(map[string]interface{})
+		for j, name := range m.FeatureNames {
+			v := X.At(i, j)
+			if v == 0 {
+				continue
+			}
+			kv, isOneHot := m.oneHot[j]
+			if !isOneHot {
+				record[name] = v
+				continue
+			}
+			switch prev := record[kv[0]].(type) {
+			case nil:
+				record[kv[0]] = kv[1]
+			case string:
+				record[kv[0]] = []string{prev, kv[1]}
+			case []string:
+				record[kv[0]] = append(prev, kv[1])
+			}
+		}
+		records[i] = record
+	}
+	return records
+}
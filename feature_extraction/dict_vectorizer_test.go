@@ -0,0 +1,58 @@
+package featureExtraction
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func ExampleDictVectorizer() {
+	records := []map[string]interface{}{
+		{"city": "Paris", "temperature": 12, "rain": true},
+		{"city": "London", "temperature": 9.5},
+	}
+	dv := NewDictVectorizer()
+	X := dv.FitTransform(records)
+	fmt.Println(dv.GetFeatureNames())
+	fmt.Printf("%g\n", mat.Formatted(X))
+	fmt.Println(dv.InverseTransform(X))
+	// Output:
+	// [city=London city=Paris rain temperature]
+	// ⎡  0    1    1   12⎤
+	// ⎣  1    0    0  9.5⎦
+	// [map[city:Paris rain:1 temperature:12] map[city:London temperature:9.5]]
+}
+
+func TestDictVectorizer(t *testing.T) {
+	dv := NewDictVectorizer()
+	dv.Fit([]map[string]interface{}{{"tags": []string{"a", "b"}, "n": 1}})
+	X := dv.TransformSparse([]map[string]interface{}{{"tags": []string{"b"}, "n": 2, "unseen": 3.}})
+	if !mat.Equal(X, mat.NewDense(1, 3, []float64{2, 0, 1})) {
+		t.Errorf("unexpected %g", mat.Formatted(X))
+	}
+	records := dv.InverseTransform(mat.NewDense(1, 3, []float64{0, 1, 1}))
+	if !reflect.DeepEqual(records[0], map[string]interface{}{"tags": []string{"a", "b"}}) {
+		t.Errorf("unexpected inverse %v", records)
+	}
+}
+
+func TestFeatureHasher(t *testing.T) {
+	h := NewFeatureHasher()
+	h.NFeatures = 32
+	// 32 bits FNV-1a: "city=Paris" is 8 (+), "temperature" 21 (-), "a" 12 (-), "b" 5 (-)
+	X := h.Transform([]map[string]interface{}{{"city": "Paris", "temperature": 12}})
+	if !reflect.DeepEqual(X.Indices, []int{8, 21}) || !reflect.DeepEqual(X.Data, []float64{1, -12}) {
+		t.Errorf("unexpected hashed row %v %g", X.Indices, X.Data)
+	}
+	X = h.TransformStrings([][]string{{"a", "b", "a"}})
+	if !reflect.DeepEqual(X.Indices, []int{5, 12}) || !reflect.DeepEqual(X.Data, []float64{-1, -2}) {
+		t.Errorf("unexpected hashed row %v %g", X.Indices, X.Data)
+	}
+	h.AlternateSign = false
+	X = h.TransformStrings([][]string{{"a", "b", "a"}})
+	if !reflect.DeepEqual(X.Data, []float64{1, 2}) {
+		t.Errorf("unexpected unsigned values %g", X.Data)
+	}
+}
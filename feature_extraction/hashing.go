@@ -0,0 +1,83 @@
+package featureExtraction
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/gcla/sklearn/base"
+)
+
+// FeatureHasher implements the hashing trick: feature names are hashed to column indices so no vocabulary is kept.
+// string values are hashed as "name=value" with value 1, like DictVectorizer one-hot features
+type FeatureHasher struct {
+	NFeatures int
+	// AlternateSign gives a hash-dependent sign to features so that collisions tend to cancel out
+	AlternateSign bool
+}
+
+// NewFeatureHasher returns a *FeatureHasher with 2^20 features and AlternateSign
+func NewFeatureHasher() *FeatureHasher {
+	return &FeatureHasher{NFeatures: 1 << 20, AlternateSign: true}
+}
+
+// Hash returns the column index and sign for feature name
+func (m *FeatureHasher) Hash(name string) (int, float64) {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	sum := h.Sum32()
+	sign := 1.
+	if m.AlternateSign && sum&(1<<31) != 0 {
+		sign = -1.
+	}
+	return int(sum % uint32(m.NFeatures)), sign
+}
+
+// Transform hashes records to a *base.CSR
+func (m *FeatureHasher) Transform(records []map[string]interface{}) *base.CSR {
+	X := base.NewCSR(0, m.NFeatures, nil, nil, nil)
+	for _, record := range records {
+		values := make(map[int]float64)
+		add := func(name string, value float64) {
+			j, sign := m.Hash(name)
+			values[j] += sign * value
+		}
+		for k, v := range record {
+			switch vv := v.(type) {
+			case string:
+				add(k+"="+vv, 1)
+			case []string:
+				for _, s := range vv {
+					add(k+"="+s, 1)
+				}
+			case nil:
+			default:
+				add(k, toFloat(k, v))
+			}
+		}
+		X.AppendMapRow(values)
+	}
+	return X
+}
+
+// TransformStrings hashes samples given as lists of feature names (each occurrence counting for 1) to a *base.CSR
+func (m *FeatureHasher) TransformStrings(samples [][]string) *base.CSR {
+	X := base.NewCSR(0, m.NFeatures, nil, nil, nil)
+	for _, names := range samples {
+		values := make(map[int]float64)
+		for _, name := range names {
+			j, sign := m.Hash(name)
+			values[j] += sign
+		}
+		X.AppendMapRow(values)
+	}
+	return X
+}
+
+// GetFeatureNames returns "hash_<j>" names for the NFeatures columns
+func (m *FeatureHasher) GetFeatureNames() []string {
+	names := make([]string, m.NFeatures)
+	for j := range names {
+		names[j] = fmt.Sprintf("hash_%d", j)
+	}
+	return names
+}
@@ -0,0 +1,375 @@
+package text
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gcla/sklearn/base"
+	"github.com/gcla/sklearn/feature_extraction"
+)
+
+// DefaultTokenPattern selects tokens of 2 or more alphanumeric characters
+const DefaultTokenPattern = `[\p{L}\p{N}_]{2,}`
+
+// AnalyzerOptions are the options used to split a document into features. they're shared by all vectorizers
+type AnalyzerOptions struct {
+	// Analyzer is "word" or "char"
+	Analyzer  string
+	Lowercase bool
+	// TokenPattern is the regexp selecting word tokens. defaults to DefaultTokenPattern
+	TokenPattern string
+	// NGramRange is the min and max n for n-grams. {1,1} means unigrams only
+	NGramRange [2]int
+	// StopWords are removed from word tokens before n-grams are built. can be EnglishStopWords
+	StopWords []string
+
+	tokenRegexp *regexp.Regexp
+}
+
+// NewAnalyzerOptions returns default options: lowercased word unigrams
+func NewAnalyzerOptions() AnalyzerOptions {
+	return AnalyzerOptions{Analyzer: "word", Lowercase: true, TokenPattern: DefaultTokenPattern, NGramRange: [2]int{1, 1}}
+}
+
+// Analyze returns the features of doc
+func (a *AnalyzerOptions) Analyze(doc string) []string {
+	return a.analyzer()(doc)
+}
+
+// analyzer returns a function returning the features of a doc, so that vectorizers prepare the options once per call
+func (a *AnalyzerOptions) analyzer() func(doc string) []string {
+	minN, maxN := a.NGramRange[0], a.NGramRange[1]
+	if minN < 1 {
+		minN = 1
+	}
+	if maxN < minN {
+		maxN = minN
+	}
+	lower := func(doc string) string {
+		if a.Lowercase {
+			return strings.ToLower(doc)
+		}
+		return doc
+	}
+	switch a.Analyzer {
+	case "", "word":
+		tokenize := a.tokenizer()
+		return func(doc string) []string { return wordNGrams(tokenize(lower(doc)), minN, maxN) }
+	case "char":
+		return func(doc string) []string {
+			return charNGrams(strings.Join(strings.Fields(lower(doc)), " "), minN, maxN)
+		}
+	default:
+		panic(fmt.Errorf("unknown analyzer %s", a.Analyzer))
+	}
+}
+
+// tokenizer returns a function splitting a doc into tokens without StopWords
+func (a *AnalyzerOptions) tokenizer() func(doc string) []string {
+	pattern := a.TokenPattern
+	if pattern == "" {
+		pattern = DefaultTokenPattern
+	}
+	if a.tokenRegexp == nil || a.tokenRegexp.String() != pattern {
+		a.tokenRegexp = regexp.MustCompile(pattern)
+	}
+	tokenRegexp := a.tokenRegexp
+	if len(a.StopWords) == 0 {
+		return func(doc string) []string { return tokenRegexp.FindAllString(doc, -1) }
+	}
+	stopWords := make(map[string]bool, len(a.StopWords))
+	for _, w := range a.StopWords {
+		stopWords[w] = true
+	}
+	return func(doc string) []string {
+		tokens := tokenRegexp.FindAllString(doc, -1)
+		kept := tokens[:0]
+		for _, t := range tokens {
+			if !stopWords[t] {
+				kept = append(kept, t)
+			}
+		}
+		return kept
+	}
+}
+
+func wordNGrams(tokens []string, minN, maxN int) []string {
+	if minN == 1 && maxN == 1 {
+		return tokens
+	}
+	ngrams := make([]string, 0, len(tokens)*(maxN-minN+1))
+	for n := minN; n <= maxN; n++ {
+		for i := 0; i+n <= len(tokens); i++ {
+			ngrams = append(ngrams, strings.Join(tokens[i:i+n], " "))
+		}
+	}
+	return ngrams
+}
+
+func charNGrams(doc string, minN, maxN int) []string {
+	runes := []rune(doc)
+	ngrams := make([]string, 0, len(runes)*(maxN-minN+1))
+	for n := minN; n <= maxN; n++ {
+		for i := 0; i+n <= len(runes); i++ {
+			ngrams = append(ngrams, string(runes[i:i+n]))
+		}
+	}
+	return ngrams
+}
+
+// CountVectorizer converts documents to a matrix of token counts
+type CountVectorizer struct {
+	AnalyzerOptions
+	// MinDf and MaxDf bound the proportion of documents containing kept terms, in [0,1]. MaxDf is ignored if 0
+	MinDf, MaxDf float64
+	// MinDfCount and MaxDfCount bound the number of documents containing kept terms. MaxDfCount is ignored if 0
+	MinDfCount, MaxDfCount int
+	// MaxFeatures keeps only the most frequent terms if > 0
+	MaxFeatures int
+	// Binary sets non-zero counts to 1
+	Binary bool
+	// Vocabulary maps terms to feature indices. it's built by Fit but can also be set before calling Transform
+	Vocabulary map[string]int
+}
+
+// NewCountVectorizer returns a *CountVectorizer with defaults
+func NewCountVectorizer() *CountVectorizer {
+	return &CountVectorizer{AnalyzerOptions: NewAnalyzerOptions(), MinDf: 0, MaxDf: 1}
+}
+
+// Fit builds Vocabulary from docs
+func (m *CountVectorizer) Fit(docs []string) *CountVectorizer {
+	nDocs := float64(len(docs))
+	df, tf := make(map[string]int), make(map[string]int)
+	analyze := m.analyzer()
+	for _, doc := range docs {
+		seen := make(map[string]bool)
+		for _, term := range analyze(doc) {
+			tf[term]++
+			if !seen[term] {
+				seen[term] = true
+				df[term]++
+			}
+		}
+	}
+	for _, v := range []float64{m.MinDf, m.MaxDf} {
+		if v < 0 || v > 1 {
+			panic(fmt.Errorf("MinDf and MaxDf are proportions in [0,1], got %g. see MinDfCount and MaxDfCount", v))
+		}
+	}
+	minDf, maxDf := math.Max(m.MinDf*nDocs, float64(m.MinDfCount)), nDocs
+	if m.MaxDf > 0 {
+		maxDf = m.MaxDf * nDocs
+	}
+	if m.MaxDfCount > 0 {
+		maxDf = math.Min(maxDf, float64(m.MaxDfCount))
+	}
+	terms := make([]string, 0, len(df))
+	for term, n := range df {
+		if float64(n) >= minDf && float64(n) <= maxDf {
+			terms = append(terms, term)
+		}
+	}
+	if m.MaxFeatures > 0 && len(terms) > m.MaxFeatures {
+		sort.Slice(terms, func(i, j int) bool {
+			if tf[terms[i]] != tf[terms[j]] {
+				return tf[terms[i]] > tf[terms[j]]
+			}
+			return terms[i] < terms[j]
+		})
+		terms = terms[:m.MaxFeatures]
+	}
+	sort.Strings(terms)
+	m.Vocabulary = make(map[string]int, len(terms))
+	for i, term := range terms {
+		m.Vocabulary[term] = i
+	}
+	return m
+}
+
+// Transform returns the document-term counts for docs as a *base.CSR
+func (m *CountVectorizer) Transform(docs []string) *base.CSR {
+	if m.Vocabulary == nil {
+		panic("CountVectorizer: Vocabulary is nil. call Fit first")
+	}
+	X := base.NewCSR(0, len(m.Vocabulary), nil, nil, nil)
+	analyze := m.analyzer()
+	for _, doc := range docs {
+		counts := make(map[int]float64)
+		for _, term := range analyze(doc) {
+			if j, ok := m.Vocabulary[term]; ok {
+				if m.Binary {
+					counts[j] = 1
+				} else {
+					counts[j]++
+				}
+			}
+		}
+		X.AppendMapRow(counts)
+	}
+	return X
+}
+
+// FitTransform fits and transforms docs
+func (m *CountVectorizer) FitTransform(docs []string) *base.CSR {
+	return m.Fit(docs).Transform(docs)
+}
+
+// GetFeatureNames returns terms ordered by feature index
+func (m *CountVectorizer) GetFeatureNames() []string {
+	names := make([]string, len(m.Vocabulary))
+	for term, j := range m.Vocabulary {
+		names[j] = term
+	}
+	return names
+}
+
+// normalizeRows scales rows of X in place to unit "l1" or "l2" norm. norm "" does nothing
+func normalizeRows(X *base.CSR, norm string) {
+	for i := 0; i < X.Rows; i++ {
+		row := X.Data[X.Indptr[i]:X.Indptr[i+1]]
+		s := 0.
+		switch norm {
+		case "":
+			return
+		case "l1":
+			for _, v := range row {
+				s += math.Abs(v)
+			}
+		case "l2":
+			for _, v := range row {
+				s += v * v
+			}
+			s = math.Sqrt(s)
+		default:
+			panic(fmt.Errorf("unknown norm %s", norm))
+		}
+		if s == 0 {
+			continue
+		}
+		for k := range row {
+			row[k] /= s
+		}
+	}
+}
+
+// TfidfTransformer transforms a count matrix to a tf-idf representation
+type TfidfTransformer struct {
+	// Norm is "l1", "l2" or ""
+	Norm                           string
+	UseIdf, SmoothIdf, SublinearTf bool
+	Idf                            []float64
+}
+
+// NewTfidfTransformer returns a *TfidfTransformer with defaults
+func NewTfidfTransformer() *TfidfTransformer {
+	return &TfidfTransformer{Norm: "l2", UseIdf: true, SmoothIdf: true}
+}
+
+// Fit computes Idf from counts X
+func (m *TfidfTransformer) Fit(X *base.CSR) *TfidfTransformer {
+	nSamples, nFeatures := X.Dims()
+	df := make([]float64, nFeatures)
+	for k := X.Indptr[0]; k < X.Indptr[nSamples]; k++ {
+		if X.Data[k] != 0 {
+			df[X.Indices[k]]++
+		}
+	}
+	n := float64(nSamples)
+	m.Idf = make([]float64, nFeatures)
+	for j := range df {
+		if m.SmoothIdf {
+			m.Idf[j] = math.Log((1+n)/(1+df[j])) + 1
+		} else {
+			m.Idf[j] = math.Log(n/df[j]) + 1
+		}
+	}
+	return m
+}
+
+// Transform returns a tf-idf weighted copy of X
+func (m *TfidfTransformer) Transform(X *base.CSR) *base.CSR {
+	Xout := X.Copy()
+	if m.SublinearTf {
+		for k, v := range Xout.Data {
+			if v > 0 {
+				Xout.Data[k] = 1 + math.Log(v)
+			}
+		}
+	}
+	if m.UseIdf {
+		Xout.ScaleColumns(m.Idf)
+	}
+	normalizeRows(Xout, m.Norm)
+	return Xout
+}
+
+// FitTransform fits and transforms X
+func (m *TfidfTransformer) FitTransform(X *base.CSR) *base.CSR {
+	return m.Fit(X).Transform(X)
+}
+
+// TfidfVectorizer is a CountVectorizer followed by a TfidfTransformer
+type TfidfVectorizer struct {
+	CountVectorizer
+	TfidfTransformer
+}
+
+// NewTfidfVectorizer returns a *TfidfVectorizer with defaults
+func NewTfidfVectorizer() *TfidfVectorizer {
+	return &TfidfVectorizer{CountVectorizer: *NewCountVectorizer(), TfidfTransformer: *NewTfidfTransformer()}
+}
+
+// Fit builds Vocabulary and Idf from docs
+func (m *TfidfVectorizer) Fit(docs []string) *TfidfVectorizer {
+	m.TfidfTransformer.Fit(m.CountVectorizer.FitTransform(docs))
+	return m
+}
+
+// Transform returns tf-idf features of docs
+func (m *TfidfVectorizer) Transform(docs []string) *base.CSR {
+	return m.TfidfTransformer.Transform(m.CountVectorizer.Transform(docs))
+}
+
+// FitTransform fits and transforms docs
+func (m *TfidfVectorizer) FitTransform(docs []string) *base.CSR {
+	counts := m.CountVectorizer.FitTransform(docs)
+	return m.TfidfTransformer.FitTransform(counts)
+}
+
+// HashingVectorizer converts documents to token counts using the hashing trick. it has no state so it doesn't need Fit
+type HashingVectorizer struct {
+	AnalyzerOptions
+	NFeatures int
+	// AlternateSign gives a hash-dependent sign to features so that collisions tend to cancel out
+	AlternateSign bool
+	// Norm is "l1", "l2" or ""
+	Norm   string
+	Binary bool
+}
+
+// NewHashingVectorizer returns a *HashingVectorizer with 2^20 features
+func NewHashingVectorizer() *HashingVectorizer {
+	return &HashingVectorizer{AnalyzerOptions: NewAnalyzerOptions(), NFeatures: 1 << 20, AlternateSign: true, Norm: "l2"}
+}
+
+// Transform returns hashed features of docs
+func (m *HashingVectorizer) Transform(docs []string) *base.CSR {
+	samples := make([][]string, len(docs))
+	analyze := m.analyzer()
+	for i, doc := range docs {
+		samples[i] = analyze(doc)
+	}
+	hasher := &featureExtraction.FeatureHasher{NFeatures: m.NFeatures, AlternateSign: m.AlternateSign}
+	X := hasher.TransformStrings(samples)
+	if m.Binary {
+		for k, v := range X.Data {
+			X.Data[k] = math.Copysign(1, v)
+		}
+	}
+	normalizeRows(X, m.Norm)
+	return X
+}
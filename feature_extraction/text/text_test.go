@@ -0,0 +1,112 @@
+package text
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gcla/sklearn/linear_model"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+var corpus = []string{
+	"This is the first document.",
+	"This document is the second document.",
+	"And this is the third one.",
+	"Is this the first document?",
+}
+
+func ExampleCountVectorizer() {
+	vectorizer := NewCountVectorizer()
+	X := vectorizer.FitTransform(corpus)
+	fmt.Println(vectorizer.GetFeatureNames())
+	fmt.Printf("%g\n", mat.Formatted(X.ToDense()))
+	// Output:
+	// [and document first is one second the third this]
+	// ⎡0  1  1  1  0  0  1  0  1⎤
+	// ⎢0  2  0  1  0  1  1  0  1⎥
+	// ⎢1  0  0  1  1  0  1  1  1⎥
+	// ⎣0  1  1  1  0  0  1  0  1⎦
+}
+
+func TestCountVectorizerOptions(t *testing.T) {
+	vectorizer := NewCountVectorizer()
+	vectorizer.NGramRange = [2]int{2, 2}
+	vectorizer.StopWords = []string{"is", "the"}
+	vectorizer.MinDfCount = 2
+	vectorizer.Fit(corpus)
+	if fmt.Sprint(vectorizer.GetFeatureNames()) != "[first document this first]" {
+		t.Errorf("unexpected features %v", vectorizer.GetFeatureNames())
+	}
+	// a count of 1 keeps every term, a proportion of 1 only the terms of every document
+	vectorizer = NewCountVectorizer()
+	vectorizer.MinDfCount = 1
+	if features := vectorizer.Fit(corpus).GetFeatureNames(); len(features) != 9 {
+		t.Errorf("MinDfCount=1: expected 9 features got %v", features)
+	}
+	vectorizer.MinDfCount, vectorizer.MinDf = 0, 1
+	if features := vectorizer.Fit(corpus).GetFeatureNames(); fmt.Sprint(features) != "[is the this]" {
+		t.Errorf("MinDf=1: unexpected features %v", features)
+	}
+	vectorizer.MinDf, vectorizer.MaxDfCount = 0, 1
+	if features := vectorizer.Fit(corpus).GetFeatureNames(); fmt.Sprint(features) != "[and one second third]" {
+		t.Errorf("MaxDfCount=1: unexpected features %v", features)
+	}
+	vectorizer = NewCountVectorizer()
+	vectorizer.Analyzer = "char"
+	vectorizer.NGramRange = [2]int{1, 2}
+	vectorizer.MaxFeatures = 2
+	vectorizer.Fit([]string{"aab", "ab"})
+	if fmt.Sprint(vectorizer.GetFeatureNames()) != "[a ab]" {
+		t.Errorf("unexpected char features %v", vectorizer.GetFeatureNames())
+	}
+}
+
+func TestTfidfVectorizer(t *testing.T) {
+	vectorizer := NewTfidfVectorizer()
+	X := vectorizer.FitTransform(corpus)
+	// idf for smooth idf: ln((1+4)/(1+df))+1
+	idf := func(df float64) float64 { return math.Log(5/(1+df)) + 1 }
+	expected := []float64{0, idf(3), idf(2), idf(4), 0, 0, idf(4), 0, idf(4)}
+	floats.Scale(1/floats.Norm(expected, 2), expected)
+	if !floats.EqualApprox(X.ToDense().RawRowView(0), expected, 1e-12) {
+		t.Errorf("expected %g got %g", expected, X.ToDense().RawRowView(0))
+	}
+	if !floats.EqualApprox(vectorizer.Transform(corpus[:1]).ToDense().RawRowView(0), expected, 1e-12) {
+		t.Errorf("Transform differs from FitTransform")
+	}
+}
+
+func TestHashingVectorizer(t *testing.T) {
+	vectorizer := NewHashingVectorizer()
+	vectorizer.NFeatures = 16
+	vectorizer.Norm = ""
+	vectorizer.AlternateSign = false
+	X := vectorizer.Transform(corpus)
+	nSamples, nFeatures := X.Dims()
+	if nSamples != 4 || nFeatures != 16 {
+		t.Errorf("bad dims %d,%d", nSamples, nFeatures)
+	}
+	for i, n := range []float64{5, 6, 6, 5} {
+		if floats.Sum(X.ToDense().RawRowView(i)) != n {
+			t.Errorf("row %d expected %g tokens got %g", i, n, floats.Sum(X.ToDense().RawRowView(i)))
+		}
+	}
+}
+
+func TestTfidfLogisticRegression(t *testing.T) {
+	docs := []string{"cannot login to my account", "password reset does not work", "login page error",
+		"invoice is wrong", "refund my payment", "billing charged twice"}
+	Y := mat.NewDense(6, 1, []float64{1, 1, 1, 0, 0, 0})
+	vectorizer := NewTfidfVectorizer()
+	X := vectorizer.FitTransform(docs)
+	regr := linearModel.NewLogisticRegression()
+	regr.Alpha = 0
+	regr.FitSparse(X, Y)
+	Ypred := mat.NewDense(2, 1, nil)
+	regr.PredictProba(vectorizer.Transform([]string{"login error", "payment refund"}), Ypred)
+	if Ypred.At(0, 0) < .5 || Ypred.At(1, 0) > .5 {
+		t.Errorf("unexpected probabilities %g", mat.Formatted(Ypred.T()))
+	}
+}
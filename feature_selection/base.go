@@ -0,0 +1,100 @@
+package featureSelection
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Selector holds the features mask computed by a selector Fit and implements Transform
+type Selector struct {
+	Support []bool
+}
+
+// GetSupport returns the mask of selected features
+func (s *Selector) GetSupport() []bool { return s.Support }
+
+// GetSupportIndices returns the indices of selected features
+func (s *Selector) GetSupportIndices() []int {
+	indices := make([]int, 0, len(s.Support))
+	for j, selected := range s.Support {
+		if selected {
+			indices = append(indices, j)
+		}
+	}
+	return indices
+}
+
+// Transform returns X reduced to selected features. Y is unchanged
+func (s *Selector) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	if s.Support == nil {
+		panic("Support is nil. call Fit first")
+	}
+	return SelectColumns(X, s.Support), Y
+}
+
+// SelectColumns returns a new *mat.Dense with the columns j of X where support[j] is true
+func SelectColumns(X mat.Matrix, support []bool) *mat.Dense {
+	nSamples, nFeatures := X.Dims()
+	if len(support) != nFeatures {
+		panic(fmt.Errorf("X has %d features, support has %d", nFeatures, len(support)))
+	}
+	nSelected := 0
+	for _, selected := range support {
+		if selected {
+			nSelected++
+		}
+	}
+	Xout := mat.NewDense(nSamples, nSelected, nil)
+	for i := 0; i < nSamples; i++ {
+		row := Xout.RawRowView(i)
+		c := 0
+		for j, selected := range support {
+			if selected {
+				row[c] = X.At(i, j)
+				c++
+			}
+		}
+	}
+	return Xout
+}
+
+// CoefFitter is an estimator exposing nFeatures×nOutputs coefficients after Fit, like linear models
+type CoefFitter interface {
+	Fit(X, Y *mat.Dense) base.Transformer
+	GetCoef() *mat.Dense
+}
+
+// coefImportances returns the L1 norm of each row of coef
+func coefImportances(coef *mat.Dense) []float64 {
+	nFeatures, _ := coef.Dims()
+	importances := make([]float64, nFeatures)
+	for j := range importances {
+		importances[j] = floats.Norm(coef.RawRowView(j), 1)
+	}
+	return importances
+}
+
+// topK returns a mask of the k highest scores. NaN scores are ranked last
+func topK(scores []float64, k int) []bool {
+	indices := make([]int, len(scores))
+	for j := range indices {
+		indices[j] = j
+	}
+	score := func(j int) float64 {
+		if math.IsNaN(scores[j]) {
+			return math.Inf(-1)
+		}
+		return scores[j]
+	}
+	sort.SliceStable(indices, func(a, b int) bool { return score(indices[a]) > score(indices[b]) })
+	support := make([]bool, len(scores))
+	for _, j := range indices[:k] {
+		support[j] = true
+	}
+	return support
+}
@@ -0,0 +1,71 @@
+package featureSelection
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// SelectFromModel selects features whose importance (L1 norm of the Coef row of Estimator) is >= threshold.
+// the threshold is the mean or median importance if ThresholdStrategy is "mean" or "median", else Threshold.
+// MaxFeatures > 0 limits the number of selected features
+type SelectFromModel struct {
+	Selector
+	Estimator         CoefFitter
+	Threshold         float64
+	ThresholdStrategy string
+	MaxFeatures       int
+	// Prefit skips fitting Estimator in Fit
+	Prefit      bool
+	Importances []float64
+}
+
+// NewSelectFromModel returns a *SelectFromModel using mean importance as threshold
+func NewSelectFromModel(estimator CoefFitter) *SelectFromModel {
+	return &SelectFromModel{Estimator: estimator, ThresholdStrategy: "mean"}
+}
+
+// Fit fits Estimator (unless Prefit) and computes Support
+func (m *SelectFromModel) Fit(X, Y *mat.Dense) base.Transformer {
+	if !m.Prefit {
+		m.Estimator.Fit(X, Y)
+	}
+	m.Importances = coefImportances(m.Estimator.GetCoef())
+	var threshold float64
+	switch m.ThresholdStrategy {
+	case "mean":
+		threshold = floats.Sum(m.Importances) / float64(len(m.Importances))
+	case "median":
+		sorted := append([]float64(nil), m.Importances...)
+		sort.Float64s(sorted)
+		threshold = (sorted[(len(sorted)-1)/2] + sorted[len(sorted)/2]) / 2
+	case "":
+		threshold = m.Threshold
+	default:
+		panic(fmt.Errorf("unknown ThresholdStrategy %s. use mean, median or empty for Threshold", m.ThresholdStrategy))
+	}
+	m.Support = make([]bool, len(m.Importances))
+	indices := make([]int, 0, len(m.Importances))
+	for j, importance := range m.Importances {
+		if importance >= threshold {
+			indices = append(indices, j)
+		}
+	}
+	if m.MaxFeatures > 0 && len(indices) > m.MaxFeatures {
+		sort.SliceStable(indices, func(a, b int) bool { return m.Importances[indices[a]] > m.Importances[indices[b]] })
+		indices = indices[:m.MaxFeatures]
+	}
+	for _, j := range indices {
+		m.Support[j] = true
+	}
+	return m
+}
+
+// FitTransform fits and transforms X
+func (m *SelectFromModel) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	m.Fit(X, Y)
+	return m.Transform(X, Y)
+}
@@ -0,0 +1,141 @@
+package featureSelection
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/mathext"
+	"gonum.org/v1/gonum/stat"
+)
+
+// MutualInfoNeighbors is the number of neighbors used by the mutual information estimators
+var MutualInfoNeighbors = 3
+
+// MutualInfoRegression estimates mutual information between each continuous feature and the first column of Y
+// using the Kraskov nearest neighbors estimator. it's O(nSamples²) per feature. pValues is nil
+func MutualInfoRegression(X, Y *mat.Dense) (mi, pValues []float64) {
+	nSamples, nFeatures := X.Dims()
+	y := scaled(mat.Col(nil, 0, Y))
+	mi = make([]float64, nFeatures)
+	x := make([]float64, nSamples)
+	for j := range mi {
+		mat.Col(x, j, X)
+		mi[j] = miContinuousContinuous(scaled(x), y, MutualInfoNeighbors)
+	}
+	return
+}
+
+// MutualInfoClassif estimates mutual information between each continuous feature and class labels Y
+// using the Ross nearest neighbors estimator. it's O(nSamples²) per feature. pValues is nil
+func MutualInfoClassif(X, Y *mat.Dense) (mi, pValues []float64) {
+	nSamples, nFeatures := X.Dims()
+	y, _ := labels(Y)
+	mi = make([]float64, nFeatures)
+	x := make([]float64, nSamples)
+	for j := range mi {
+		mat.Col(x, j, X)
+		mi[j] = miContinuousDiscrete(scaled(x), y, MutualInfoNeighbors)
+	}
+	return
+}
+
+// scaled returns a copy of x with unit variance and a tiny deterministic noise added to break ties
+func scaled(x []float64) []float64 {
+	xs := append([]float64(nil), x...)
+	if std := stat.StdDev(xs, nil); std > 0 {
+		for i := range xs {
+			xs[i] /= std
+		}
+	}
+	meanAbs := 0.
+	for _, v := range xs {
+		meanAbs += math.Abs(v) / float64(len(xs))
+	}
+	rnd := rand.New(rand.NewSource(0))
+	for i := range xs {
+		xs[i] += 1e-10 * math.Max(1, meanAbs) * rnd.NormFloat64()
+	}
+	return xs
+}
+
+// kthSmallest returns the k-th (1-based) smallest value of d. d is modified
+func kthSmallest(d []float64, k int) float64 {
+	sort.Float64s(d)
+	return d[k-1]
+}
+
+func miContinuousContinuous(x, y []float64, k int) float64 {
+	n := len(x)
+	if k >= n {
+		k = n - 1
+	}
+	d := make([]float64, 0, n)
+	digammaSum := 0.
+	for i := 0; i < n; i++ {
+		d = d[:0]
+		for l := 0; l < n; l++ {
+			if l != i {
+				d = append(d, math.Max(math.Abs(x[i]-x[l]), math.Abs(y[i]-y[l])))
+			}
+		}
+		radius := math.Nextafter(kthSmallest(d, k), 0)
+		nx, ny := 0, 0
+		for l := 0; l < n; l++ {
+			if l == i {
+				continue
+			}
+			if math.Abs(x[i]-x[l]) <= radius {
+				nx++
+			}
+			if math.Abs(y[i]-y[l]) <= radius {
+				ny++
+			}
+		}
+		digammaSum += mathext.Digamma(float64(nx+1)) + mathext.Digamma(float64(ny+1))
+	}
+	mi := mathext.Digamma(float64(n)) + mathext.Digamma(float64(k)) - digammaSum/float64(n)
+	return math.Max(0, mi)
+}
+
+func miContinuousDiscrete(x, y []float64, k int) float64 {
+	n := len(x)
+	counts := make(map[float64]int)
+	for _, c := range y {
+		counts[c]++
+	}
+	d := make([]float64, 0, n)
+	sum, nUsed := 0., 0
+	for i := 0; i < n; i++ {
+		count := counts[y[i]]
+		if count <= 1 {
+			// a lone sample of its class gives no information
+			continue
+		}
+		ki := k
+		if ki > count-1 {
+			ki = count - 1
+		}
+		d = d[:0]
+		for l := 0; l < n; l++ {
+			if l != i && y[l] == y[i] {
+				d = append(d, math.Abs(x[i]-x[l]))
+			}
+		}
+		radius := math.Nextafter(kthSmallest(d, ki), 0)
+		m := 0
+		for l := 0; l < n; l++ {
+			if l != i && math.Abs(x[i]-x[l]) <= radius {
+				m++
+			}
+		}
+		sum += mathext.Digamma(float64(ki)) - mathext.Digamma(float64(count)) - mathext.Digamma(float64(m+1))
+		nUsed++
+	}
+	if nUsed == 0 {
+		return 0
+	}
+	mi := mathext.Digamma(float64(nUsed)) + sum/float64(nUsed)
+	return math.Max(0, mi)
+}
@@ -0,0 +1,192 @@
+package featureSelection
+
+import (
+	"fmt"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/mat"
+)
+
+// RFE is recursive feature elimination: Estimator is fitted on the remaining features and
+// the Step features with smallest coefficients are removed until NFeaturesToSelect remain
+type RFE struct {
+	Selector
+	Estimator CoefFitter
+	// NFeaturesToSelect defaults to half of the features
+	NFeaturesToSelect, Step int
+	// Ranking is 1 for selected features and increases with earlier elimination
+	Ranking []int
+}
+
+// NewRFE returns a *RFE eliminating one feature at a time
+func NewRFE(estimator CoefFitter, nFeaturesToSelect int) *RFE {
+	return &RFE{Estimator: estimator, NFeaturesToSelect: nFeaturesToSelect, Step: 1}
+}
+
+// Fit runs the elimination. Estimator is left fitted on selected features
+func (m *RFE) Fit(X, Y *mat.Dense) base.Transformer {
+	_, nFeatures := X.Dims()
+	nToSelect := m.NFeaturesToSelect
+	if nToSelect <= 0 {
+		nToSelect = nFeatures / 2
+	}
+	m.Support, m.Ranking = eliminate(m.Estimator, X, Y, nToSelect, m.Step, nil)
+	return m
+}
+
+// FitTransform fits and transforms X
+func (m *RFE) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	m.Fit(X, Y)
+	return m.Transform(X, Y)
+}
+
+// eliminate runs recursive feature elimination down to nToSelect features.
+// if not nil, f is called with Estimator fitted on each successive subset, including the final one
+func eliminate(estimator CoefFitter, X, Y *mat.Dense, nToSelect, step int, f func(support []bool)) (support []bool, ranking []int) {
+	_, nFeatures := X.Dims()
+	if step < 1 {
+		step = 1
+	}
+	support = make([]bool, nFeatures)
+	ranking = make([]int, nFeatures)
+	for j := range support {
+		support[j] = true
+	}
+	nSelected := nFeatures
+	for {
+		estimator.Fit(SelectColumns(X, support), Y)
+		if f != nil {
+			f(support)
+		}
+		if nSelected <= nToSelect {
+			break
+		}
+		importances := coefImportances(estimator.GetCoef())
+		indices := make([]int, 0, nSelected)
+		for j, selected := range support {
+			if selected {
+				indices = append(indices, j)
+			}
+		}
+		nRemove := step
+		if nSelected-nRemove < nToSelect {
+			nRemove = nSelected - nToSelect
+		}
+		// keep the highest importances. importances are in the order of indices
+		keep := topK(importances, nSelected-nRemove)
+		for c, j := range indices {
+			if !keep[c] {
+				support[j] = false
+			}
+		}
+		for j := range ranking {
+			if !support[j] {
+				ranking[j]++
+			}
+		}
+		nSelected -= nRemove
+	}
+	for j := range ranking {
+		ranking[j]++
+	}
+	return
+}
+
+// Scorer is a CoefFitter with a Score method, as needed by RFECV
+type Scorer interface {
+	CoefFitter
+	Score(X, Y *mat.Dense) float64
+}
+
+// RFECV is RFE with the number of features chosen by cross-validated Estimator.Score
+type RFECV struct {
+	RFE
+	// MinFeaturesToSelect defaults to 1
+	MinFeaturesToSelect int
+	// CV is the number of folds. defaults to 5
+	CV int
+	// NFeatures[i] is the number of features for which mean score across folds was CVScores[i]
+	NFeatures []int
+	CVScores  []float64
+}
+
+// NewRFECV returns a *RFECV with 5 folds and Step 1
+func NewRFECV(estimator Scorer) *RFECV {
+	return &RFECV{RFE: RFE{Estimator: estimator, Step: 1}, MinFeaturesToSelect: 1, CV: 5}
+}
+
+// Fit computes CVScores, then runs RFE for the number of features with the best score
+func (m *RFECV) Fit(X, Y *mat.Dense) base.Transformer {
+	estimator, ok := m.Estimator.(Scorer)
+	if !ok {
+		panic(fmt.Errorf("RFECV: %T has no Score method", m.Estimator))
+	}
+	nSamples, nFeatures := X.Dims()
+	nFolds := m.CV
+	if nFolds <= 1 {
+		nFolds = 5
+	}
+	if m.MinFeaturesToSelect < 1 {
+		m.MinFeaturesToSelect = 1
+	}
+	m.NFeatures, m.CVScores = nil, nil
+	scoreIndex := make(map[int]int)
+	for fold := 0; fold < nFolds; fold++ {
+		testStart, testEnd := fold*nSamples/nFolds, (fold+1)*nSamples/nFolds
+		train, test := make([]int, 0, nSamples), make([]int, 0, testEnd-testStart)
+		for i := 0; i < nSamples; i++ {
+			if i >= testStart && i < testEnd {
+				test = append(test, i)
+			} else {
+				train = append(train, i)
+			}
+		}
+		Xtrain, Ytrain := rowsSubset(X, train), rowsSubset(Y, train)
+		Xtest, Ytest := rowsSubset(X, test), rowsSubset(Y, test)
+		eliminate(estimator, Xtrain, Ytrain, m.MinFeaturesToSelect, m.Step, func(support []bool) {
+			n := 0
+			for _, selected := range support {
+				if selected {
+					n++
+				}
+			}
+			idx, ok := scoreIndex[n]
+			if !ok {
+				idx = len(m.CVScores)
+				scoreIndex[n] = idx
+				m.NFeatures = append(m.NFeatures, n)
+				m.CVScores = append(m.CVScores, 0)
+			}
+			m.CVScores[idx] += estimator.Score(SelectColumns(Xtest, support), Ytest) / float64(nFolds)
+		})
+	}
+	best := 0
+	for idx := range m.CVScores {
+		// on ties prefer fewer features
+		if m.CVScores[idx] > m.CVScores[best] || (m.CVScores[idx] == m.CVScores[best] && m.NFeatures[idx] < m.NFeatures[best]) {
+			best = idx
+		}
+	}
+	m.NFeaturesToSelect = nFeatures
+	if len(m.NFeatures) > 0 {
+		m.NFeaturesToSelect = m.NFeatures[best]
+	}
+	m.RFE.Fit(X, Y)
+	return m
+}
+
+// FitTransform fits and transforms X
+func (m *RFECV) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	m.Fit(X, Y)
+	return m.Transform(X, Y)
+}
+
+// rowsSubset returns a new *mat.Dense with rows of X
+func rowsSubset(X *mat.Dense, rows []int) *mat.Dense {
+	_, nCols := X.Dims()
+	Xout := mat.NewDense(len(rows), nCols, nil)
+	for i, src := range rows {
+		Xout.SetRow(i, X.RawRowView(src))
+	}
+	return Xout
+}
@@ -0,0 +1,60 @@
+package featureSelection
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gcla/sklearn/datasets"
+	"github.com/gcla/sklearn/linear_model"
+)
+
+func TestSelectFromModel(t *testing.T) {
+	X, Y, _ := datasets.MakeRegression(map[string]interface{}{"n_samples": 100, "n_features": 6, "n_informative": 2, "noise": .1, "random_state": rand.New(rand.NewSource(8))})
+	m := NewSelectFromModel(linearModel.NewBayesianRidge())
+	m.Fit(X, Y)
+	if fmt.Sprint(m.GetSupportIndices()) != "[0 1]" {
+		t.Errorf("expected [0 1] got %v importances %.3g", m.GetSupportIndices(), m.Importances)
+	}
+	m.MaxFeatures = 1
+	m.Prefit = true
+	m.Fit(X, Y)
+	if fmt.Sprint(m.GetSupportIndices()) != "[1]" {
+		t.Errorf("expected [1] got %v", m.GetSupportIndices())
+	}
+	m.MaxFeatures = 0
+	m.ThresholdStrategy = "median"
+	m.Fit(X, Y)
+	if n := len(m.GetSupportIndices()); n != 3 {
+		t.Errorf("median threshold should select 3 of 6 features, got %d", n)
+	}
+	// an explicit zero Threshold keeps all features
+	m.ThresholdStrategy = ""
+	m.Threshold = 0
+	m.Fit(X, Y)
+	if n := len(m.GetSupportIndices()); n != 6 {
+		t.Errorf("zero threshold should select 6 features, got %d", n)
+	}
+}
+
+func TestRFE(t *testing.T) {
+	X, Y, _ := datasets.MakeRegression(map[string]interface{}{"n_samples": 100, "n_features": 6, "n_informative": 2, "noise": .1, "random_state": rand.New(rand.NewSource(8))})
+	m := NewRFE(linearModel.NewBayesianRidge(), 2)
+	m.Fit(X, Y)
+	if fmt.Sprint(m.GetSupportIndices()) != "[0 1]" {
+		t.Errorf("expected [0 1] got %v", m.GetSupportIndices())
+	}
+	ranking := map[int]bool{}
+	for _, r := range m.Ranking {
+		ranking[r] = true
+	}
+	if m.Ranking[0] != 1 || m.Ranking[1] != 1 || len(ranking) != 5 {
+		t.Errorf("unexpected ranking %v", m.Ranking)
+	}
+
+	cv := NewRFECV(linearModel.NewBayesianRidge())
+	cv.Fit(X, Y)
+	if fmt.Sprint(cv.GetSupportIndices()) != "[0 1]" {
+		t.Errorf("RFECV expected [0 1] got %v scores %.4g for %v", cv.GetSupportIndices(), cv.CVScores, cv.NFeatures)
+	}
+}
@@ -0,0 +1,202 @@
+package featureSelection
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// ScoreFunc returns a score for each feature of X and optionally p-values (nil for mutual information)
+type ScoreFunc func(X, Y *mat.Dense) (scores, pValues []float64)
+
+// FRegression returns univariate linear regression F-values between each feature and the first column of Y
+func FRegression(X, Y *mat.Dense) (F, pValues []float64) {
+	nSamples, nFeatures := X.Dims()
+	y := mat.Col(nil, 0, Y)
+	x := make([]float64, nSamples)
+	F, pValues = make([]float64, nFeatures), make([]float64, nFeatures)
+	dof := float64(nSamples - 2)
+	dist := distuv.F{D1: 1, D2: dof}
+	for j := 0; j < nFeatures; j++ {
+		mat.Col(x, j, X)
+		r := stat.Correlation(x, y, nil)
+		F[j] = r * r / (1 - r*r) * dof
+		pValues[j] = dist.Survival(F[j])
+	}
+	return
+}
+
+// labels returns the class of each sample and the sorted classes. Y is a column of labels or a one-hot matrix
+func labels(Y *mat.Dense) (y []float64, classes []float64) {
+	nSamples, nOutputs := Y.Dims()
+	y = make([]float64, nSamples)
+	for i := range y {
+		if nOutputs == 1 {
+			y[i] = Y.At(i, 0)
+		} else {
+			y[i] = float64(floats.MaxIdx(Y.RawRowView(i)))
+		}
+	}
+	seen := make(map[float64]bool)
+	for _, c := range y {
+		if !seen[c] {
+			seen[c] = true
+			classes = append(classes, c)
+		}
+	}
+	sort.Float64s(classes)
+	return
+}
+
+// FClassif returns ANOVA F-values between each feature and class labels Y
+func FClassif(X, Y *mat.Dense) (F, pValues []float64) {
+	nSamples, nFeatures := X.Dims()
+	y, classes := labels(Y)
+	nClasses := len(classes)
+	classIndex := make(map[float64]int, nClasses)
+	for c, class := range classes {
+		classIndex[class] = c
+	}
+	dfBetween, dfWithin := float64(nClasses-1), float64(nSamples-nClasses)
+	dist := distuv.F{D1: dfBetween, D2: dfWithin}
+	F, pValues = make([]float64, nFeatures), make([]float64, nFeatures)
+	sums, counts := make([]float64, nClasses), make([]float64, nClasses)
+	for j := 0; j < nFeatures; j++ {
+		for c := range sums {
+			sums[c], counts[c] = 0, 0
+		}
+		total, totalSq := 0., 0.
+		for i, class := range y {
+			v := X.At(i, j)
+			c := classIndex[class]
+			sums[c] += v
+			counts[c]++
+			total += v
+			totalSq += v * v
+		}
+		ssTotal := totalSq - total*total/float64(nSamples)
+		ssBetween := -total * total / float64(nSamples)
+		for c := range sums {
+			ssBetween += sums[c] * sums[c] / counts[c]
+		}
+		ssWithin := ssTotal - ssBetween
+		F[j] = (ssBetween / dfBetween) / (ssWithin / dfWithin)
+		pValues[j] = dist.Survival(F[j])
+	}
+	return
+}
+
+// Chi2 returns chi-squared statistics between each non-negative feature (such as counts or frequencies) and class labels Y
+func Chi2(X, Y *mat.Dense) (chi2, pValues []float64) {
+	nSamples, nFeatures := X.Dims()
+	y, classes := labels(Y)
+	nClasses := len(classes)
+	classIndex := make(map[float64]int, nClasses)
+	for c, class := range classes {
+		classIndex[class] = c
+	}
+	observed := mat.NewDense(nClasses, nFeatures, nil)
+	classProb := make([]float64, nClasses)
+	featureSum := make([]float64, nFeatures)
+	for i, class := range y {
+		c := classIndex[class]
+		classProb[c] += 1 / float64(nSamples)
+		row := observed.RawRowView(c)
+		for j := 0; j < nFeatures; j++ {
+			v := X.At(i, j)
+			if v < 0 {
+				panic(fmt.Errorf("Chi2: X must be non-negative. got %g at %d,%d", v, i, j))
+			}
+			row[j] += v
+			featureSum[j] += v
+		}
+	}
+	dist := distuv.ChiSquared{K: float64(nClasses - 1)}
+	chi2, pValues = make([]float64, nFeatures), make([]float64, nFeatures)
+	for j := range chi2 {
+		for c := 0; c < nClasses; c++ {
+			expected := classProb[c] * featureSum[j]
+			d := observed.At(c, j) - expected
+			chi2[j] += d * d / expected
+		}
+		pValues[j] = dist.Survival(chi2[j])
+	}
+	return
+}
+
+// univariateSelector holds what's common to SelectKBest and SelectPercentile
+type univariateSelector struct {
+	Selector
+	ScoreFunc       ScoreFunc
+	Scores, PValues []float64
+}
+
+func (m *univariateSelector) score(X, Y *mat.Dense) {
+	if m.ScoreFunc == nil {
+		m.ScoreFunc = FClassif
+	}
+	m.Scores, m.PValues = m.ScoreFunc(X, Y)
+}
+
+// SelectKBest selects the K features with highest ScoreFunc scores
+type SelectKBest struct {
+	univariateSelector
+	K int
+}
+
+// NewSelectKBest returns a *SelectKBest. scoreFunc is one of FRegression,FClassif,Chi2,MutualInfoRegression,MutualInfoClassif (defaults to FClassif)
+func NewSelectKBest(scoreFunc ScoreFunc, k int) *SelectKBest {
+	m := &SelectKBest{K: k}
+	m.ScoreFunc = scoreFunc
+	return m
+}
+
+// Fit computes Scores and Support
+func (m *SelectKBest) Fit(X, Y *mat.Dense) base.Transformer {
+	m.score(X, Y)
+	k := m.K
+	if k <= 0 || k > len(m.Scores) {
+		k = len(m.Scores)
+	}
+	m.Support = topK(m.Scores, k)
+	return m
+}
+
+// FitTransform fits and transforms X
+func (m *SelectKBest) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	m.Fit(X, Y)
+	return m.Transform(X, Y)
+}
+
+// SelectPercentile selects the Percentile % of features with highest ScoreFunc scores
+type SelectPercentile struct {
+	univariateSelector
+	Percentile float64
+}
+
+// NewSelectPercentile returns a *SelectPercentile. scoreFunc defaults to FClassif
+func NewSelectPercentile(scoreFunc ScoreFunc, percentile float64) *SelectPercentile {
+	m := &SelectPercentile{Percentile: percentile}
+	m.ScoreFunc = scoreFunc
+	return m
+}
+
+// Fit computes Scores and Support
+func (m *SelectPercentile) Fit(X, Y *mat.Dense) base.Transformer {
+	m.score(X, Y)
+	k := int(math.Floor(float64(len(m.Scores)) * m.Percentile / 100))
+	m.Support = topK(m.Scores, k)
+	return m
+}
+
+// FitTransform fits and transforms X
+func (m *SelectPercentile) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	m.Fit(X, Y)
+	return m.Transform(X, Y)
+}
@@ -0,0 +1,60 @@
+package featureSelection
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gcla/sklearn/datasets"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func ExampleVarianceThreshold() {
+	X := mat.NewDense(4, 3, []float64{0, 2, 0, 0, 1, 4, 0, 1, 1, 0, 1, 3})
+	m := NewVarianceThreshold(0)
+	Xout, _ := m.FitTransform(X, nil)
+	fmt.Println(m.GetSupport())
+	fmt.Printf("%g\n", mat.Formatted(Xout))
+	// Output:
+	// [false true true]
+	// ⎡2  0⎤
+	// ⎢1  4⎥
+	// ⎢1  1⎥
+	// ⎣1  3⎦
+}
+
+func TestSelectKBestRegression(t *testing.T) {
+	X, Y, _ := datasets.MakeRegression(map[string]interface{}{"n_samples": 100, "n_features": 6, "n_informative": 2, "noise": .1, "random_state": rand.New(rand.NewSource(8))})
+	for _, scoreFunc := range []ScoreFunc{FRegression, MutualInfoRegression} {
+		m := NewSelectKBest(scoreFunc, 2)
+		Xout, _ := m.FitTransform(X, Y)
+		if fmt.Sprint(m.GetSupportIndices()) != "[0 1]" {
+			t.Errorf("expected [0 1] got %v scores %.3g", m.GetSupportIndices(), m.Scores)
+		}
+		if _, c := Xout.Dims(); c != 2 {
+			t.Errorf("expected 2 columns got %d", c)
+		}
+	}
+}
+
+func TestUnivariateClassif(t *testing.T) {
+	ds := datasets.LoadIris()
+	// first feature values from sklearn.feature_selection. (sklearn iris has 2 fixed samples in other features)
+	F, _ := FClassif(ds.X, ds.Y)
+	if !floats.EqualWithinAbsOrRel(F[0], 119.26450218, 1e-6, 1e-6) || floats.MaxIdx(F) != 2 || floats.MinIdx(F) != 1 {
+		t.Errorf("FClassif %g", F)
+	}
+	chi2, pValues := Chi2(ds.X, ds.Y)
+	if !floats.EqualWithinAbsOrRel(chi2[0], 10.81782088, 1e-6, 1e-6) || floats.MaxIdx(chi2) != 2 || floats.MinIdx(chi2) != 1 {
+		t.Errorf("Chi2 %g", chi2)
+	}
+	if pValues[2] > 1e-20 || pValues[1] < .1 {
+		t.Errorf("Chi2 pValues %g", pValues)
+	}
+	m := NewSelectPercentile(MutualInfoClassif, 50)
+	m.Fit(ds.X, ds.Y)
+	if fmt.Sprint(m.GetSupportIndices()) != "[2 3]" {
+		t.Errorf("expected [2 3] got %v scores %.3g", m.GetSupportIndices(), m.Scores)
+	}
+}
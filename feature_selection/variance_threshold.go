@@ -0,0 +1,40 @@
+package featureSelection
+
+import (
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// VarianceThreshold removes features whose variance is not above Threshold. Y is ignored
+type VarianceThreshold struct {
+	Selector
+	Threshold float64
+	Variances []float64
+}
+
+// NewVarianceThreshold returns a *VarianceThreshold removing features with variance <= threshold
+func NewVarianceThreshold(threshold float64) *VarianceThreshold {
+	return &VarianceThreshold{Threshold: threshold}
+}
+
+// Fit computes Variances and Support
+func (m *VarianceThreshold) Fit(X, Y *mat.Dense) base.Transformer {
+	nSamples, nFeatures := X.Dims()
+	m.Variances = make([]float64, nFeatures)
+	m.Support = make([]bool, nFeatures)
+	col := make([]float64, nSamples)
+	for j := range m.Variances {
+		mat.Col(col, j, X)
+		mean := stat.Mean(col, nil)
+		m.Variances[j] = stat.MomentAbout(2, col, mean, nil)
+		m.Support[j] = m.Variances[j] > m.Threshold
+	}
+	return m
+}
+
+// FitTransform fits and transforms X
+func (m *VarianceThreshold) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	m.Fit(X, Y)
+	return m.Transform(X, Y)
+}
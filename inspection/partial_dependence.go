@@ -0,0 +1,124 @@
+package inspection
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// PartialDependenceOptions are options for PartialDependence
+type PartialDependenceOptions struct {
+	// GridResolution is the max number of values per feature. defaults to 100
+	GridResolution int
+	// Percentiles bound the grid of each feature. defaults to {.05, .95}
+	Percentiles [2]float64
+	// NOutputs is the number of columns predicted by the estimator. defaults to 1
+	NOutputs int
+}
+
+// PartialDependenceResult holds partial dependence and individual conditional expectation (ICE) values.
+// Grid has a row for each combination of Values, with a column for each feature.
+// Average has a row for each Grid row and a column for each output.
+// Individual has a matrix per output, with a row per sample and a column per Grid row
+type PartialDependenceResult struct {
+	Values     [][]float64
+	Grid       *mat.Dense
+	Average    *mat.Dense
+	Individual []*mat.Dense
+}
+
+// PartialDependence computes the predictions of a fitted estimator when the given features of every sample of X are set to each point of a grid.
+// the grid is the cartesian product of the values of each feature: its unique values if fewer than GridResolution, else GridResolution values evenly spaced between Percentiles
+func PartialDependence(estimator base.Regressor, X *mat.Dense, features []int, opts *PartialDependenceOptions) *PartialDependenceResult {
+	if opts == nil {
+		opts = &PartialDependenceOptions{}
+	}
+	gridResolution := opts.GridResolution
+	if gridResolution <= 0 {
+		gridResolution = 100
+	}
+	percentiles := opts.Percentiles
+	if percentiles == [2]float64{} {
+		percentiles = [2]float64{.05, .95}
+	}
+	nOutputs := opts.NOutputs
+	if nOutputs <= 0 {
+		nOutputs = 1
+	}
+	nSamples, nFeatures := X.Dims()
+	res := &PartialDependenceResult{Values: make([][]float64, len(features))}
+	nGrid := 1
+	for f, j := range features {
+		if j < 0 || j >= nFeatures {
+			panic(fmt.Errorf("feature %d out of range (%d features)", j, nFeatures))
+		}
+		res.Values[f] = featureGrid(mat.Col(nil, j, X), gridResolution, percentiles)
+		nGrid *= len(res.Values[f])
+	}
+	res.Grid = mat.NewDense(nGrid, len(features), nil)
+	for g := 0; g < nGrid; g++ {
+		// last feature varies fastest
+		rem := g
+		for f := len(features) - 1; f >= 0; f-- {
+			values := res.Values[f]
+			res.Grid.Set(g, f, values[rem%len(values)])
+			rem /= len(values)
+		}
+	}
+	res.Average = mat.NewDense(nGrid, nOutputs, nil)
+	res.Individual = make([]*mat.Dense, nOutputs)
+	for o := range res.Individual {
+		res.Individual[o] = mat.NewDense(nSamples, nGrid, nil)
+	}
+	Xg := mat.DenseCopyOf(X)
+	Ypred := mat.NewDense(nSamples, nOutputs, nil)
+	for g := 0; g < nGrid; g++ {
+		for f, j := range features {
+			v := res.Grid.At(g, f)
+			for i := 0; i < nSamples; i++ {
+				Xg.Set(i, j, v)
+			}
+		}
+		estimator.Predict(Xg, Ypred)
+		for o := 0; o < nOutputs; o++ {
+			col := mat.Col(nil, o, Ypred)
+			res.Individual[o].SetCol(g, col)
+			res.Average.Set(g, o, floats.Sum(col)/float64(nSamples))
+		}
+	}
+	return res
+}
+
+// featureGrid returns the sorted unique values of x if there are at most gridResolution of them, else gridResolution values evenly spaced between percentiles of x
+func featureGrid(x []float64, gridResolution int, percentiles [2]float64) []float64 {
+	sorted := append([]float64(nil), x...)
+	sort.Float64s(sorted)
+	unique := sorted[:0:0]
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			unique = append(unique, v)
+		}
+	}
+	if len(unique) <= gridResolution {
+		return unique
+	}
+	lo, hi := percentile(sorted, percentiles[0]), percentile(sorted, percentiles[1])
+	if lo == hi {
+		panic(fmt.Errorf("percentiles %g are too close for this feature", percentiles))
+	}
+	return floats.Span(make([]float64, gridResolution), lo, hi)
+}
+
+// percentile returns the p quantile of sorted with linear interpolation
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	i := int(math.Floor(pos))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
@@ -0,0 +1,51 @@
+package inspection
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gcla/sklearn/datasets"
+	"github.com/gcla/sklearn/linear_model"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPartialDependence(t *testing.T) {
+	X, Y, Coef := datasets.MakeRegression(map[string]interface{}{"n_samples": 200, "n_features": 4, "n_informative": 2, "random_state": rand.New(rand.NewSource(7))})
+	regr := linearModel.NewBayesianRidge()
+	regr.Fit(X, Y)
+	res := PartialDependence(regr, X, []int{0}, &PartialDependenceOptions{GridResolution: 5})
+	if r, c := res.Grid.Dims(); r != 5 || c != 1 {
+		t.Errorf("unexpected grid dims %d,%d", r, c)
+	}
+	// slope of average partial dependence is Coef of feature 0
+	slope := (res.Average.At(4, 0) - res.Average.At(0, 0)) / (res.Grid.At(4, 0) - res.Grid.At(0, 0))
+	if !floats.EqualWithinAbs(slope, Coef.At(0, 0), 1e-3) {
+		t.Errorf("expected slope %g got %g", Coef.At(0, 0), slope)
+	}
+	if r, c := res.Individual[0].Dims(); r != 200 || c != 5 {
+		t.Errorf("unexpected ICE dims %d,%d", r, c)
+	}
+}
+
+func ExamplePartialDependence() {
+	X := mat.NewDense(4, 2, []float64{-1, 1, 1, -1, -1, -1, 1, 1})
+	Y := mat.NewDense(4, 1, []float64{-1, 1, -3, 3})
+	regr := linearModel.NewBayesianRidge()
+	regr.Fit(X, Y)
+	res := PartialDependence(regr, X, []int{0, 1}, nil)
+	fmt.Printf("Grid:\n%g\n", mat.Formatted(res.Grid))
+	fmt.Printf("Average:\n%.1f\n", mat.Formatted(res.Average))
+	// Output:
+	// Grid:
+	// ⎡-1  -1⎤
+	// ⎢-1   1⎥
+	// ⎢ 1  -1⎥
+	// ⎣ 1   1⎦
+	// Average:
+	// ⎡-3.0⎤
+	// ⎢-1.0⎥
+	// ⎢ 1.0⎥
+	// ⎣ 3.0⎦
+}
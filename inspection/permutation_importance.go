@@ -0,0 +1,113 @@
+package inspection
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"github.com/gcla/sklearn/base"
+	"github.com/gcla/sklearn/metrics"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Cloner is implemented by estimators returning a copy safe to use concurrently with the original.
+// estimators without Clone are assumed unsafe and their Predict calls are serialized
+type Cloner interface {
+	Clone() base.Transformer
+}
+
+// PermutationImportanceOptions are options for PermutationImportance
+type PermutationImportanceOptions struct {
+	// NRepeats is the number of permutations per feature. defaults to 5
+	NRepeats int
+	// Scorer defaults to estimator Score. see metrics.GetScorer
+	Scorer      *metrics.Scorer
+	RandomState *rand.Rand
+	// NJobs is the number of features processed concurrently. <=0 means runtime.NumCPU()
+	NJobs int
+}
+
+// PermutationImportanceResult holds the score decrease for each feature (row) and repeat (column) of Importances
+type PermutationImportanceResult struct {
+	BaselineScore                   float64
+	Importances                     *mat.Dense
+	ImportancesMean, ImportancesStd []float64
+}
+
+// PermutationImportance returns the decrease of score of the fitted estimator when each feature of X is shuffled
+func PermutationImportance(estimator base.Regressor, X, Y *mat.Dense, opts *PermutationImportanceOptions) *PermutationImportanceResult {
+	if opts == nil {
+		opts = &PermutationImportanceOptions{}
+	}
+	nRepeats := opts.NRepeats
+	if nRepeats <= 0 {
+		nRepeats = 5
+	}
+	nJobs := opts.NJobs
+	if nJobs <= 0 {
+		nJobs = runtime.NumCPU()
+	}
+	nSamples, nFeatures := X.Dims()
+	// seeds are drawn before starting goroutines so that results don't depend on NJobs
+	seeds := make([]int64, nFeatures)
+	for j := range seeds {
+		if opts.RandomState != nil {
+			seeds[j] = opts.RandomState.Int63()
+		} else {
+			seeds[j] = rand.Int63()
+		}
+	}
+	var predictMutex sync.Mutex
+	score := func(estimator base.Regressor, X *mat.Dense) float64 {
+		if _, ok := estimator.(Cloner); !ok {
+			predictMutex.Lock()
+			defer predictMutex.Unlock()
+		}
+		if opts.Scorer == nil {
+			return estimator.Score(X, Y)
+		}
+		return opts.Scorer.Score(estimator, X, Y)
+	}
+	res := &PermutationImportanceResult{
+		BaselineScore:   score(estimator, X),
+		Importances:     mat.NewDense(nFeatures, nRepeats, nil),
+		ImportancesMean: make([]float64, nFeatures),
+		ImportancesStd:  make([]float64, nFeatures),
+	}
+	features := make(chan int)
+	var wg sync.WaitGroup
+	for job := 0; job < nJobs; job++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			estimator := estimator
+			if cloner, ok := estimator.(Cloner); ok {
+				estimator = cloner.Clone().(base.Regressor)
+			}
+			Xperm := mat.DenseCopyOf(X)
+			col := make([]float64, nSamples)
+			for j := range features {
+				rnd := rand.New(rand.NewSource(seeds[j]))
+				mat.Col(col, j, X)
+				for r := 0; r < nRepeats; r++ {
+					rnd.Shuffle(nSamples, func(a, b int) { col[a], col[b] = col[b], col[a] })
+					Xperm.SetCol(j, col)
+					res.Importances.Set(j, r, res.BaselineScore-score(estimator, Xperm))
+				}
+				// restore original column
+				Xperm.SetCol(j, mat.Col(col, j, X))
+				importances := res.Importances.RawRowView(j)
+				res.ImportancesMean[j] = stat.Mean(importances, nil)
+				res.ImportancesStd[j] = math.Sqrt(stat.MomentAbout(2, importances, res.ImportancesMean[j], nil))
+			}
+		}()
+	}
+	for j := 0; j < nFeatures; j++ {
+		features <- j
+	}
+	close(features)
+	wg.Wait()
+	return res
+}
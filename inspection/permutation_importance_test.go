@@ -0,0 +1,39 @@
+package inspection
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gcla/sklearn/datasets"
+	"github.com/gcla/sklearn/linear_model"
+	"github.com/gcla/sklearn/metrics"
+	"github.com/gcla/sklearn/neural_network"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPermutationImportance(t *testing.T) {
+	X, Y, _ := datasets.MakeRegression(map[string]interface{}{"n_samples": 200, "n_features": 4, "n_informative": 2, "random_state": rand.New(rand.NewSource(7))})
+	regr := linearModel.NewBayesianRidge()
+	regr.Fit(X, Y)
+	res := PermutationImportance(regr, X, Y, &PermutationImportanceOptions{RandomState: rand.New(rand.NewSource(1)), NJobs: 1})
+	// only the first 2 features are informative
+	if res.ImportancesMean[0] < .5 || res.ImportancesMean[1] < .5 || res.ImportancesMean[2] > .01 || res.ImportancesMean[3] > .01 {
+		t.Errorf("unexpected importances %.3g", res.ImportancesMean)
+	}
+	res2 := PermutationImportance(regr, X, Y, &PermutationImportanceOptions{RandomState: rand.New(rand.NewSource(1)), NJobs: 4})
+	if !mat.Equal(res.Importances, res2.Importances) {
+		t.Errorf("results depend on NJobs")
+	}
+}
+
+func TestPermutationImportanceMLP(t *testing.T) {
+	X, Y, _ := datasets.MakeRegression(map[string]interface{}{"n_samples": 200, "n_features": 4, "n_informative": 2, "random_state": rand.New(rand.NewSource(7))})
+	regr := neuralNetwork.NewMLPRegressor([]int{}, "identity", "lbfgs", 0)
+	regr.Epochs = 100
+	regr.Fit(X, Y)
+	res := PermutationImportance(regr, X, Y, &PermutationImportanceOptions{NRepeats: 3, Scorer: metrics.GetScorer("neg_mean_squared_error"), NJobs: 4})
+	if floats.MaxIdx(res.ImportancesMean) > 1 || floats.MinIdx(res.ImportancesMean) < 2 {
+		t.Errorf("unexpected importances %.3g", res.ImportancesMean)
+	}
+}
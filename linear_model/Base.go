@@ -1,6 +1,7 @@
 package linearModel
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/gcla/sklearn/base"
 	"github.com/gcla/sklearn/metrics"
 	"github.com/gcla/sklearn/preprocessing"
+	"gonum.org/v1/gonum/floats"
 	//"gonum.org/v1/gonum/diff/fd"
 	"math"
 	"math/rand"
@@ -26,6 +28,8 @@ type Activation = base.Activation
 type LinearModel struct {
 	FitIntercept, Normalize          bool
 	XOffset, XScale, Coef, Intercept *mat.Dense
+	// Scorer is used by Score when not nil
+	Scorer *metrics.Scorer
 }
 
 // LinearRegression ia Ordinary least squares Linear Regression.
@@ -57,10 +61,18 @@ type LinearRegression struct {
 	LossFunction        Loss
 	ActivationFunction  Activation
 	Options             LinFitOptions
+	// LossCurve and ValidationScores are copied from LinFitResult by Fit
+	LossCurve, ValidationScores []float64
+	// Solver, if not empty, is a key of DirectSolvers (normal,cholesky,qr,svd,lsqr) fitting the square loss without Optimizer.
+	// it then computes exactly the w minimizing ||y-X.w||^2 + Alpha*||w||^2 (as scikit-learn Ridge) that the iterative fit approaches.
+	// L1Ratio must be 0 and Tol is only used by lsqr
+	Solver string
+	// SampleWeight, if not nil, weights the samples. it needs a direct Solver
+	SampleWeight []float64
 }
 
 // NewLinearRegression create a *LinearRegression with defaults
-// implemented as a per-output optimization of (possibly regularized) square-loss a base.Optimizer (defaults to Adam)
+// implemented as a per-output optimization of (possibly regularized) square-loss a base.Optimizer (defaults to Adam), unless Solver is set
 func NewLinearRegression() *LinearRegression {
 	regr := &LinearRegression{Tol: 1e-6}
 	regr.Optimizer = base.NewAdamOptimizer()
@@ -73,19 +85,133 @@ func NewLinearRegression() *LinearRegression {
 
 // Fit fits Coef for a LinearRegression
 func (regr *LinearRegression) Fit(X0, Y0 *mat.Dense) base.Transformer {
+	regr.FitContext(context.Background(), X0, Y0)
+	return regr
+}
+
+// FitContext is Fit stopping at the next epoch once ctx is done. it then keeps the best Coef so far and returns ctx.Err()
+func (regr *LinearRegression) FitContext(ctx context.Context, X0, Y0 *mat.Dense) (base.Transformer, error) {
+	if regr.Solver != "" {
+		regr.fitDirect(X0, Y0)
+		return regr, nil
+	}
+	regr.checkNoSampleWeight()
 	X := mat.DenseCopyOf(X0)
 	regr.XOffset, regr.XScale = preprocessing.DenseNormalize(X, regr.FitIntercept, regr.Normalize)
 	Y := mat.DenseCopyOf(Y0)
 	YOffset, _ := preprocessing.DenseNormalize(Y, regr.FitIntercept, false)
+	res, err := LinFitContext(ctx, X, Y, regr.linFitOptions())
+	regr.Coef = res.Theta
+	regr.LossCurve, regr.ValidationScores = res.LossCurve, res.ValidationScores
+	regr.LinearModel.setIntercept(regr.XOffset, YOffset, regr.XScale)
+	return regr, err
+}
+
+// FitSparse fits Coef for a LinearRegression from a sparse X.
+// X is neither centered nor normalized to keep it sparse, so the intercept is fitted as the coefficient of a prepended column of ones
+func (regr *LinearRegression) FitSparse(X0 *base.CSR, Y0 *mat.Dense) base.Transformer {
+	regr.FitSparseContext(context.Background(), X0, Y0)
+	return regr
+}
+
+// FitSparseContext is FitSparse stopping at the next epoch once ctx is done. see FitContext
+func (regr *LinearRegression) FitSparseContext(ctx context.Context, X0 *base.CSR, Y0 *mat.Dense) (base.Transformer, error) {
+	if regr.Solver != "" {
+		panic(fmt.Errorf("%s solver needs a dense X", regr.Solver))
+	}
+	regr.checkNoSampleWeight()
+	var X *base.CSR
+	if regr.FitIntercept {
+		X = X0.OnesPrepended()
+	} else {
+		X = X0.Copy()
+	}
+	Y := mat.DenseCopyOf(Y0)
+	opts := regr.linFitOptions()
+	if regr.FitIntercept {
+		opts.Loss = interceptExcluded(opts.Loss)
+	}
+	res, err := LinFitContext(ctx, X, Y, opts)
+	regr.LossCurve, regr.ValidationScores = res.LossCurve, res.ValidationScores
+	regr.setSparseCoef(res.Theta)
+	return regr, err
+}
+
+func (regr *LinearRegression) linFitOptions() *LinFitOptions {
 	opt := regr.Options
 	opt.Tol = regr.Tol
 	opt.Solver = regr.Optimizer
 	opt.Loss = regr.LossFunction
 	opt.Activation = regr.ActivationFunction
-	res := LinFit(X, Y, &opt)
-	regr.Coef = res.Theta
+	opt.Alpha, opt.L1Ratio = regr.Alpha, regr.L1Ratio
+	return &opt
+}
+
+func (regr *LinearRegression) checkNoSampleWeight() {
+	if regr.SampleWeight != nil {
+		panic(fmt.Errorf("SampleWeight needs a direct Solver. see DirectSolvers"))
+	}
+}
+
+// fitDirect fits Coef and Intercept with DirectSolvers[regr.Solver] on X and Y centered (and normalized) with SampleWeight
+// weighted means, and their rows scaled by the square root of SampleWeight
+func (regr *LinearRegression) fitDirect(X0, Y0 *mat.Dense) {
+	solve, ok := DirectSolvers[regr.Solver]
+	if !ok {
+		panic(fmt.Errorf("unknown solver %q", regr.Solver))
+	}
+	if regr.L1Ratio != 0 {
+		panic(fmt.Errorf("%s solver needs L1Ratio 0, got %g. see ElasticNet", regr.Solver, regr.L1Ratio))
+	}
+	if _, isIdentity := regr.ActivationFunction.(base.Identity); regr.ActivationFunction != nil && !isIdentity {
+		panic(fmt.Errorf("%s solver needs identity activation, got %T", regr.Solver, regr.ActivationFunction))
+	}
+	nSamples, nFeatures := X0.Dims()
+	_, nOutputs := Y0.Dims()
+	weights := regr.SampleWeight
+	if weights == nil {
+		weights = make([]float64, nSamples)
+		floats.AddConst(1, weights)
+	} else if len(weights) != nSamples {
+		panic(fmt.Errorf("%d sample weights for %d samples", len(weights), nSamples))
+	}
+	sumWeights := floats.Sum(weights)
+	weightedMean := func(M *mat.Dense, j int) (mean float64) {
+		if regr.FitIntercept {
+			for i, w := range weights {
+				mean += w * M.At(i, j)
+			}
+			mean /= sumWeights
+		}
+		return
+	}
+	regr.XOffset, regr.XScale = mat.NewDense(1, nFeatures, nil), mat.NewDense(1, nFeatures, nil)
+	YOffset := mat.NewDense(1, nOutputs, nil)
+	for j := 0; j < nFeatures; j++ {
+		offset, scale := weightedMean(X0, j), 0.
+		if regr.Normalize {
+			for i, w := range weights {
+				scale += w * math.Pow(X0.At(i, j)-offset, 2)
+			}
+			scale = math.Sqrt(scale / sumWeights)
+		}
+		if scale == 0 {
+			scale = 1
+		}
+		regr.XOffset.Set(0, j, offset)
+		regr.XScale.Set(0, j, scale)
+	}
+	for o := 0; o < nOutputs; o++ {
+		YOffset.Set(0, o, weightedMean(Y0, o))
+	}
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, nOutputs, nil)
+	X.Apply(func(i, j int, _ float64) float64 {
+		return (X0.At(i, j) - regr.XOffset.At(0, j)) / regr.XScale.At(0, j) * math.Sqrt(weights[i])
+	}, X)
+	Y.Apply(func(i, o int, _ float64) float64 { return (Y0.At(i, o) - YOffset.At(0, o)) * math.Sqrt(weights[i]) }, Y)
+	regr.Coef = solve(X, Y, regr.Alpha, regr.Tol)
+	regr.LossCurve, regr.ValidationScores = nil, nil
 	regr.LinearModel.setIntercept(regr.XOffset, YOffset, regr.XScale)
-	return regr
 }
 
 // Predict predicts y for X using Coef
@@ -94,7 +220,7 @@ func (regr *LinearRegression) Predict(X, Y *mat.Dense) base.Regressor {
 	return regr
 }
 
-// NewRidge creates a *Ridge with defaults
+// NewRidge creates a *Ridge with defaults. set Solver (e.g. "cholesky") to solve it exactly
 func NewRidge() *LinearRegression {
 	regr := NewLinearRegression()
 	regr.Alpha = 1.
@@ -135,6 +261,8 @@ type SGDRegressor struct {
 	Tol, Alpha, L1Ratio float
 	NJobs               int
 	Method              optimize.Method
+	// Callbacks are called for each output at each major iteration of Method. see base.CallbackRecorder
+	Callbacks base.Callbacks
 }
 
 // NewSGDRegressor creates a *SGDRegressor with defaults
@@ -147,6 +275,12 @@ func NewSGDRegressor() *SGDRegressor {
 
 // Fit learns Coef
 func (regr *SGDRegressor) Fit(X0, y0 *mat.Dense) base.Transformer {
+	regr.FitContext(context.Background(), X0, y0)
+	return regr
+}
+
+// FitContext is Fit stopping at the next iteration once ctx is done. it then keeps the best Coef so far and returns ctx.Err()
+func (regr *SGDRegressor) FitContext(ctx context.Context, X0, y0 *mat.Dense) (base.Transformer, error) {
 	X := mat.DenseCopyOf(X0)
 	regr.XOffset, regr.XScale = preprocessing.DenseNormalize(X, regr.FitIntercept, regr.Normalize)
 	Y := mat.DenseCopyOf(y0)
@@ -242,6 +376,9 @@ func (regr *SGDRegressor) Fit(X0, y0 *mat.Dense) base.Transformer {
 		// printer := NewPrinter()
 		// printer.HeadingInterval = 1
 		// settings.Recorder = printer
+		if callbacks := regr.Callbacks.WithContext(ctx); len(callbacks) > 0 {
+			settings.Recorder = &base.CallbackRecorder{Callbacks: callbacks}
+		}
 
 		method := regr.Method
 		res, err := optimize.Local(p, initialcoefs, settings, method)
@@ -257,7 +394,7 @@ func (regr *SGDRegressor) Fit(X0, y0 *mat.Dense) base.Transformer {
 	// end use gonum gradient gradientDescent
 	regr.setIntercept(regr.XOffset, YOffset, regr.XScale)
 
-	return regr
+	return regr, ctx.Err()
 }
 
 // Predict predicts y from X using Coef
@@ -300,6 +437,21 @@ type LinFitOptions struct {
 	ThetaInitializer func(Theta *mat.Dense)
 	Recorder         optimize.Recorder
 	PerOutputFit     bool
+	// EarlyStopping holds out ValidationFraction (default .1) of the samples and stops when the validation score (the opposite of the unregularized validation loss)
+	// has not improved by at least Tol for NIterNoChange (default 10) epochs. Theta is then the best Theta on the validation set.
+	// without EarlyStopping, a positive NIterNoChange stops when the training loss has not decreased by at least Tol.
+	// they're only used with base.Optimizer solvers
+	EarlyStopping      bool
+	ValidationFraction float64
+	NIterNoChange      int
+	// LRScheduler, if not nil, is set as the scheduler of a base.LRScheduled Solver. a base.LossObserver observes the unregularized
+	// validation loss with EarlyStopping, else the training loss. see base.LRSchedulers
+	LRScheduler base.LRScheduler
+	// Callbacks are called on epoch begin and end and after each mini-batch. see base.CallbackInfo.
+	// with gonum/optimize methods, they're called by a base.CallbackRecorder, concurrently for each output when PerOutputFit
+	Callbacks base.Callbacks
+	// RandomState, if not nil, is used for Theta initialization, the validation split and shuffling, making runs reproducible
+	RandomState *rand.Rand
 }
 
 // LinFitResult is the result or LinFit
@@ -308,6 +460,8 @@ type LinFitResult struct {
 	RMSE, J   float64
 	Epoch     int
 	Theta     *mat.Dense
+	// LossCurve is the training loss of each epoch. ValidationScores is the validation score of each epoch when EarlyStopping
+	LossCurve, ValidationScores []float64
 }
 
 func initRecorder(recorder optimize.Recorder) (err error) {
@@ -319,10 +473,21 @@ func initRecorder(recorder optimize.Recorder) (err error) {
 	return recorder.Init()
 }
 
-// LinFit is an internal helper to fit linear regressions
-func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
+// LinFitContext is LinFit stopping at the next epoch once ctx is done. it then returns the best Theta so far and ctx.Err()
+func LinFitContext(ctx context.Context, X mat.Matrix, Ytrue *mat.Dense, opts *LinFitOptions) (*LinFitResult, error) {
+	ctxOpts := *opts
+	ctxOpts.Callbacks = opts.Callbacks.WithContext(ctx)
+	return LinFit(X, Ytrue, &ctxOpts), ctx.Err()
+}
+
+// LinFit is an internal helper to fit linear regressions. X can be a *mat.Dense or a *base.CSR
+func LinFit(X mat.Matrix, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 	nSamples, nFeatures := X.Dims()
 	_, nOutputs := Ytrue.Dims()
+	rnd := opts.RandomState
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(rand.Int63()))
+	}
 	if opts.GOMethodCreator == nil && opts.Solver == nil {
 		opts.GOMethodCreator = func() optimize.Method { return &optimize.LBFGS{} }
 	}
@@ -335,6 +500,16 @@ func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 		opts.PerOutputFit = true
 		return LinFitGOM(X, Ytrue, opts)
 	}
+	var Xval mat.Matrix
+	var Yval *mat.Dense
+	if opts.EarlyStopping {
+		X, Ytrue, Xval, Yval = validationSplit(X, Ytrue, opts.ValidationFraction, rnd)
+		nSamples, _ = X.Dims()
+	}
+	nIterNoChange := opts.NIterNoChange
+	if opts.EarlyStopping && nIterNoChange <= 0 {
+		nIterNoChange = 10
+	}
 
 	thetaSlice := make([]float64, nFeatures*nOutputs, nFeatures*nOutputs)
 	thetaSliceBest := make([]float64, nFeatures*nOutputs, nFeatures*nOutputs)
@@ -347,9 +522,11 @@ func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 		opts.ThetaInitializer(Theta)
 	} else {
 		Theta.Apply(func(i, j int, v float64) float64 {
-			return 0.01 * rand.Float64()
+			return 0.01 * rnd.Float64()
 		}, Theta)
 	}
+	// the initial Theta is the best so far if no epoch completes
+	copy(thetaSliceBest, thetaSlice)
 
 	var (
 		miniBatchStart = 0
@@ -376,6 +553,10 @@ func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 
 	s := opts.Solver
 	s.SetTheta(Theta)
+	if scheduled, ok := s.(base.LRScheduled); ok && opts.LRScheduler != nil {
+		scheduled.SetLRScheduler(opts.LRScheduler)
+	}
+	lossObserver, observesLoss := opts.LRScheduler.(base.LossObserver)
 	rmse := math.Inf(1.)
 	J := math.Inf(1.)
 	JBest := math.Inf(1.)
@@ -385,6 +566,31 @@ func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 		opts.Epochs = 1e6 / nSamples
 	}
 	var epoch int
+	var lossCurve, validationScores []float64
+	var YpredVal, YdiffVal *mat.Dense
+	var nVal int
+	if opts.EarlyStopping {
+		nVal, _ = Xval.Dims()
+		YpredVal, YdiffVal = mat.NewDense(nVal, nOutputs, nil), mat.NewDense(nVal, nOutputs, nil)
+	}
+	bestScore, noImprovement := math.Inf(-1), 0
+	stepSizer, hasStepSize := s.(base.StepSizer)
+	// notify calls event on Callbacks and applies a step size changed by a callback. it returns true if a callback stopped training
+	notify := func(event func(base.Callbacks, *base.CallbackInfo), epoch, miniBatch int, J float64) bool {
+		if len(opts.Callbacks) == 0 {
+			return false
+		}
+		info := &base.CallbackInfo{Epoch: epoch, MiniBatch: miniBatch, Loss: J, GradNorm: floats.Norm(gradSlice, 2), Theta: thetaSlice}
+		if hasStepSize {
+			info.StepSize = stepSizer.GetStepSize()
+		}
+		event(opts.Callbacks, info)
+		if hasStepSize && info.StepSize != stepSizer.GetStepSize() {
+			stepSizer.SetStepSize(info.StepSize)
+		}
+		return info.Stop
+	}
+	stopped := false
 	var hasRecorder = initRecorder(opts.Recorder) == nil
 	if hasRecorder {
 		opts.Recorder.Record(
@@ -393,7 +599,10 @@ func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 			&optimize.Stats{MajorIterations: epoch, FuncEvaluations: epoch, GradEvaluations: epoch, Runtime: time.Since(start)})
 	}
 	for epoch = 1; epoch <= opts.Epochs && !converged; epoch++ {
-		base.MatShuffle(X, Ytrue)
+		if notify(base.Callbacks.OnEpochBegin, epoch-1, 0, J) {
+			break
+		}
+		matShuffle(X, Ytrue, rnd)
 		for miniBatch := 0; miniBatch*miniBatchSize < nSamples; miniBatch++ {
 			miniBatchStart = miniBatch * miniBatchSize
 			miniBatchEnd := miniBatchStart + miniBatchSize
@@ -404,13 +613,17 @@ func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 
 			J = opts.Loss(
 				Ytrue.Slice(miniBatchStart, miniBatchEnd, 0, nOutputs),
-				X.Slice(miniBatchStart, miniBatchEnd, 0, nFeatures),
+				matRowSlice(X, miniBatchStart, miniBatchEnd),
 				Theta,
 				YpredMini.Slice(0, miniBatchRows, 0, nOutputs).(*mat.Dense),
 				YdiffMini.Slice(0, miniBatchRows, 0, nOutputs).(*mat.Dense),
 				grad,
 				opts.Alpha, opts.L1Ratio, nSamples, opts.Activation)
 			s.UpdateParams(grad)
+			if notify(base.Callbacks.OnMiniBatchEnd, epoch-1, miniBatch, J) {
+				stopped = true
+				break
+			}
 		}
 		J = opts.Loss(
 			Ytrue,
@@ -420,13 +633,44 @@ func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 			Ydiff,
 			grad,
 			opts.Alpha, opts.L1Ratio, nSamples, opts.Activation)
-		if J < JBest {
-			JBest = J
-			copy(thetaSliceBest, thetaSlice)
+		lossCurve = append(lossCurve, J)
+		if opts.EarlyStopping {
+			score := -opts.Loss(Yval, Xval, Theta, YpredVal, YdiffVal, nil, 0, 0, nVal, opts.Activation)
+			validationScores = append(validationScores, score)
+			if score > bestScore {
+				JBest = J
+				copy(thetaSliceBest, thetaSlice)
+			}
+			if score > bestScore+opts.Tol {
+				noImprovement = 0
+			} else {
+				noImprovement++
+			}
+			bestScore = math.Max(bestScore, score)
+		} else {
+			if nIterNoChange > 0 {
+				if J < JBest-opts.Tol {
+					noImprovement = 0
+				} else {
+					noImprovement++
+				}
+			}
+			if J < JBest {
+				JBest = J
+				copy(thetaSliceBest, thetaSlice)
+			}
+		}
+		if observesLoss {
+			loss := J
+			if opts.EarlyStopping {
+				loss = -validationScores[len(validationScores)-1]
+			}
+			lossObserver.ObserveLoss(loss)
 		}
 		rmse = math.Sqrt(metrics.MeanSquaredError(Ytrue, Ypred, nil, "").At(0, 0))
 
-		converged = math.Sqrt(rmse) < opts.Tol
+		converged = math.Sqrt(rmse) < opts.Tol || (nIterNoChange > 0 && noImprovement >= nIterNoChange)
+		stopped = notify(base.Callbacks.OnEpochEnd, epoch-1, 0, J) || stopped
 		//fmt.Println(epoch, J)
 		if hasRecorder {
 			opts.Recorder.Record(
@@ -434,14 +678,47 @@ func LinFit(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 				optimize.InitIteration,
 				&optimize.Stats{MajorIterations: epoch, FuncEvaluations: epoch, GradEvaluations: epoch, Runtime: time.Since(start)})
 		}
+		if stopped {
+			break
+		}
 	}
 	J = JBest
 	Theta = mat.NewDense(nFeatures, nOutputs, thetaSliceBest)
-	return &LinFitResult{Converged: converged, RMSE: rmse, J: J, Epoch: epoch, Theta: Theta}
+	return &LinFitResult{Converged: converged, RMSE: rmse, J: J, Epoch: epoch, Theta: Theta, LossCurve: lossCurve, ValidationScores: validationScores}
 }
 
-// LinFitGOM fits a regression with a gonum/optimizer Method
-func LinFitGOM(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
+// validationSplit returns a random split of X,Y with validationFraction (default .1) of the samples in Xval,Yval.
+// Xtrain and Ytrain share the data of X and Y, which are shuffled with rnd
+func validationSplit(X mat.Matrix, Y *mat.Dense, validationFraction float64, rnd *rand.Rand) (Xtrain mat.Matrix, Ytrain *mat.Dense, Xval mat.Matrix, Yval *mat.Dense) {
+	nSamples, _ := X.Dims()
+	_, nOutputs := Y.Dims()
+	if validationFraction <= 0 || validationFraction >= 1 {
+		validationFraction = .1
+	}
+	nVal := int(math.Ceil(validationFraction * float64(nSamples)))
+	if nVal >= nSamples {
+		panic(fmt.Errorf("validation set of %d samples leaves no training samples", nVal))
+	}
+	nTrain := nSamples - nVal
+	matShuffle(X, Y, rnd)
+	if Xs, ok := X.(*base.CSR); ok {
+		// CSR row slices can't be shuffled independently
+		train, val := make([]int, nTrain), make([]int, nVal)
+		for i := range train {
+			train[i] = i
+		}
+		for i := range val {
+			val[i] = nTrain + i
+		}
+		Xtrain, Xval = Xs.RowsSubset(train), Xs.RowsSubset(val)
+	} else {
+		Xtrain, Xval = matRowSlice(X, 0, nTrain), mat.DenseCopyOf(matRowSlice(X, nTrain, nSamples))
+	}
+	return Xtrain, Y.Slice(0, nTrain, 0, nOutputs).(*mat.Dense), Xval, mat.DenseCopyOf(Y.Slice(nTrain, nSamples, 0, nOutputs))
+}
+
+// LinFitGOM fits a regression with a gonum/optimizer Method. X can be a *mat.Dense or a *base.CSR
+func LinFitGOM(X mat.Matrix, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 	nSamples, nFeatures := X.Dims()
 	_, nOutputs := Ytrue.Dims()
 
@@ -459,6 +736,9 @@ func LinFitGOM(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 	fSettings := func() *optimize.Settings {
 		settings := optimize.DefaultSettings()
 		settings.Recorder = opts.Recorder
+		if len(opts.Callbacks) > 0 {
+			settings.Recorder = &base.CallbackRecorder{Callbacks: opts.Callbacks, Recorder: opts.Recorder}
+		}
 		settings.GradientThreshold = 1e-12
 		settings.FunctionConverge = nil
 		settings.FuncEvaluations = opts.Epochs
@@ -470,8 +750,12 @@ func LinFitGOM(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 
 	theta := make([]float64, nFeatures*nOutputs, nFeatures*nOutputs)
 	thetaM := mat.NewDense(nFeatures, nOutputs, theta)
+	rnd := opts.RandomState
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(rand.Int63()))
+	}
 	for j := 0; j < len(theta); j++ {
-		theta[j] = 0.01 * rand.NormFloat64()
+		theta[j] = 0.01 * rnd.NormFloat64()
 	}
 	var ret *optimize.Result
 	var err error
@@ -544,6 +828,33 @@ func LinFitGOM(X, Ytrue *mat.Dense, opts *LinFitOptions) *LinFitResult {
 	return &LinFitResult{Converged: converged, RMSE: rmse, Epoch: epoch, Theta: thetaM}
 }
 
+// matShuffle shuffles the rows of X (a *mat.Dense or a *base.CSR) and Y with a permutation drawn from rnd
+func matShuffle(X mat.Matrix, Y *mat.Dense, rnd *rand.Rand) {
+	nSamples, _ := X.Dims()
+	perm := rnd.Perm(nSamples)
+	switch Xm := X.(type) {
+	case *mat.Dense:
+		(&preprocessing.Shuffler{Perm: perm}).Transform(Xm, Y)
+	case *base.CSR:
+		base.MatShuffleCSR(Xm, Y, perm)
+	default:
+		panic(fmt.Errorf("can't shuffle a %T", X))
+	}
+}
+
+// matRowSlice returns rows i..k-1 of X (a *mat.Dense or a *base.CSR) without copying
+func matRowSlice(X mat.Matrix, i, k int) mat.Matrix {
+	switch Xm := X.(type) {
+	case *mat.Dense:
+		_, nFeatures := Xm.Dims()
+		return Xm.Slice(i, k, 0, nFeatures)
+	case *base.CSR:
+		return Xm.RowSlice(i, k)
+	default:
+		return base.MatRowSlice{Matrix: X, Start: i, End: k}
+	}
+}
+
 var copyStruct = base.CopyStruct
 
 /*
@@ -584,6 +895,21 @@ func (regr *LinearModel) setIntercept(XOffset, YOffset, XScale mat.Matrix) {
 	}
 }
 
+// setSparseCoef sets Coef and Intercept from a theta fitted on a sparse X with ones prepended if FitIntercept
+func (regr *LinearModel) setSparseCoef(Theta *mat.Dense) {
+	nFeatures, nOutputs := Theta.Dims()
+	regr.Intercept = mat.NewDense(1, nOutputs, nil)
+	if regr.FitIntercept {
+		nFeatures--
+		regr.Intercept.Copy(Theta.RowView(0).T())
+		regr.Coef = mat.DenseCopyOf(Theta.Slice(1, 1+nFeatures, 0, nOutputs))
+	} else {
+		regr.Coef = Theta
+	}
+	regr.XOffset = mat.NewDense(1, nFeatures, nil)
+	regr.XScale = mat.DenseCopyOf(base.MatConst{Rows: 1, Columns: nFeatures, Value: 1.})
+}
+
 func dims(mats ...mat.Matrix) string {
 	s := ""
 	for _, m := range mats {
@@ -609,18 +935,24 @@ func chkdims(op string, R, X, Y mat.Matrix) {
 	}
 }
 
-// DecisionFunction fills Y with X dot Coef+Intercept
-func (regr *LinearModel) DecisionFunction(X, Y *mat.Dense) {
+// DecisionFunction fills Y with X dot Coef+Intercept. X can be a *mat.Dense or a *base.CSR
+func (regr *LinearModel) DecisionFunction(X mat.Matrix, Y *mat.Dense) {
 	chkdims(".", Y, X, regr.Coef)
-	Y.Mul(X, regr.Coef)
+	base.MatMul(Y, X, regr.Coef)
 	Y.Apply(func(j int, o int, v float64) float64 {
 
 		return v + regr.Intercept.At(0, o)
 	}, Y)
 }
 
-// Score returns R2Score between Y and X dot Coef+Intercept
+// GetCoef returns Coef. it lets feature selection use any linear model
+func (regr *LinearModel) GetCoef() *mat.Dense { return regr.Coef }
+
+// Score returns R2Score between Y and X dot Coef+Intercept, or the Scorer score if set
 func (regr *LinearModel) Score(X, Y *mat.Dense) float64 {
+	if regr.Scorer != nil {
+		return regr.Scorer.Score(regr, X, Y)
+	}
 	nSamples, nOutputs := Y.Dims()
 	Ypred := mat.NewDense(nSamples, nOutputs, nil)
 	regr.DecisionFunction(X, Ypred)
@@ -1,6 +1,7 @@
 package linearModel
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
@@ -126,6 +127,11 @@ func TestRidge(t *testing.T) {
 		} else {
 			//fmt.Printf("Test %T ok normalize=%v r2score=%g  mse=%g mae=%g elapsed=%s\n", regr, normalize, r2score, mse, mae, elapsed)
 		}
+		// NewRidge is iterative unless Solver is set, so it records LossCurve and accepts sparse X
+		if len(regr.LossCurve) == 0 {
+			t.Error("expected a LossCurve")
+		}
+		NewRidge().FitSparse(base.NewCSRFromMatrix(p.X), p.Y)
 	}
 
 }
@@ -340,3 +346,223 @@ func TestBestRegressionImplementation(t *testing.T) {
 	fmt.Printf("Test Regression implementations BEST SETUP:%v\n\n", bestSetup)
 
 }
+
+func TestLinearModelScorer(t *testing.T) {
+	X, Y := mat.NewDense(3, 1, []float64{1, 2, 3}), mat.NewDense(3, 1, []float64{3, 5, 8})
+	regr := &LinearModel{Coef: mat.NewDense(1, 1, []float64{2}), Intercept: mat.NewDense(1, 1, []float64{1})}
+	if r2 := regr.Score(X, Y); math.Abs(r2-(1-1./(38./3))) > 1e-12 {
+		t.Errorf("expected r2 %g got %g", 1-1./(38./3), r2)
+	}
+	regr.Scorer = metrics.GetScorer("neg_mean_squared_error")
+	if score := regr.Score(X, Y); math.Abs(score+1./3) > 1e-12 {
+		t.Errorf("expected %g got %g", -1./3, score)
+	}
+}
+
+func TestLinearRegressionFitSparse(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 200, 5
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 2, nil)
+	X.Apply(func(_, _ int, _ float64) float64 {
+		if rnd.Float64() < .7 {
+			return 0
+		}
+		return rnd.NormFloat64()
+	}, X)
+	Y.Apply(func(i, o int, _ float64) float64 {
+		return 1 + float64(o) + 2*X.At(i, 0) - X.At(i, 3) + float64(o)*X.At(i, 4) + .1*rnd.NormFloat64()
+	}, Y)
+	for _, fitIntercept := range []bool{true, false} {
+		dense, sparse := NewLinearRegression(), NewLinearRegression()
+		for _, regr := range []*LinearRegression{dense, sparse} {
+			regr.FitIntercept = fitIntercept
+			regr.Options.GOMethodCreator = func() optimize.Method { return &optimize.LBFGS{} }
+		}
+		dense.Fit(X, Y)
+		sparse.FitSparse(base.NewCSRFromMatrix(X), Y)
+		if !mat.EqualApprox(dense.Coef, sparse.Coef, 1e-6) || !mat.EqualApprox(dense.Intercept, sparse.Intercept, 1e-6) {
+			t.Errorf("FitIntercept=%v: sparse coef %v intercept %v differ from dense %v %v", fitIntercept,
+				sparse.Coef.RawMatrix().Data, sparse.Intercept.RawMatrix().Data, dense.Coef.RawMatrix().Data, dense.Intercept.RawMatrix().Data)
+		}
+	}
+}
+
+func TestLinFitEarlyStopping(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	nSamples := 1000
+	X, Y := mat.NewDense(nSamples, 2, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return 1 + 2*X.At(i, 0) - X.At(i, 1) + .1*rnd.NormFloat64() }, Y)
+	regr := NewLinearRegression()
+	regr.Options.Epochs = 1000
+	regr.Options.EarlyStopping = true
+	regr.Options.NIterNoChange = 5
+	regr.Options.RandomState = rnd
+	regr.Fit(X, Y)
+	epochs := len(regr.LossCurve)
+	if epochs == 0 || epochs >= 1000 || len(regr.ValidationScores) != epochs {
+		t.Errorf("unexpected %d epochs and %d validation scores", epochs, len(regr.ValidationScores))
+	}
+	if math.Abs(regr.Coef.At(0, 0)-2) > .1 || math.Abs(regr.Coef.At(1, 0)+1) > .1 {
+		t.Errorf("unexpected Coef %v", mat.Formatted(regr.Coef.T()))
+	}
+}
+
+func TestCallbacks(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples := 200
+	X, Y := mat.NewDense(nSamples, 2, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return 1 + 2*X.At(i, 0) - X.At(i, 1) }, Y)
+	stopAt := func(epoch int, epochs *int) base.Callback {
+		return base.CallbackFuncs{EpochEnd: func(info *base.CallbackInfo) {
+			*epochs = info.Epoch + 1
+			info.Stop = info.Epoch == epoch
+		}}
+	}
+	var epochs, miniBatches int
+	lr := NewLinearRegression()
+	lr.Options.Epochs = 1000
+	lr.Options.Callbacks = base.Callbacks{stopAt(4, &epochs), base.CallbackFuncs{
+		MiniBatchEnd: func(info *base.CallbackInfo) {
+			miniBatches++
+			info.StepSize = .1
+		}}}
+	lr.Fit(X, Y)
+	if epochs != 5 || len(lr.LossCurve) != 5 || miniBatches == 0 || lr.Optimizer.(base.StepSizer).GetStepSize() != .1 {
+		t.Errorf("LinearRegression: expected stop after 5 epochs with step size .1, got %d epochs, %d losses", epochs, len(lr.LossCurve))
+	}
+
+	sgd := NewSGDRegressor()
+	sgd.Callbacks = base.Callbacks{stopAt(2, &epochs)}
+	sgd.Fit(X, Y)
+	if epochs != 3 {
+		t.Errorf("SGDRegressor: expected stop after 3 iterations, got %d", epochs)
+	}
+
+	br := NewBayesianRidge()
+	br.Tol = 0
+	br.Callbacks = base.Callbacks{stopAt(6, &epochs)}
+	br.Fit(X, Y)
+	if epochs != 7 {
+		t.Errorf("BayesianRidge: expected stop after 7 iterations, got %d", epochs)
+	}
+}
+
+func TestFitContext(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples := 200
+	X, Y := mat.NewDense(nSamples, 2, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return 1 + 2*X.At(i, 0) - X.At(i, 1) }, Y)
+	// cancelAt returns a context canceled at the end of epoch and a callback counting epochs
+	cancelAt := func(epoch int, epochs *int) (context.Context, base.Callback) {
+		ctx, cancel := context.WithCancel(context.Background())
+		return ctx, base.CallbackFuncs{EpochEnd: func(info *base.CallbackInfo) {
+			*epochs = info.Epoch + 1
+			if info.Epoch == epoch {
+				cancel()
+			}
+		}}
+	}
+	var epochs int
+	lr := NewLinearRegression()
+	lr.Options.Epochs = 1000
+	ctx, cb := cancelAt(3, &epochs)
+	lr.Options.Callbacks = base.Callbacks{cb}
+	if _, err := lr.FitContext(ctx, X, Y); err != context.Canceled || epochs != 4 || len(lr.LossCurve) != 4 || lr.Coef == nil {
+		t.Errorf("LinearRegression: expected Canceled after 4 epochs, got %v after %d epochs", err, epochs)
+	}
+	gom := NewLinearRegression()
+	gom.Options.GOMethodCreator = func() optimize.Method { return &optimize.GradientDescent{} }
+	ctx, cb = cancelAt(3, &epochs)
+	gom.Options.Callbacks = base.Callbacks{cb}
+	if _, err := gom.FitContext(ctx, X, Y); err != context.Canceled || epochs != 4 {
+		t.Errorf("LinearRegression with gonum method: expected Canceled after 4 iterations, got %v after %d", err, epochs)
+	}
+
+	sgd := NewSGDRegressor()
+	sgd.Method = &optimize.GradientDescent{}
+	ctx, cb = cancelAt(2, &epochs)
+	sgd.Callbacks = base.Callbacks{cb}
+	if _, err := sgd.FitContext(ctx, X, Y); err != context.Canceled || epochs != 3 {
+		t.Errorf("SGDRegressor: expected Canceled after 3 iterations, got %v after %d", err, epochs)
+	}
+
+	br := NewBayesianRidge()
+	br.Tol = 0
+	ctx, cb = cancelAt(5, &epochs)
+	br.Callbacks = base.Callbacks{cb}
+	if _, err := br.FitContext(ctx, X, Y); err != context.Canceled || epochs != 6 {
+		t.Errorf("BayesianRidge: expected Canceled after 6 iterations, got %v after %d", err, epochs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	lr = NewLinearRegression()
+	// with Tol 0, only the deadline can stop the fit on noiseless data
+	lr.Tol = 0
+	lr.Options.Epochs = 1e6
+	if _, err := lr.FitContext(ctx, X, Y); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+
+	// a context done before the first epoch keeps the initial Theta
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	opts := &LinFitOptions{Epochs: 10, Solver: base.NewAdamOptimizer(), ThetaInitializer: func(Theta *mat.Dense) {
+		Theta.Apply(func(_, _ int, _ float64) float64 { return .5 }, Theta)
+	}}
+	res, err := LinFitContext(ctx, X, Y, opts)
+	if err != context.Canceled || res.Epoch != 1 || mat.Sum(res.Theta) != 1 {
+		t.Errorf("expected Canceled with initial Theta, got %v with Theta %v", err, mat.Formatted(res.Theta.T()))
+	}
+}
+
+func TestLinFitLRScheduler(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples := 1000
+	X, Y := mat.NewDense(nSamples, 2, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return 1 + 2*X.At(i, 0) - X.At(i, 1) }, Y)
+	for name := range base.LRSchedulers {
+		regr := NewLinearRegression()
+		regr.Options.Epochs = 300
+		regr.Options.LRScheduler = base.NewLRScheduler(name)
+		regr.Fit(X, Y)
+		if regr.Optimizer.(*base.SGDOptimizer).Scheduler == nil {
+			t.Errorf("%s: Scheduler not set", name)
+		}
+		if math.Abs(regr.Coef.At(0, 0)-2) > .05 || math.Abs(regr.Coef.At(1, 0)+1) > .05 {
+			t.Errorf("%s: unexpected Coef %v", name, mat.Formatted(regr.Coef.T()))
+		}
+	}
+}
+
+func TestLinFitSolvers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples := 1000
+	X, Y := mat.NewDense(nSamples, 2, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return 1 + 2*X.At(i, 0) - X.At(i, 1) }, Y)
+	for _, name := range []string{"nesterov", "nadam", "adamw", "amsgrad", "adamax", "lamb"} {
+		regr := NewLinearRegression()
+		regr.Optimizer = base.NewOptimizer(name)
+		regr.Options.Epochs = 500
+		regr.Fit(X, Y)
+		if math.Abs(regr.Coef.At(0, 0)-2) > .05 || math.Abs(regr.Coef.At(1, 0)+1) > .05 {
+			t.Errorf("%s: unexpected Coef %v", name, mat.Formatted(regr.Coef.T()))
+		}
+
+		gom := NewLinearRegression()
+		gom.Options.GOMethodCreator = base.GOMethodCreators[name]
+		if name == "lamb" {
+			// lamb steps are proportional to ||Theta||, which starts around .01
+			gom.Options.GOMethodCreator = func() optimize.Method { o := base.NewLAMBOptimizer(); o.StepSize = .003; return o }
+		}
+		gom.Fit(X, Y)
+		if math.Abs(gom.Coef.At(0, 0)-2) > .05 || math.Abs(gom.Coef.At(1, 0)+1) > .05 {
+			t.Errorf("%s gonum method: unexpected Coef %v", name, mat.Formatted(gom.Coef.T()))
+		}
+	}
+}
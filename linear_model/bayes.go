@@ -1,6 +1,7 @@
 package linearModel
 
 import (
+	"context"
 	"fmt"
 	"math"
 
@@ -20,6 +21,8 @@ type BayesianRidge struct {
 	Alpha, Lambda                         float
 	Sigma                                 *mat.Dense
 	Scores                                []float
+	// Callbacks are called at each iteration, which counts as an epoch. Loss is the mean squared error, GradNorm and StepSize are 0
+	Callbacks base.Callbacks
 }
 
 // NewBayesianRidge creates a *BayesianRidge with defaults
@@ -39,6 +42,13 @@ func NewBayesianRidge() *BayesianRidge {
 //         y : numpy array of shape [nSamples]
 //             Target values. Will be cast to X's dtype if necessary
 func (regr *BayesianRidge) Fit(X0, Y *mat.Dense) base.Transformer {
+	regr.FitContext(context.Background(), X0, Y)
+	return regr
+}
+
+// FitContext is Fit stopping at the next iteration once ctx is done. it then keeps the last Coef and returns ctx.Err()
+func (regr *BayesianRidge) FitContext(ctx context.Context, X0, Y *mat.Dense) (base.Transformer, error) {
+	callbacks := regr.Callbacks.WithContext(ctx)
 	var nSamples, nFeatures = X0.Dims()
 	var _, nOutputs = Y.Dims()
 	X := mat.NewDense(nSamples, nFeatures, nil)
@@ -98,9 +108,23 @@ func (regr *BayesianRidge) Fit(X0, Y *mat.Dense) base.Transformer {
 	diff := mat.NewDense(nSamples, nOutputs, nil)
 
 	coef2 := mat.NewDense(nFeatures, nOutputs, nil)
+	mse := func() float {
+		norm := mat.Norm(diff, 2)
+		return norm * norm / float(nSamples*nOutputs)
+	}
 
 	// # Convergence loop of the bayesian RidgeMatMat regression
 	for iter := 0; iter < regr.NIter; iter++ {
+		if len(callbacks) > 0 {
+			info := &base.CallbackInfo{Epoch: iter, Loss: math.Inf(1), Theta: coef.RawMatrix().Data}
+			if iter > 0 {
+				info.Loss = mse()
+			}
+			callbacks.OnEpochBegin(info)
+			if info.Stop {
+				break
+			}
+		}
 		// # Compute mu and sigma
 		// # sigma = lambda / alpha * np.eye(nFeatures) + np.dot(X.T, X)
 		// # coef = sigma^-1 * XT * y
@@ -161,6 +185,13 @@ func (regr *BayesianRidge) Fit(X0, Y *mat.Dense) base.Transformer {
 				float(nSamples)*log(2*math.Pi))
 			regr.Scores = append(regr.Scores, s)
 		}
+		if len(callbacks) > 0 {
+			info := &base.CallbackInfo{Epoch: iter, Loss: mse(), Theta: coef.RawMatrix().Data}
+			callbacks.OnEpochEnd(info)
+			if info.Stop {
+				break
+			}
+		}
 		// # Check for convergence
 		if iter > 0 {
 			sumabsdiff := 0.
@@ -193,7 +224,7 @@ func (regr *BayesianRidge) Fit(X0, Y *mat.Dense) base.Transformer {
 	regr.setIntercept(XOffset, YOffset, XScale)
 	regr.XOffset = XOffset
 	regr.XScale = XScale
-	return regr
+	return regr, ctx.Err()
 }
 
 // Predict using the linear model.
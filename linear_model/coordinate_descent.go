@@ -0,0 +1,438 @@
+package linearModel
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gcla/sklearn/base"
+	"github.com/gcla/sklearn/preprocessing"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// ElasticNet is a linear regression with L1 and L2 regularization fitted by coordinate descent. for each output, it minimizes
+// 1/(2*nSamples)*||y-X.w||^2 + Alpha*L1Ratio*||w||_1 + Alpha*(1-L1Ratio)/2*||w||^2
+// as scikit-learn ElasticNet. unlike NewLasso, L1 regularization gives exact zero coefficients.
+// iterations stop when the duality gap is below Tol*||y||^2, or after MaxIter passes over the features
+type ElasticNet struct {
+	LinearModel
+	Alpha, L1Ratio float64
+	MaxIter        int
+	Tol            float64
+	// WarmStart has Fit start from the current Coef
+	WarmStart bool
+	// NIter is the max number of passes over the features for an output in the last Fit, DualGap the max final duality gap
+	NIter   int
+	DualGap float64
+}
+
+// NewElasticNet returns an *ElasticNet with Alpha 1 and L1Ratio .5
+func NewElasticNet() *ElasticNet {
+	regr := &ElasticNet{Alpha: 1, L1Ratio: .5, MaxIter: 1000, Tol: 1e-4}
+	regr.FitIntercept = true
+	return regr
+}
+
+// NewLassoCD returns an *ElasticNet with Alpha 1 and L1Ratio 1, the coordinate descent Lasso
+func NewLassoCD() *ElasticNet {
+	regr := NewElasticNet()
+	regr.L1Ratio = 1
+	return regr
+}
+
+// Fit fits Coef and Intercept
+func (regr *ElasticNet) Fit(X0, Y0 *mat.Dense) base.Transformer {
+	X, Y := mat.DenseCopyOf(X0), mat.DenseCopyOf(Y0)
+	XOffset, XScale := preprocessing.DenseNormalize(X, regr.FitIntercept, regr.Normalize)
+	YOffset, _ := preprocessing.DenseNormalize(Y, regr.FitIntercept, false)
+	_, nFeatures := X.Dims()
+	_, nOutputs := Y.Dims()
+	coef := mat.NewDense(nFeatures, nOutputs, nil)
+	if regr.WarmStart && regr.Coef != nil {
+		if r, c := regr.Coef.Dims(); r == nFeatures && c == nOutputs {
+			coef.Apply(func(j, o int, _ float64) float64 { return regr.Coef.At(j, o) * XScale.At(0, j) }, coef)
+		}
+	}
+	cd := newCoordinateDescent(X)
+	regr.NIter, regr.DualGap = 0, 0
+	w := make([]float64, nFeatures)
+	for o := 0; o < nOutputs; o++ {
+		mat.Col(w, o, coef)
+		nIter, gap := cd.fit(w, mat.Col(nil, o, Y), regr.Alpha, regr.L1Ratio, regr.MaxIter, regr.Tol)
+		coef.SetCol(o, w)
+		if nIter > regr.NIter {
+			regr.NIter = nIter
+		}
+		regr.DualGap = math.Max(regr.DualGap, gap)
+	}
+	regr.Coef = coef
+	regr.setIntercept(XOffset, YOffset, XScale)
+	return regr
+}
+
+// Predict predicts y for X using Coef
+func (regr *ElasticNet) Predict(X, Y *mat.Dense) base.Regressor {
+	regr.DecisionFunction(X, Y)
+	return regr
+}
+
+// FitTransform is for Pipeline
+func (regr *ElasticNet) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	r, c := Y.Dims()
+	Xout, Yout = X, mat.NewDense(r, c, nil)
+	regr.Fit(X, Y)
+	regr.Predict(X, Yout)
+	return
+}
+
+// Transform is for Pipeline
+func (regr *ElasticNet) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	r, c := Y.Dims()
+	Xout, Yout = X, mat.NewDense(r, c, nil)
+	regr.Predict(X, Yout)
+	return
+}
+
+// coordinateDescent holds the columns of X and their squared norms
+type coordinateDescent struct {
+	cols     [][]float64
+	norms    []float64
+	nSamples int
+}
+
+func newCoordinateDescent(X mat.Matrix) *coordinateDescent {
+	nSamples, nFeatures := X.Dims()
+	cd := &coordinateDescent{cols: make([][]float64, nFeatures), norms: make([]float64, nFeatures), nSamples: nSamples}
+	for j := range cd.cols {
+		cd.cols[j] = mat.Col(nil, j, X)
+		cd.norms[j] = floats.Dot(cd.cols[j], cd.cols[j])
+	}
+	return cd
+}
+
+// fit minimizes the ElasticNet objective for y starting from w, which is updated.
+// it returns the number of passes over the features and the final duality gap. see scikit-learn enet_coordinate_descent
+func (cd *coordinateDescent) fit(w, y []float64, alpha, l1Ratio float64, maxIter int, tol float64) (nIter int, gap float64) {
+	n := float64(cd.nSamples)
+	l1, l2 := alpha*l1Ratio*n, alpha*(1-l1Ratio)*n
+	// R is the residual y-X.w
+	R := append([]float64(nil), y...)
+	for j, wj := range w {
+		if wj != 0 {
+			floats.AddScaled(R, -wj, cd.cols[j])
+		}
+	}
+	gapTol := tol * floats.Dot(y, y)
+	gap = math.Inf(1)
+	for nIter = 1; nIter <= maxIter; nIter++ {
+		maxW, maxDW := 0., 0.
+		for j, col := range cd.cols {
+			if cd.norms[j] == 0 {
+				continue
+			}
+			wj := w[j]
+			if wj != 0 {
+				floats.AddScaled(R, wj, col)
+			}
+			tmp := floats.Dot(col, R)
+			w[j] = math.Copysign(math.Max(math.Abs(tmp)-l1, 0), tmp) / (cd.norms[j] + l2)
+			if w[j] != 0 {
+				floats.AddScaled(R, -w[j], col)
+			}
+			maxDW = math.Max(maxDW, math.Abs(w[j]-wj))
+			maxW = math.Max(maxW, math.Abs(w[j]))
+		}
+		if maxW == 0 || maxDW/maxW < tol || nIter == maxIter {
+			gap = cd.dualGap(w, y, R, l1, l2)
+			if gap < gapTol {
+				break
+			}
+		}
+	}
+	if nIter > maxIter {
+		nIter = maxIter
+	}
+	return
+}
+
+// dualGap returns the duality gap of the ElasticNet objective (scaled by nSamples) at w, R being y-X.w
+func (cd *coordinateDescent) dualGap(w, y, R []float64, l1, l2 float64) float64 {
+	dualNorm := 0.
+	for j, col := range cd.cols {
+		dualNorm = math.Max(dualNorm, math.Abs(floats.Dot(col, R)-l2*w[j]))
+	}
+	RNorm2, wNorm2 := floats.Dot(R, R), floats.Dot(w, w)
+	c, gap := 1., RNorm2
+	if dualNorm > l1 {
+		c = l1 / dualNorm
+		gap = .5 * (RNorm2 + RNorm2*c*c)
+	}
+	return gap + l1*floats.Norm(w, 1) - c*floats.Dot(R, y) + .5*l2*(1+c*c)*wNorm2
+}
+
+// AlphaGrid returns nAlphas alphas decreasing log-spaced from the smallest alpha giving all zero ElasticNet coefficients
+// to eps times it. X and Y are expected to be centered. l1Ratio must be > 0
+func AlphaGrid(X, Y *mat.Dense, l1Ratio, eps float64, nAlphas int) []float64 {
+	if l1Ratio <= 0 {
+		panic(fmt.Errorf("automatic alpha grid needs l1Ratio>0, got %g", l1Ratio))
+	}
+	nSamples, _ := X.Dims()
+	var XTY mat.Dense
+	XTY.Mul(X.T(), Y)
+	alphaMax := math.Max(mat.Max(&XTY), -mat.Min(&XTY)) / float64(nSamples) / l1Ratio
+	alphas := make([]float64, nAlphas)
+	for k := range alphas {
+		alphas[k] = alphaMax
+		if nAlphas > 1 {
+			alphas[k] *= math.Pow(eps, float64(k)/float64(nAlphas-1))
+		}
+	}
+	return alphas
+}
+
+// EnetPath returns the ElasticNet coefficients (nFeatures x nOutputs) for each of alphas, each fit being warm started
+// from the previous one, so alphas should be decreasing. no intercept is fitted: X and Y are expected to be centered
+func EnetPath(X, Y *mat.Dense, l1Ratio float64, alphas []float64) []*mat.Dense {
+	_, nFeatures := X.Dims()
+	_, nOutputs := Y.Dims()
+	cd := newCoordinateDescent(X)
+	defaults := NewElasticNet()
+	coefs := make([]*mat.Dense, len(alphas))
+	for k := range coefs {
+		coefs[k] = mat.NewDense(nFeatures, nOutputs, nil)
+	}
+	w := make([]float64, nFeatures)
+	for o := 0; o < nOutputs; o++ {
+		y := mat.Col(nil, o, Y)
+		for j := range w {
+			w[j] = 0
+		}
+		for k, alpha := range alphas {
+			cd.fit(w, y, alpha, l1Ratio, defaults.MaxIter, defaults.Tol)
+			coefs[k].SetCol(o, w)
+		}
+	}
+	return coefs
+}
+
+// LassoPath is EnetPath with l1Ratio 1
+func LassoPath(X, Y *mat.Dense, alphas []float64) []*mat.Dense {
+	return EnetPath(X, Y, 1, alphas)
+}
+
+// ElasticNetCV is an ElasticNet whose Alpha and L1Ratio are chosen by k-fold cross-validation of the mean squared error
+// along the regularization path of each of L1Ratios. it's then fitted on all samples
+type ElasticNetCV struct {
+	ElasticNet
+	// L1Ratios are the candidate L1Ratio values, [.5] if empty
+	L1Ratios []float64
+	// Alphas are the candidate Alpha values. if empty, NAlphas (default 100) values from AlphaGrid with Eps (default 1e-3) are used
+	Alphas  []float64
+	NAlphas int
+	Eps     float64
+	// CV is the number of folds. defaults to 5
+	CV int
+	// CVAlphas[l] are the alphas tried for L1Ratios[l], MSEPath[l][a] the mean squared error across folds for CVAlphas[l][a]
+	CVAlphas, MSEPath [][]float64
+}
+
+// NewElasticNetCV returns an *ElasticNetCV with 5 folds
+func NewElasticNetCV() *ElasticNetCV {
+	return &ElasticNetCV{ElasticNet: *NewElasticNet(), L1Ratios: []float64{.5}, NAlphas: 100, Eps: 1e-3, CV: 5}
+}
+
+// NewLassoCV returns an *ElasticNetCV with L1Ratios [1]
+func NewLassoCV() *ElasticNetCV {
+	regr := NewElasticNetCV()
+	regr.L1Ratios = []float64{1}
+	return regr
+}
+
+// Fit computes MSEPath, sets Alpha and L1Ratio to the best ones and fits the ElasticNet
+func (regr *ElasticNetCV) Fit(X, Y *mat.Dense) base.Transformer {
+	nSamples, _ := X.Dims()
+	_, nOutputs := Y.Dims()
+	nFolds := regr.CV
+	if nFolds <= 1 {
+		nFolds = 5
+	}
+	l1Ratios := regr.L1Ratios
+	if len(l1Ratios) == 0 {
+		l1Ratios = []float64{.5}
+	}
+	nAlphas, eps := regr.NAlphas, regr.Eps
+	if nAlphas <= 0 {
+		nAlphas = 100
+	}
+	if eps <= 0 {
+		eps = 1e-3
+	}
+	Xc, Yc := mat.DenseCopyOf(X), mat.DenseCopyOf(Y)
+	preprocessing.DenseNormalize(Xc, regr.FitIntercept, regr.Normalize)
+	preprocessing.DenseNormalize(Yc, regr.FitIntercept, false)
+	regr.CVAlphas, regr.MSEPath = make([][]float64, len(l1Ratios)), make([][]float64, len(l1Ratios))
+	for l, l1Ratio := range l1Ratios {
+		regr.CVAlphas[l] = regr.Alphas
+		if len(regr.Alphas) == 0 {
+			regr.CVAlphas[l] = AlphaGrid(Xc, Yc, l1Ratio, eps, nAlphas)
+		}
+		regr.MSEPath[l] = make([]float64, len(regr.CVAlphas[l]))
+	}
+	for fold := 0; fold < nFolds; fold++ {
+		testStart, testEnd := fold*nSamples/nFolds, (fold+1)*nSamples/nFolds
+		train, test := make([]int, 0, nSamples), make([]int, 0, testEnd-testStart)
+		for i := 0; i < nSamples; i++ {
+			if i >= testStart && i < testEnd {
+				test = append(test, i)
+			} else {
+				train = append(train, i)
+			}
+		}
+		Xtrain, Ytrain, Xtest, Ytest := denseRows(X, train), denseRows(Y, train), denseRows(X, test), denseRows(Y, test)
+		XOffset, XScale := preprocessing.DenseNormalize(Xtrain, regr.FitIntercept, regr.Normalize)
+		YOffset, _ := preprocessing.DenseNormalize(Ytrain, regr.FitIntercept, false)
+		Xtest.Apply(func(_, j int, x float64) float64 { return (x - XOffset.At(0, j)) / XScale.At(0, j) }, Xtest)
+		Ypred := mat.NewDense(len(test), nOutputs, nil)
+		for l, l1Ratio := range l1Ratios {
+			for a, coef := range EnetPath(Xtrain, Ytrain, l1Ratio, regr.CVAlphas[l]) {
+				Ypred.Mul(Xtest, coef)
+				mse := 0.
+				Ypred.Apply(func(i, o int, ypred float64) float64 {
+					d := ypred + YOffset.At(0, o) - Ytest.At(i, o)
+					mse += d * d
+					return ypred
+				}, Ypred)
+				regr.MSEPath[l][a] += mse / float64(len(test)*nOutputs) / float64(nFolds)
+			}
+		}
+	}
+	bestMSE := math.Inf(1)
+	for l := range l1Ratios {
+		for a, mse := range regr.MSEPath[l] {
+			if mse < bestMSE {
+				bestMSE, regr.L1Ratio, regr.Alpha = mse, l1Ratios[l], regr.CVAlphas[l][a]
+			}
+		}
+	}
+	regr.ElasticNet.Fit(X, Y)
+	return regr
+}
+
+// FitTransform is for Pipeline
+func (regr *ElasticNetCV) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	r, c := Y.Dims()
+	Xout, Yout = X, mat.NewDense(r, c, nil)
+	regr.Fit(X, Y)
+	regr.Predict(X, Yout)
+	return
+}
+
+// RidgeCV is a ridge regression minimizing ||y-X.w||^2 + Alpha*||w||^2 (scikit-learn Ridge objective), with Alpha chosen among
+// Alphas by leave-one-out cross-validation computed from a singular value decomposition of X
+type RidgeCV struct {
+	LinearModel
+	// Alphas are the candidate Alpha values, [.1 1 10] if empty
+	Alphas []float64
+	Alpha  float64
+	// CVValues[a] is the leave-one-out mean squared error for Alphas[a]
+	CVValues []float64
+}
+
+// NewRidgeCV returns a *RidgeCV with Alphas .1,1,10
+func NewRidgeCV() *RidgeCV {
+	regr := &RidgeCV{Alphas: []float64{.1, 1, 10}}
+	regr.FitIntercept = true
+	return regr
+}
+
+// Fit computes CVValues, sets Alpha to the best one and fits Coef and Intercept.
+// with X=U.S.Vt, the ridge predictions are H.y with H=U.diag(s^2/(s^2+alpha)).Ut (plus 1/nSamples for the intercept),
+// and the leave-one-out residual for sample i is (y-H.y)[i]/(1-H[i,i])
+func (regr *RidgeCV) Fit(X0, Y0 *mat.Dense) base.Transformer {
+	X, Y := mat.DenseCopyOf(X0), mat.DenseCopyOf(Y0)
+	XOffset, XScale := preprocessing.DenseNormalize(X, regr.FitIntercept, regr.Normalize)
+	YOffset, _ := preprocessing.DenseNormalize(Y, regr.FitIntercept, false)
+	nSamples, nFeatures := X.Dims()
+	_, nOutputs := Y.Dims()
+	alphas := regr.Alphas
+	if len(alphas) == 0 {
+		alphas = []float64{.1, 1, 10}
+	}
+	var svd mat.SVD
+	if !svd.Factorize(X, mat.SVDThin) {
+		panic("svd failed")
+	}
+	U, s, V := svd.UTo(nil), svd.Values(nil), svd.VTo(nil)
+	UTY := mat.NewDense(len(s), nOutputs, nil)
+	UTY.Mul(U.T(), Y)
+	// shrunk scales UTY rows by the filter factors s^2/(s^2+alpha) for the predictions or s/(s^2+alpha) for the coefficients
+	shrunk := func(alpha float64, factor func(s float64) float64) *mat.Dense {
+		M := mat.DenseCopyOf(UTY)
+		M.Apply(func(k, _ int, v float64) float64 { return v * factor(s[k]) / (s[k]*s[k] + alpha) }, M)
+		return M
+	}
+	hatBias := 0.
+	if regr.FitIntercept {
+		hatBias = 1. / float64(nSamples)
+	}
+	regr.CVValues = make([]float64, len(alphas))
+	best := 0
+	Ypred := mat.NewDense(nSamples, nOutputs, nil)
+	for a, alpha := range alphas {
+		Ypred.Mul(U, shrunk(alpha, func(s float64) float64 { return s * s }))
+		mse := 0.
+		for i := 0; i < nSamples; i++ {
+			h := hatBias
+			for k, sk := range s {
+				u := U.At(i, k)
+				h += u * u * sk * sk / (sk*sk + alpha)
+			}
+			for o := 0; o < nOutputs; o++ {
+				e := (Y.At(i, o) - Ypred.At(i, o)) / (1 - h)
+				mse += e * e
+			}
+		}
+		regr.CVValues[a] = mse / float64(nSamples*nOutputs)
+		if regr.CVValues[a] < regr.CVValues[best] {
+			best = a
+		}
+	}
+	regr.Alpha = alphas[best]
+	regr.Coef = mat.NewDense(nFeatures, nOutputs, nil)
+	regr.Coef.Mul(V, shrunk(regr.Alpha, func(s float64) float64 { return s }))
+	regr.setIntercept(XOffset, YOffset, XScale)
+	return regr
+}
+
+// Predict predicts y for X using Coef
+func (regr *RidgeCV) Predict(X, Y *mat.Dense) base.Regressor {
+	regr.DecisionFunction(X, Y)
+	return regr
+}
+
+// FitTransform is for Pipeline
+func (regr *RidgeCV) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	r, c := Y.Dims()
+	Xout, Yout = X, mat.NewDense(r, c, nil)
+	regr.Fit(X, Y)
+	regr.Predict(X, Yout)
+	return
+}
+
+// Transform is for Pipeline
+func (regr *RidgeCV) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	r, c := Y.Dims()
+	Xout, Yout = X, mat.NewDense(r, c, nil)
+	regr.Predict(X, Yout)
+	return
+}
+
+// denseRows returns a new *mat.Dense with rows of X
+func denseRows(X *mat.Dense, rows []int) *mat.Dense {
+	_, nCols := X.Dims()
+	Xout := mat.NewDense(len(rows), nCols, nil)
+	for i, src := range rows {
+		Xout.SetRow(i, X.RawRowView(src))
+	}
+	return Xout
+}
@@ -0,0 +1,171 @@
+package linearModel
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gcla/sklearn/preprocessing"
+	"gonum.org/v1/gonum/mat"
+)
+
+// sparseProblem returns X and Y=1+X.coef+noise where only 3 of 10 coefficients are not zero
+func sparseProblem(rnd *rand.Rand, nSamples int) (X, Y *mat.Dense, coef []float64) {
+	coef = []float64{3, 0, 0, -2, 0, 0, 0, 1.5, 0, 0}
+	X, Y = mat.NewDense(nSamples, len(coef), nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 {
+		y := 1 + .1*rnd.NormFloat64()
+		for j, c := range coef {
+			y += c * X.At(i, j)
+		}
+		return y
+	}, Y)
+	return
+}
+
+func TestElasticNet(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	X, Y, coef := sparseProblem(rnd, 200)
+	nSamples, nFeatures := X.Dims()
+	for _, l1Ratio := range []float64{1, .5} {
+		regr := NewElasticNet()
+		regr.Alpha, regr.L1Ratio, regr.Tol = .1, l1Ratio, 1e-8
+		regr.Fit(X, Y)
+		// optimality conditions: X_j.R/nSamples - alpha*(1-l1Ratio)*w_j is alpha*l1Ratio*sign(w_j) if w_j!=0, else in [-alpha*l1Ratio,alpha*l1Ratio]
+		R := mat.NewDense(nSamples, 1, nil)
+		regr.Predict(X, R)
+		R.Sub(Y, R)
+		for j := 0; j < nFeatures; j++ {
+			w := regr.Coef.At(j, 0)
+			g := mat.Dot(X.ColView(j), R.ColView(0))/float64(nSamples) - regr.Alpha*(1-l1Ratio)*w
+			if w != 0 && math.Abs(g-regr.Alpha*l1Ratio*math.Copysign(1, w)) > 1e-6 || w == 0 && math.Abs(g) > regr.Alpha*l1Ratio+1e-6 {
+				t.Errorf("l1Ratio=%g feature %d: coef %g violates optimality, gradient %g", l1Ratio, j, w, g)
+			}
+			if (coef[j] == 0) != (w == 0) {
+				t.Errorf("l1Ratio=%g feature %d: expected coef %g got %g", l1Ratio, j, coef[j], w)
+			}
+		}
+		if regr.DualGap > regr.Tol*mat.Dot(Y.ColView(0), Y.ColView(0)) {
+			t.Errorf("l1Ratio=%g: not converged, dual gap %g", l1Ratio, regr.DualGap)
+		}
+		nIter := regr.NIter
+		regr.WarmStart = true
+		regr.Fit(X, Y)
+		if regr.NIter >= nIter {
+			t.Errorf("l1Ratio=%g: expected warm start to take less than %d iterations, took %d", l1Ratio, nIter, regr.NIter)
+		}
+	}
+}
+
+func TestLassoPath(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	X, Y, _ := sparseProblem(rnd, 100)
+	preprocessing.DenseNormalize(X, true, false)
+	preprocessing.DenseNormalize(Y, true, false)
+	alphas := AlphaGrid(X, Y, 1, 1e-3, 20)
+	coefs := LassoPath(X, Y, alphas)
+	if len(coefs) != 20 || alphas[0] <= alphas[19] || math.Abs(alphas[19]/alphas[0]-1e-3) > 1e-12 {
+		t.Fatalf("unexpected alpha grid %v", alphas)
+	}
+	// at alpha max, the soft threshold cancels the gradient up to rounding
+	if mat.Max(coefs[0]) > 1e-12 || mat.Min(coefs[0]) < -1e-12 {
+		t.Errorf("expected zero coefs for alpha max, got %v", coefs[0].RawMatrix().Data)
+	}
+	slightlyBelow := LassoPath(X, Y, []float64{alphas[0] * .99})[0]
+	if mat.Max(slightlyBelow) == 0 && mat.Min(slightlyBelow) == 0 {
+		t.Error("expected a non zero coef below alpha max")
+	}
+	// the path matches independent fits
+	regr := NewLassoCD()
+	regr.FitIntercept = false
+	regr.Alpha = alphas[10]
+	regr.Fit(X, Y)
+	if !mat.EqualApprox(regr.Coef, coefs[10], 1e-3) {
+		t.Errorf("path coefs %v differ from fit %v", coefs[10].RawMatrix().Data, regr.Coef.RawMatrix().Data)
+	}
+}
+
+func TestElasticNetCV(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	X, Y, coef := sparseProblem(rnd, 200)
+	for _, regr := range []*ElasticNetCV{NewLassoCV(), func() *ElasticNetCV {
+		regr := NewElasticNetCV()
+		regr.L1Ratios = []float64{.1, .5, .9, 1}
+		regr.NAlphas = 30
+		return regr
+	}()} {
+		regr.Fit(X, Y)
+		best := regr.MSEPath[0][0]
+		for l := range regr.MSEPath {
+			for _, mse := range regr.MSEPath[l] {
+				best = math.Min(best, mse)
+			}
+		}
+		if best > .02 {
+			t.Errorf("expected best cross-validated mse near noise variance .01, got %g", best)
+		}
+		if regr.Alpha >= regr.CVAlphas[0][0]/10 {
+			t.Errorf("expected a small alpha, got %g", regr.Alpha)
+		}
+		for j, c := range coef {
+			if math.Abs(regr.Coef.At(j, 0)-c) > .05 {
+				t.Errorf("alpha=%g l1Ratio=%g feature %d: expected coef %g got %g", regr.Alpha, regr.L1Ratio, j, c, regr.Coef.At(j, 0))
+			}
+		}
+		if math.Abs(regr.Intercept.At(0, 0)-1) > .05 {
+			t.Errorf("expected intercept 1 got %g", regr.Intercept.At(0, 0))
+		}
+	}
+}
+
+func TestRidgeCV(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures, nOutputs := 30, 4, 2
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, nOutputs, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	Y.Apply(func(i, o int, _ float64) float64 { return float64(o+1)*X.At(i, 0) - X.At(i, 1) + 2 + rnd.NormFloat64() }, Y)
+	regr := NewRidgeCV()
+	regr.Alphas = []float64{.01, 1, 10, 100}
+	regr.Fit(X, Y)
+	// brute force leave-one-out with a single alpha
+	for a, alpha := range regr.Alphas {
+		mse := 0.
+		for i := 0; i < nSamples; i++ {
+			train := make([]int, 0, nSamples-1)
+			for k := 0; k < nSamples; k++ {
+				if k != i {
+					train = append(train, k)
+				}
+			}
+			loo := NewRidgeCV()
+			loo.Alphas = []float64{alpha}
+			loo.Fit(denseRows(X, train), denseRows(Y, train))
+			Ypred := mat.NewDense(1, nOutputs, nil)
+			loo.Predict(denseRows(X, []int{i}), Ypred)
+			for o := 0; o < nOutputs; o++ {
+				e := Ypred.At(0, o) - Y.At(i, o)
+				mse += e * e
+			}
+		}
+		mse /= float64(nSamples * nOutputs)
+		if math.Abs(mse-regr.CVValues[a]) > 1e-9 {
+			t.Errorf("alpha=%g: expected leave-one-out mse %g got %g", alpha, mse, regr.CVValues[a])
+		}
+	}
+	// coefs solve (Xt.X+alpha*I).coef=Xt.y on centered data
+	Xc, Yc := mat.DenseCopyOf(X), mat.DenseCopyOf(Y)
+	preprocessing.DenseNormalize(Xc, true, false)
+	preprocessing.DenseNormalize(Yc, true, false)
+	A, B := mat.NewDense(nFeatures, nFeatures, nil), mat.NewDense(nFeatures, nOutputs, nil)
+	A.Mul(Xc.T(), Xc)
+	for j := 0; j < nFeatures; j++ {
+		A.Set(j, j, A.At(j, j)+regr.Alpha)
+	}
+	B.Mul(Xc.T(), Yc)
+	var expected mat.Dense
+	expected.Solve(A, B)
+	if !mat.EqualApprox(&expected, regr.Coef, 1e-9) {
+		t.Errorf("expected coef %v got %v", expected.RawMatrix().Data, regr.Coef.RawMatrix().Data)
+	}
+}
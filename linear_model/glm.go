@@ -0,0 +1,158 @@
+package linearModel
+
+import (
+	"context"
+
+	"github.com/gcla/sklearn/base"
+	"github.com/gcla/sklearn/metrics"
+	"gonum.org/v1/gonum/mat"
+)
+
+// GeneralizedLinearRegressor fits Coef and Intercept with LinFit to minimize LossFunction, ActivationFunction being the inverse
+// of the link function, plus Alpha,L1Ratio regularization of Coef.
+// unlike LinearRegression, Y is not centered: the intercept is fitted as the coefficient of a column of ones prepended to X if
+// FitIntercept, so it's right for losses whose optimal intercept is not the mean of Y. Normalize is ignored.
+// Predict applies ActivationFunction to X dot Coef+Intercept
+type GeneralizedLinearRegressor struct {
+	LinearModel
+	// Optimizer is the base.Optimizer solver, nil for LBFGS
+	Optimizer           base.Optimizer
+	Tol, Alpha, L1Ratio float64
+	LossFunction        Loss
+	ActivationFunction  Activation
+	Options             LinFitOptions
+	// LossCurve and ValidationScores are copied from LinFitResult by Fit
+	LossCurve, ValidationScores []float64
+}
+
+// NewGeneralizedLinearRegressor returns a *GeneralizedLinearRegressor for lossFunction and activation, fitted with LBFGS
+func NewGeneralizedLinearRegressor(lossFunction Loss, activation Activation) *GeneralizedLinearRegressor {
+	regr := &GeneralizedLinearRegressor{Tol: 1e-6, LossFunction: lossFunction, ActivationFunction: activation}
+	regr.FitIntercept = true
+	return regr
+}
+
+// NewHuberRegressor returns a *GeneralizedLinearRegressor with Huber loss, robust to outliers beyond epsilon (1.35 in scikit-learn)
+func NewHuberRegressor(epsilon float64) *GeneralizedLinearRegressor {
+	return NewGeneralizedLinearRegressor(NewHuberLoss(epsilon), base.Identity{})
+}
+
+// NewQuantileRegressor returns a *GeneralizedLinearRegressor predicting the tau quantile of Y.
+// the pinball loss being non smooth, it's fitted with Adam
+func NewQuantileRegressor(tau float64) *GeneralizedLinearRegressor {
+	regr := NewGeneralizedLinearRegressor(NewQuantileLoss(tau), base.Identity{})
+	adam := base.NewAdamOptimizer()
+	adam.StepSize = .01
+	regr.Optimizer = adam
+	return regr
+}
+
+// NewPoissonRegressor returns a *GeneralizedLinearRegressor with Poisson deviance and log link, for counts
+func NewPoissonRegressor() *GeneralizedLinearRegressor {
+	return NewGeneralizedLinearRegressor(PoissonLoss, base.Exp{})
+}
+
+// NewGammaRegressor returns a *GeneralizedLinearRegressor with Gamma deviance and log link, for positive Y
+func NewGammaRegressor() *GeneralizedLinearRegressor {
+	return NewGeneralizedLinearRegressor(GammaLoss, base.Exp{})
+}
+
+// NewTweedieRegressor returns a *GeneralizedLinearRegressor with Tweedie deviance of the given power (see NewTweedieLoss)
+// and log link
+func NewTweedieRegressor(power float64) *GeneralizedLinearRegressor {
+	return NewGeneralizedLinearRegressor(NewTweedieLoss(power), base.Exp{})
+}
+
+// Fit fits Coef and Intercept
+func (regr *GeneralizedLinearRegressor) Fit(X, Y *mat.Dense) base.Transformer {
+	regr.FitContext(context.Background(), X, Y)
+	return regr
+}
+
+// FitContext is Fit stopping at the next epoch once ctx is done. it then keeps the best Coef so far and returns ctx.Err()
+func (regr *GeneralizedLinearRegressor) FitContext(ctx context.Context, X0, Y0 *mat.Dense) (base.Transformer, error) {
+	X := mat.DenseCopyOf(X0)
+	if regr.FitIntercept {
+		nSamples, nFeatures := X0.Dims()
+		X = mat.NewDense(nSamples, 1+nFeatures, nil)
+		X.Copy(base.MatOnesPrepended{Matrix: X0})
+	}
+	res, err := LinFitContext(ctx, X, mat.DenseCopyOf(Y0), regr.linFitOptions())
+	regr.LossCurve, regr.ValidationScores = res.LossCurve, res.ValidationScores
+	regr.setSparseCoef(res.Theta)
+	return regr, err
+}
+
+// FitSparse fits Coef and Intercept from a sparse X
+func (regr *GeneralizedLinearRegressor) FitSparse(X *base.CSR, Y *mat.Dense) base.Transformer {
+	regr.FitSparseContext(context.Background(), X, Y)
+	return regr
+}
+
+// FitSparseContext is FitSparse stopping at the next epoch once ctx is done. see FitContext
+func (regr *GeneralizedLinearRegressor) FitSparseContext(ctx context.Context, X0 *base.CSR, Y0 *mat.Dense) (base.Transformer, error) {
+	X := X0.Copy()
+	if regr.FitIntercept {
+		X = X0.OnesPrepended()
+	}
+	res, err := LinFitContext(ctx, X, mat.DenseCopyOf(Y0), regr.linFitOptions())
+	regr.LossCurve, regr.ValidationScores = res.LossCurve, res.ValidationScores
+	regr.setSparseCoef(res.Theta)
+	return regr, err
+}
+
+func (regr *GeneralizedLinearRegressor) linFitOptions() *LinFitOptions {
+	opt := regr.Options
+	opt.Tol = regr.Tol
+	opt.Solver = regr.Optimizer
+	opt.Alpha, opt.L1Ratio = regr.Alpha, regr.L1Ratio
+	opt.Loss = regr.LossFunction
+	if regr.FitIntercept {
+		opt.Loss = interceptExcluded(regr.LossFunction)
+	}
+	opt.Activation = regr.ActivationFunction
+	if opt.ThetaInitializer == nil {
+		// exp link functions overflow with large initial weights
+		opt.ThetaInitializer = func(Theta *mat.Dense) { Theta.Scale(0, Theta) }
+	}
+	return &opt
+}
+
+// Predict puts ActivationFunction(X dot Coef+Intercept) in Y. X can be a *mat.Dense or a *base.CSR
+func (regr *GeneralizedLinearRegressor) Predict(X, Y *mat.Dense) base.Regressor {
+	regr.predict(X, Y)
+	return regr
+}
+
+func (regr *GeneralizedLinearRegressor) predict(X mat.Matrix, Y *mat.Dense) {
+	regr.DecisionFunction(X, Y)
+	Y.Apply(func(_, _ int, v float64) float64 { return regr.ActivationFunction.F(v) }, Y)
+}
+
+// Score returns the R2Score of Predict, or the Scorer score if set
+func (regr *GeneralizedLinearRegressor) Score(X, Y *mat.Dense) float64 {
+	if regr.Scorer != nil {
+		return regr.Scorer.Score(regr, X, Y)
+	}
+	nSamples, nOutputs := Y.Dims()
+	Ypred := mat.NewDense(nSamples, nOutputs, nil)
+	regr.predict(X, Ypred)
+	return metrics.R2Score(Y, Ypred, nil, "").At(0, 0)
+}
+
+// FitTransform is for Pipeline
+func (regr *GeneralizedLinearRegressor) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	r, c := Y.Dims()
+	Xout, Yout = X, mat.NewDense(r, c, nil)
+	regr.Fit(X, Y)
+	regr.Predict(X, Yout)
+	return
+}
+
+// Transform is for Pipeline
+func (regr *GeneralizedLinearRegressor) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	r, c := Y.Dims()
+	Xout, Yout = X, mat.NewDense(r, c, nil)
+	regr.Predict(X, Yout)
+	return
+}
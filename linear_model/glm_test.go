@@ -0,0 +1,171 @@
+package linearModel
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestRobustLossGradients(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 50, 3
+	X, Theta := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nFeatures, 1, []float64{.2, -.3, .1})
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	XTheta := mat.NewDense(nSamples, 1, nil)
+	XTheta.Mul(X, Theta)
+	// residuals in [.5,1] keep the check away from kinks
+	Y := mat.NewDense(nSamples, 1, nil)
+	Y.Apply(func(i, _ int, xtheta float64) float64 {
+		r := .5 + .5*rnd.Float64()
+		if i%2 == 0 {
+			r = -r
+		}
+		return xtheta + r
+	}, XTheta)
+	// positive Y for deviances, around exp(XTheta)
+	Ypos := mat.NewDense(nSamples, 1, nil)
+	Ypos.Apply(func(_, _ int, xtheta float64) float64 { return math.Exp(xtheta) * (.5 + rnd.Float64()) }, XTheta)
+	for _, tc := range []struct {
+		name       string
+		loss       Loss
+		activation Activation
+		Y          *mat.Dense
+	}{
+		{"huber", NewHuberLoss(.7), base.Identity{}, Y},
+		{"huber gelu", NewHuberLoss(.7), base.GELU{}, Y},
+		{"epsilon-insensitive", NewEpsilonInsensitiveLoss(.1), base.Identity{}, Y},
+		{"quantile", NewQuantileLoss(.8), base.Identity{}, Y},
+		{"poisson", PoissonLoss, base.Exp{}, Ypos},
+		{"gamma", GammaLoss, base.Exp{}, Ypos},
+		{"tweedie", NewTweedieLoss(1.3), base.Exp{}, Ypos},
+		{"tweedie 3", NewTweedieLoss(3), base.Exp{}, Ypos},
+		{"tweedie 0", NewTweedieLoss(0), base.Identity{}, Y},
+	} {
+		for _, L1Ratio := range []float64{0, .5} {
+			gc := CheckLossGradient(tc.loss, tc.Y, X, Theta, .1, L1Ratio, tc.activation, 0)
+			if gc.MaxRelErr > 1e-4 {
+				t.Errorf("%s L1Ratio=%g parameter %d grad:%g fd:%g", tc.name, L1Ratio, gc.ArgMax, gc.Analytic[gc.ArgMax], gc.Numeric[gc.ArgMax])
+			}
+		}
+	}
+	// tweedie 0 is the square loss, without the halving of L1
+	J0 := NewTweedieLoss(0)(Y, X, Theta, mat.NewDense(nSamples, 1, nil), mat.NewDense(nSamples, 1, nil), nil, 0, 0, nSamples, base.Identity{})
+	JSquare := SquareLoss(Y, X, Theta, mat.NewDense(nSamples, 1, nil), mat.NewDense(nSamples, 1, nil), nil, 0, 0, nSamples, base.Identity{})
+	if math.Abs(J0-JSquare) > 1e-12 {
+		t.Errorf("expected tweedie 0 loss %g to be square loss %g", J0, JSquare)
+	}
+}
+
+func TestHuberRegressor(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples := 200
+	X, Y := mat.NewDense(nSamples, 2, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 {
+		y := 1 + 2*X.At(i, 0) - X.At(i, 1) + .1*rnd.NormFloat64()
+		if i%10 == 0 {
+			// outliers
+			y += 50
+		}
+		return y
+	}, Y)
+	regr := NewHuberRegressor(1.35)
+	regr.Fit(X, Y)
+	if !floats.EqualApprox(regr.Coef.RawMatrix().Data, []float64{2, -1}, .1) || math.Abs(regr.Intercept.At(0, 0)-1) > .2 {
+		t.Errorf("expected coef [2 -1] intercept 1, got %v %v", regr.Coef.RawMatrix().Data, regr.Intercept.RawMatrix().Data)
+	}
+}
+
+func TestGeneralizedLinearRegressorIntercept(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples := 200
+	X, Y := mat.NewDense(nSamples, 2, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return 5 + 2*X.At(i, 0) - X.At(i, 1) + .1*rnd.NormFloat64() }, Y)
+	// strong regularization shrinks every coefficient, but not the intercept
+	for _, fitIntercept := range []bool{true, false} {
+		regr := NewHuberRegressor(1.35)
+		regr.Alpha, regr.FitIntercept = 1e4, fitIntercept
+		regr.Fit(X, Y)
+		if mat.Max(regr.Coef) > .05 || mat.Min(regr.Coef) < -.05 {
+			t.Errorf("FitIntercept=%v: expected coefs near 0, got %v", fitIntercept, regr.Coef.RawMatrix().Data)
+		}
+		if intercept := regr.Intercept.At(0, 0); fitIntercept && math.Abs(intercept-5) > .2 || !fitIntercept && intercept != 0 {
+			t.Errorf("FitIntercept=%v: unexpected intercept %g", fitIntercept, intercept)
+		}
+	}
+}
+
+func TestQuantileRegressor(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples := 500
+	X, Y := mat.NewDense(nSamples, 1, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return 2*X.At(i, 0) + rnd.Float64() }, Y)
+	for _, tau := range []float64{.1, .5, .9} {
+		regr := NewQuantileRegressor(tau)
+		regr.Options.Epochs = 300
+		regr.Fit(X, Y)
+		Ypred := mat.NewDense(nSamples, 1, nil)
+		regr.Predict(X, Ypred)
+		below := 0.
+		for i := 0; i < nSamples; i++ {
+			if Y.At(i, 0) < Ypred.At(i, 0) {
+				below++
+			}
+		}
+		if frac := below / float64(nSamples); math.Abs(frac-tau) > .05 {
+			t.Errorf("tau=%g: %g of Y below predictions", tau, frac)
+		}
+		// the tau quantile of Y-2X, uniform in [0,1], is tau
+		if math.Abs(regr.Coef.At(0, 0)-2) > .15 || math.Abs(regr.Intercept.At(0, 0)-tau) > .1 {
+			t.Errorf("tau=%g: expected coef 2 intercept %g, got %g %g", tau, tau, regr.Coef.At(0, 0), regr.Intercept.At(0, 0))
+		}
+	}
+}
+
+func TestPoissonGammaTweedieRegressors(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples := 1000
+	X := mat.NewDense(nSamples, 2, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	mu := func(i int) float64 { return math.Exp(.5 + .3*X.At(i, 0) - .2*X.At(i, 1)) }
+	Ycount, Ypos := mat.NewDense(nSamples, 1, nil), mat.NewDense(nSamples, 1, nil)
+	for i := 0; i < nSamples; i++ {
+		// Knuth's Poisson sampling
+		k, p := 0., rnd.Float64()
+		for l := math.Exp(-mu(i)); p > l; p *= rnd.Float64() {
+			k++
+		}
+		Ycount.Set(i, 0, k)
+		// gamma with mean mu(i) and shape 5: a sum of 5 exponentials
+		g := 0.
+		for s := 0; s < 5; s++ {
+			g += rnd.ExpFloat64()
+		}
+		Ypos.Set(i, 0, g*mu(i)/5)
+	}
+	for _, tc := range []struct {
+		name string
+		regr *GeneralizedLinearRegressor
+		Y    *mat.Dense
+	}{
+		{"poisson", NewPoissonRegressor(), Ycount},
+		{"tweedie", NewTweedieRegressor(1.5), Ycount},
+		{"gamma", NewGammaRegressor(), Ypos},
+	} {
+		tc.regr.Fit(X, tc.Y)
+		if !floats.EqualApprox(tc.regr.Coef.RawMatrix().Data, []float64{.3, -.2}, .06) || math.Abs(tc.regr.Intercept.At(0, 0)-.5) > .06 {
+			t.Errorf("%s: expected coef [.3 -.2] intercept .5, got %v %v", tc.name, tc.regr.Coef.RawMatrix().Data, tc.regr.Intercept.RawMatrix().Data)
+		}
+		Ypred := mat.NewDense(nSamples, 1, nil)
+		tc.regr.Predict(X, Ypred)
+		if mat.Min(Ypred) <= 0 {
+			t.Errorf("%s: expected positive predictions", tc.name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package linearModel
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
@@ -30,6 +31,12 @@ func NewLinearRegressionGorgonia() *LinearRegressionGorgonia {
 
 // Fit lears coef and intercept for a *LinearRegressionGorgonia
 func (regr *LinearRegressionGorgonia) Fit(X0, y0 *mat.Dense) base.Transformer {
+	regr.FitContext(context.Background(), X0, y0)
+	return regr
+}
+
+// FitContext is Fit stopping at the next epoch once ctx is done. it then keeps the weights with the lowest cost so far and returns ctx.Err()
+func (regr *LinearRegressionGorgonia) FitContext(ctx context.Context, X0, y0 *mat.Dense) (base.Transformer, error) {
 	Float := gg.Float64
 
 	g := gg.NewGraph()
@@ -86,10 +93,20 @@ func (regr *LinearRegressionGorgonia) Fit(X0, y0 *mat.Dense) base.Transformer {
 	if regr.Epochs <= 0 {
 		regr.Epochs = 1e6 / nSamples
 	}
+	var bestW []float
+	bestCost := math.Inf(1)
 	for i := 0; i < regr.Epochs; i++ {
+		if ctx.Err() != nil {
+			break
+		}
 		if err = machine.RunAll(); err != nil {
 			break
 		}
+		// cost is computed with w before the step
+		if c := cost.Value().Data().(float); c < bestCost {
+			bestCost = c
+			bestW = append(bestW[:0], w.Value().Data().([]float)...)
+		}
 		if err = solver.Step(model); err != nil {
 			log.Fatal(err)
 		}
@@ -102,6 +119,9 @@ func (regr *LinearRegressionGorgonia) Fit(X0, y0 *mat.Dense) base.Transformer {
 
 	}
 	wmat := mat.NewDense(xT.Shape()[1], Yshape[1], w.Value().Data().([]float))
+	if ctx.Err() != nil && bestW != nil {
+		wmat = mat.NewDense(xT.Shape()[1], Yshape[1], bestW)
+	}
 	regr.Coef = mat.NewDense(Xshape[1], Yshape[1], nil)
 	regr.Intercept = mat.NewDense(1, Yshape[1], nil)
 	ifeat0 := 0
@@ -110,7 +130,7 @@ func (regr *LinearRegressionGorgonia) Fit(X0, y0 *mat.Dense) base.Transformer {
 		regr.Intercept.Clone(wmat.RowView(0).T())
 	}
 	regr.Coef.Apply(func(j, o int, c float64) float64 { return wmat.At(j+ifeat0, o) }, regr.Coef)
-	return regr
+	return regr, ctx.Err()
 
 }
 
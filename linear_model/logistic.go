@@ -27,8 +27,8 @@ func NewLogisticRegression() *LogisticRegression {
 	return regr
 }
 
-// PredictProba predicts probabolity of y=1 for X using Coef
-func (regr *LogisticRegression) PredictProba(X, Y *mat.Dense) {
+// PredictProba predicts probabolity of y=1 for X using Coef. X can be a *mat.Dense or a *base.CSR
+func (regr *LogisticRegression) PredictProba(X mat.Matrix, Y *mat.Dense) {
 	regr.DecisionFunction(X, Y)
 	Y.Apply(func(i int, o int, y float64) float64 {
 		return (base.Sigmoid{}).F(y)
@@ -50,3 +50,11 @@ func (regr *LogisticRegression) Predict(X, Y *mat.Dense) {
 		}
 	}
 }
+
+// Score returns LinearModel Score, or the Scorer score using LogisticRegression Predict or PredictProba if Scorer is set
+func (regr *LogisticRegression) Score(X, Y *mat.Dense) float64 {
+	if regr.Scorer != nil {
+		return regr.Scorer.Score(regr, X, Y)
+	}
+	return regr.LinearModel.Score(X, Y)
+}
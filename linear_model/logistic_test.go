@@ -315,6 +315,8 @@ func TestLogRegMicrochipTest(t *testing.T) {
 		case "adadelta":
 			s := base.NewAdadeltaOptimizer()
 			s.StepSize = 0.05
+			// the default gamma and epsilon oscillate around the regularized minimum
+			s.RMSPropGamma, s.Epsilon = .5, 1e-6
 			return s
 		case "adam":
 			s := base.NewAdamOptimizer()
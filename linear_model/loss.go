@@ -20,7 +20,25 @@ import (
 type Loss func(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha, L1Ratio float64, nSamples int, activation Activation) (J float64)
 
 // LossFunctions is the map of implemented loss functions
-var LossFunctions = map[string]Loss{"square": SquareLoss, "log": LogLoss, "cross-entropy": CrossEntropyLoss}
+var LossFunctions = map[string]Loss{"square": SquareLoss, "log": LogLoss, "cross-entropy": CrossEntropyLoss,
+	"huber": HuberLoss, "epsilon-insensitive": EpsilonInsensitiveLoss, "quantile": QuantileLoss,
+	"poisson": PoissonLoss, "gamma": GammaLoss, "tweedie": TweedieLoss}
+
+// CheckLossGradient compares the gradient computed by lossFunc at Theta with its central differences.
+// step is the finite differences step, 0 for the default one
+func CheckLossGradient(lossFunc Loss, Ytrue, X, Theta mat.Matrix, Alpha, L1Ratio float64, activation Activation, step float64) *base.GradientCheck {
+	nSamples, _ := X.Dims()
+	nFeatures, nOutputs := Theta.Dims()
+	Ypred, Ydiff, grad := mat.NewDense(nSamples, nOutputs, nil), mat.NewDense(nSamples, nOutputs, nil), mat.NewDense(nFeatures, nOutputs, nil)
+	J := func(theta []float64) float64 {
+		return lossFunc(Ytrue, X, mat.NewDense(nFeatures, nOutputs, theta), Ypred, Ydiff, grad, Alpha, L1Ratio, nSamples, activation)
+	}
+	dJ := func(dst, theta []float64) {
+		J(theta)
+		copy(dst, grad.RawMatrix().Data)
+	}
+	return base.CheckGradient(J, dJ, mat.DenseCopyOf(Theta).RawMatrix().Data, step)
+}
 
 // SquareLoss Quadratic Loss, for regressions
 // Ytrue, X, Theta must be passed in
@@ -30,7 +48,7 @@ var LossFunctions = map[string]Loss{"square": SquareLoss, "log": LogLoss, "cross
 // grad:  hprime*(h-y)
 //
 func SquareLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha, L1Ratio float64, nSamples int, activation Activation) (J float64) {
-	Ypred.Mul(X, Theta)
+	base.MatMul(Ypred, X, Theta)
 	Ypred.Apply(func(i, o int, xtheta float64) float64 { return activation.F(xtheta) }, Ypred)
 	Ydiff.Sub(Ypred, Ytrue)
 	J = 0.
@@ -41,37 +59,23 @@ func SquareLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha
 	// put into grad
 	if grad != nil {
 		if _, ok := activation.(base.Identity); ok {
-			grad.Mul(X.T(), Ydiff) //<- for identity only
+			base.MatMul(grad, X.T(), Ydiff) //<- for identity only
 
 		} else {
+			hprime := activationPrime(activation, X, Theta)
 			grad.Apply(func(j, o int, theta float64) float64 {
 				g := 0.
 				for i := 0; i < nSamples; i++ {
 					h := Ypred.At(i, o)
-					g += Ydiff.At(i, o) * X.At(i, j) * activation.Fprime(h)
+					g += Ydiff.At(i, o) * X.At(i, j) * hprime(i, o, h)
 				}
 				return g
 			}, Theta)
 		}
 	}
-	// add regularization to cost and grad
-	if Alpha > 0. {
-		L1, L2 := 0., 0.
-		if grad != nil {
-			grad.Apply(func(j, o int, g float64) float64 {
-				// here we count feature 0 (not ones) in regularization
-				if j >= 0 {
-					c := Theta.At(j, o)
-
-					L1 += math.Abs(c)
-					L2 += c * c
-					g += Alpha * (L1Ratio*sgn(c) + (1.-L1Ratio)*c)
-				}
-				return g
-			}, grad)
-		}
-		J += Alpha * (L1Ratio*L1 + (1.-L1Ratio)*L2)
-	}
+	// add regularization to cost and grad. here we count feature 0 (not ones) in regularization.
+	// J is halved below, so the penalty is doubled here to match its gradient
+	J += 2. * addPenalty(Theta, grad, Alpha, L1Ratio, 0, 1.)
 
 	J /= 2. * float64(nSamples)
 	if grad != nil {
@@ -82,7 +86,7 @@ func SquareLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha
 
 // LogLoss for one versus rest classifiers
 func LogLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha, L1Ratio float64, nSamples int, activation Activation) (J float64) {
-	Ypred.Mul(X, Theta)
+	base.MatMul(Ypred, X, Theta)
 	Ypred.Apply(func(i, o int, xtheta float64) float64 { return activation.F(xtheta) }, Ypred)
 	Ydiff.Sub(Ypred, Ytrue)
 	J = 0.
@@ -106,33 +110,19 @@ func LogLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha, L
 	}, Ypred)
 	//grad.Mul(X.T(), Ydiff)
 	if grad != nil {
+		hprime := activationPrime(activation, X, Theta)
 		grad.Apply(func(j, o int, theta float64) float64 {
 			g := 0.
 			for i := 0; i < nSamples; i++ {
 				h := Ypred.At(i, o)
-				g += -Ytrue.At(i, o) * activation.Fprime(h) / h
+				g += -Ytrue.At(i, o) * X.At(i, j) * hprime(i, o, h) / h
 			}
 			return g
 		}, Theta)
 	}
 
-	// add regularization to cost and grad
-	if Alpha > 0. {
-		L1, L2 := 0., 0.
-		if grad != nil {
-			grad.Apply(func(j, o int, g float64) float64 {
-				// we dont count feature 0 (ones) in regularization
-				if j > 0 {
-					c := Theta.At(j, o)
-					L1 += math.Abs(c)
-					L2 += c * c / 2.
-					g += Alpha * (L1Ratio*sgn(c) + (1.-L1Ratio)*c)
-				}
-				return g
-			}, grad)
-		}
-		J += Alpha * (L1Ratio*L1 + (1.-L1Ratio)*L2)
-	}
+	// add regularization to cost and grad. we dont count feature 0 (ones) in regularization
+	J += addPenalty(Theta, grad, Alpha, L1Ratio, 1, 1.)
 	J /= float64(nSamples)
 	if grad != nil {
 		grad.Scale(1./float64(nSamples), grad)
@@ -148,7 +138,7 @@ func LogLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha, L
 // grad:  hprime*(-y/h + (1-y)/(1-h))
 //
 func CrossEntropyLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha, L1Ratio float64, nSamples int, activation Activation) (J float64) {
-	Ypred.Mul(X, Theta)
+	base.MatMul(Ypred, X, Theta)
 	Ypred.Apply(func(i, o int, xtheta float64) float64 { return panicIfNaN(activation.F(xtheta)) }, Ypred)
 	Ydiff.Sub(Ypred, Ytrue)
 	J = 0.
@@ -173,21 +163,21 @@ func CrossEntropyLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense,
 	}, Ypred)
 	if grad != nil {
 		if _, ok := activation.(base.Logistic); ok {
-			grad.Mul(X.T(), Ydiff)
+			base.MatMul(grad, X.T(), Ydiff)
 		} else {
-			// // for Logistic activation only
+			activationPrime := activationPrime(activation, X, Theta)
 			grad.Apply(func(j, o int, theta float64) float64 {
 				g := 0.
 				for i := 0; i < nSamples; i++ {
 					h := Ypred.At(i, o)
 					y := Ytrue.At(i, o)
-					hprime := activation.Fprime(h)
+					hprime := activationPrime(i, o, h)
 					if y == 1. {
-						g += -y * hprime / h
+						g += -y * hprime / h * X.At(i, j)
 					} else if y == 0. {
-						g += (1. - y) * hprime / (1. - h)
+						g += (1. - y) * hprime / (1. - h) * X.At(i, j)
 					} else {
-						g += -y*hprime/h + (1.-y)*hprime/(1.-h)
+						g += (-y*hprime/h + (1.-y)*hprime/(1.-h)) * X.At(i, j)
 					}
 					if math.IsNaN(g) {
 						panic(fmt.Errorf("g is NaN h=%g y=%g ", h, y))
@@ -197,25 +187,8 @@ func CrossEntropyLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense,
 			}, Theta)
 		}
 	}
-	// add regularization to cost and grad
-	if Alpha > 0. {
-		L1, L2 := 0., 0.
-		if grad != nil {
-			grad.Apply(func(j, o int, g float64) float64 {
-				// we dont count feature 0 (ones) in regularization
-				if j > 0 || o > 0 {
-					c := Theta.At(j, o)
-					L1 += math.Abs(c)
-					L2 += c * c / 2.
-					g += Alpha * (L1Ratio*sgn(c) + (1.-L1Ratio)*c)
-				}
-				return g
-			}, grad)
-		}
-		//fmt.Printf("J=1/%d * { %g + %g * ( %g*%g +%g*%g) }\n", nSamples, J, Alpha, L1Ratio, L1, 1.-L1Ratio, L2)
-		J += Alpha * (L1Ratio*L1 + (1.-L1Ratio)*L2)
-		//fmt.Printf("=%g\n", J)
-	}
+	// add regularization to cost and grad. we dont count feature 0 (ones) in regularization
+	J += addPenalty(Theta, grad, Alpha, L1Ratio, 1, 1.)
 	J /= float64(nSamples)
 	//fmt.Printf("/%d =>%g\n", nSamples, J)
 	if grad != nil {
@@ -244,7 +217,179 @@ func CrossEntropyLoss(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense,
 //                 ⎝    1 + ℯ    ⎠
 //
 
-// TODO Hinge,HingeSmoothed,Huber, v https://en.wikipedia.org/wiki/Hinge_loss
+// TODO Hinge,HingeSmoothed, v https://en.wikipedia.org/wiki/Hinge_loss
+
+var (
+	// HuberLoss is NewHuberLoss(1.35)
+	HuberLoss = NewHuberLoss(1.35)
+	// EpsilonInsensitiveLoss is NewEpsilonInsensitiveLoss(.1)
+	EpsilonInsensitiveLoss = NewEpsilonInsensitiveLoss(.1)
+	// QuantileLoss is NewQuantileLoss(.5), the absolute error halved
+	QuantileLoss = NewQuantileLoss(.5)
+	// PoissonLoss is NewTweedieLoss(1), for counts
+	PoissonLoss = NewTweedieLoss(1)
+	// GammaLoss is NewTweedieLoss(2), for positive Y
+	GammaLoss = NewTweedieLoss(2)
+	// TweedieLoss is NewTweedieLoss(1.5), for positive Y with zeros
+	TweedieLoss = NewTweedieLoss(1.5)
+)
+
+// NewHuberLoss returns the Huber loss, quadratic for |h-y|<=epsilon and linear beyond. https://en.wikipedia.org/wiki/Huber_loss
+// J: (h-y)^2/2 if |h-y|<=epsilon else epsilon*|h-y|-epsilon^2/2
+// grad: hprime*clip(h-y,-epsilon,epsilon)
+func NewHuberLoss(epsilon float64) Loss {
+	return elementLoss(
+		func(y, h float64) float64 {
+			if r := math.Abs(h - y); r > epsilon {
+				return epsilon*r - epsilon*epsilon/2.
+			}
+			return (h - y) * (h - y) / 2.
+		},
+		func(y, h float64) float64 { return math.Max(-epsilon, math.Min(epsilon, h-y)) })
+}
+
+// NewEpsilonInsensitiveLoss returns the loss of support vector regressions, ignoring errors up to epsilon
+// J: max(0,|h-y|-epsilon)
+// grad: hprime*sgn(h-y) if |h-y|>epsilon else 0
+func NewEpsilonInsensitiveLoss(epsilon float64) Loss {
+	return elementLoss(
+		func(y, h float64) float64 { return math.Max(0., math.Abs(h-y)-epsilon) },
+		func(y, h float64) float64 {
+			if math.Abs(h-y) <= epsilon {
+				return 0.
+			}
+			return sgn(h - y)
+		})
+}
+
+// NewQuantileLoss returns the pinball loss whose minimizer is the tau quantile of Y, tau in ]0,1[
+// J: tau*(y-h) if y>=h else (1-tau)*(h-y)
+// grad: hprime*(-tau) if y>h else hprime*(1-tau)
+func NewQuantileLoss(tau float64) Loss {
+	if tau <= 0 || tau >= 1 {
+		panic(fmt.Errorf("quantile loss tau must be in ]0,1[, got %g", tau))
+	}
+	return elementLoss(
+		func(y, h float64) float64 {
+			if y >= h {
+				return tau * (y - h)
+			}
+			return (1. - tau) * (h - y)
+		},
+		func(y, h float64) float64 {
+			switch {
+			case y > h:
+				return -tau
+			case y < h:
+				return 1. - tau
+			}
+			return 0.
+		})
+}
+
+// NewTweedieLoss returns the half unit deviance of a Tweedie distribution with the given power:
+// 0 for normal (square loss), 1 for Poisson (y>=0), 2 for Gamma (y>0), in ]1,2[ for compound Poisson-Gamma (y>=0).
+// powers in ]0,1[ are not valid. it's meant to be used with base.Exp activation (log link)
+// J: y^(2-p)/((1-p)(2-p)) - y*h^(1-p)/(1-p) + h^(2-p)/(2-p), y*log(y/h)-y+h for p=1, log(h/y)+y/h-1 for p=2
+// grad: hprime*h^-p*(h-y)
+func NewTweedieLoss(power float64) Loss {
+	if power > 0 && power < 1 {
+		panic(fmt.Errorf("tweedie power must not be in ]0,1[, got %g", power))
+	}
+	var f func(y, h float64) float64
+	switch power {
+	case 0:
+		f = func(y, h float64) float64 { return (h - y) * (h - y) / 2. }
+	case 1:
+		f = func(y, h float64) float64 {
+			if y == 0 {
+				return h
+			}
+			return y*math.Log(y/h) - y + h
+		}
+	case 2:
+		f = func(y, h float64) float64 { return math.Log(h/y) + y/h - 1. }
+	default:
+		f = func(y, h float64) float64 {
+			return math.Pow(math.Max(y, 0), 2.-power)/(1.-power)/(2.-power) - y*math.Pow(h, 1.-power)/(1.-power) + math.Pow(h, 2.-power)/(2.-power)
+		}
+	}
+	return elementLoss(f, func(y, h float64) float64 { return math.Pow(h, -power) * (h - y) })
+}
+
+// elementLoss returns a Loss from the loss f(y,h) of each element and its derivative fprime(y,h) wrt h.
+// Ydiff gets dJ/d(X dot Theta), using the activation FprimeX if it's a base.InputDerivable.
+// all Theta rows are regularized, see interceptExcluded for a column of ones
+func elementLoss(f, fprime func(y, h float64) float64) Loss {
+	return func(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha, L1Ratio float64, nSamples int, activation Activation) (J float64) {
+		hprime := func(xtheta, h float64) float64 { return activation.Fprime(h) }
+		if d, ok := activation.(base.InputDerivable); ok {
+			hprime = func(xtheta, h float64) float64 { return d.FprimeX(xtheta) }
+		}
+		// Ydiff holds X dot Theta until it gets dJ/d(X dot Theta)
+		base.MatMul(Ydiff, X, Theta)
+		Ypred.Apply(func(i, o int, xtheta float64) float64 { return activation.F(xtheta) }, Ydiff)
+		J = 0.
+		Ydiff.Apply(func(i, o int, xtheta float64) float64 {
+			y, h := Ytrue.At(i, o), Ypred.At(i, o)
+			J += f(y, h)
+			return fprime(y, h) * hprime(xtheta, h)
+		}, Ydiff)
+		if grad != nil {
+			base.MatMul(grad, X.T(), Ydiff)
+		}
+		J += addPenalty(Theta, grad, Alpha, L1Ratio, 0, 1)
+		J /= float64(nSamples)
+		if grad != nil {
+			grad.Scale(1./float64(nSamples), grad)
+		}
+		return
+	}
+}
+
+// addPenalty adds scale times the gradient of the Alpha,L1Ratio regularization of Theta rows from firstRow to grad if not nil,
+// and returns scale*Alpha*(L1Ratio*||Theta||_1+(1-L1Ratio)*||Theta||^2/2)
+func addPenalty(Theta mat.Matrix, grad *mat.Dense, Alpha, L1Ratio float64, firstRow int, scale float64) float64 {
+	if Alpha <= 0. {
+		return 0.
+	}
+	L1, L2 := 0., 0.
+	nFeatures, nOutputs := Theta.Dims()
+	for j := firstRow; j < nFeatures; j++ {
+		for o := 0; o < nOutputs; o++ {
+			c := Theta.At(j, o)
+			L1 += math.Abs(c)
+			L2 += c * c / 2.
+			if grad != nil {
+				grad.Set(j, o, grad.At(j, o)+scale*Alpha*(L1Ratio*sgn(c)+(1.-L1Ratio)*c))
+			}
+		}
+	}
+	return scale * Alpha * (L1Ratio*L1 + (1.-L1Ratio)*L2)
+}
+
+// interceptExcluded returns loss regularizing all Theta rows but the first one, which holds the intercept
+// when X has a column of ones prepended
+func interceptExcluded(loss Loss) Loss {
+	return func(Ytrue, X, Theta mat.Matrix, Ypred, Ydiff, grad *mat.Dense, Alpha, L1Ratio float64, nSamples int, activation Activation) (J float64) {
+		J = loss(Ytrue, X, Theta, Ypred, Ydiff, grad, 0, 0, nSamples, activation)
+		return J + addPenalty(Theta, grad, Alpha, L1Ratio, 1, 1./float64(nSamples))
+	}
+}
+
+// activationPrime returns the derivative of activation for sample i and output o, h being its value.
+// it uses FprimeX on X dot Theta for a base.InputDerivable activation, whose Fprime(h) is only right where it's increasing
+func activationPrime(activation Activation, X, Theta mat.Matrix) func(i, o int, h float64) float64 {
+	d, ok := activation.(base.InputDerivable)
+	if !ok {
+		return func(_, _ int, h float64) float64 { return activation.Fprime(h) }
+	}
+	nSamples, _ := X.Dims()
+	_, nOutputs := Theta.Dims()
+	XTheta := mat.NewDense(nSamples, nOutputs, nil)
+	base.MatMul(XTheta, X, Theta)
+	return func(i, o int, _ float64) float64 { return d.FprimeX(XTheta.At(i, o)) }
+}
 
 func sgn(c float64) float64 {
 	if c < 0. {
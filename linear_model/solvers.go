@@ -0,0 +1,189 @@
+package linearModel
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// DirectSolver returns the Theta (nFeatures x nOutputs) minimizing ||Y-X.Theta||^2 + alpha*||Theta||^2.
+// tol is only used by iterative solvers. as in scikit-learn, normal, cholesky and qr fall back to svd when the problem is singular
+type DirectSolver func(X, Y *mat.Dense, alpha, tol float64) *mat.Dense
+
+// DirectSolvers are the LinearRegression Solver values normal,cholesky,qr,svd,lsqr
+var DirectSolvers = map[string]DirectSolver{
+	"normal":   normalSolver,
+	"cholesky": choleskySolver,
+	"qr":       qrSolver,
+	"svd":      svdSolver,
+	"lsqr":     lsqrSolver,
+}
+
+// normalSolver solves the normal equations (Xt.X+alpha*I).Theta=Xt.Y with a LU decomposition
+func normalSolver(X, Y *mat.Dense, alpha, tol float64) *mat.Dense {
+	_, nFeatures := X.Dims()
+	A, B := mat.NewDense(nFeatures, nFeatures, nil), new(mat.Dense)
+	A.Mul(X.T(), X)
+	for j := 0; j < nFeatures; j++ {
+		A.Set(j, j, A.At(j, j)+alpha)
+	}
+	B.Mul(X.T(), Y)
+	Theta := new(mat.Dense)
+	if err := Theta.Solve(A, B); err != nil {
+		return svdSolver(X, Y, alpha, tol)
+	}
+	return Theta
+}
+
+// choleskySolver solves the normal equations with a Cholesky decomposition
+func choleskySolver(X, Y *mat.Dense, alpha, tol float64) *mat.Dense {
+	_, nFeatures := X.Dims()
+	A, B := mat.NewSymDense(nFeatures, nil), new(mat.Dense)
+	A.SymOuterK(1, X.T())
+	for j := 0; j < nFeatures; j++ {
+		A.SetSym(j, j, A.At(j, j)+alpha)
+	}
+	B.Mul(X.T(), Y)
+	var chol mat.Cholesky
+	Theta := new(mat.Dense)
+	if !chol.Factorize(A) || chol.Solve(Theta, B) != nil {
+		return svdSolver(X, Y, alpha, tol)
+	}
+	return Theta
+}
+
+// qrSolver solves the least squares problem with a QR decomposition of X, to which sqrt(alpha)*I rows are appended
+// (with zero rows appended to Y) if alpha>0
+func qrSolver(X0, Y0 *mat.Dense, alpha, tol float64) *mat.Dense {
+	nSamples, nFeatures := X0.Dims()
+	_, nOutputs := Y0.Dims()
+	X, Y := X0, Y0
+	if alpha > 0 {
+		Xa, Ya := mat.NewDense(nSamples+nFeatures, nFeatures, nil), mat.NewDense(nSamples+nFeatures, nOutputs, nil)
+		Xa.Slice(0, nSamples, 0, nFeatures).(*mat.Dense).Copy(X0)
+		Ya.Slice(0, nSamples, 0, nOutputs).(*mat.Dense).Copy(Y0)
+		for j := 0; j < nFeatures; j++ {
+			Xa.Set(nSamples+j, j, math.Sqrt(alpha))
+		}
+		X, Y = Xa, Ya
+	} else if nSamples < nFeatures {
+		return svdSolver(X0, Y0, alpha, tol)
+	}
+	var qr mat.QR
+	qr.Factorize(X)
+	Theta := new(mat.Dense)
+	if qr.Cond() >= 1/rankTolerance(nSamples, nFeatures) || qr.Solve(Theta, false, Y) != nil {
+		return svdSolver(X0, Y0, alpha, tol)
+	}
+	return Theta
+}
+
+// svdSolver computes Theta=V.diag(s/(s^2+alpha)).Ut.Y from the thin singular value decomposition X=U.diag(s).Vt.
+// without alpha, singular values below max(nSamples,nFeatures)*eps*s[0] are ignored, giving the minimum norm solution
+func svdSolver(X, Y *mat.Dense, alpha, tol float64) *mat.Dense {
+	nSamples, nFeatures := X.Dims()
+	var svd mat.SVD
+	if !svd.Factorize(X, mat.SVDThin) {
+		panic(fmt.Errorf("svd solver: svd failed"))
+	}
+	U, s, V := svd.UTo(nil), svd.Values(nil), svd.VTo(nil)
+	cutoff := 0.
+	if alpha == 0 && len(s) > 0 {
+		cutoff = rankTolerance(nSamples, nFeatures) * s[0]
+	}
+	UTY := new(mat.Dense)
+	UTY.Mul(U.T(), Y)
+	UTY.Apply(func(k, _ int, v float64) float64 {
+		if s[k] <= cutoff {
+			return 0
+		}
+		return v * s[k] / (s[k]*s[k] + alpha)
+	}, UTY)
+	Theta := new(mat.Dense)
+	Theta.Mul(V, UTY)
+	return Theta
+}
+
+// rankTolerance is the relative singular value below which a nRows x nCols matrix is considered rank deficient
+func rankTolerance(nRows, nCols int) float64 {
+	return math.Max(float64(nRows), float64(nCols)) * (math.Nextafter(1, 2) - 1)
+}
+
+// lsqrSolver solves each output with lsqr, damped by sqrt(alpha), stopping at relative tolerance tol
+func lsqrSolver(X, Y *mat.Dense, alpha, tol float64) *mat.Dense {
+	_, nFeatures := X.Dims()
+	_, nOutputs := Y.Dims()
+	Theta := mat.NewDense(nFeatures, nOutputs, nil)
+	for o := 0; o < nOutputs; o++ {
+		theta, _ := lsqr(X, mat.Col(nil, o, Y), math.Sqrt(alpha), tol, 2*nFeatures+10)
+		Theta.SetCol(o, theta)
+	}
+	return Theta
+}
+
+// lsqr minimizes ||b-A.x||^2 + damp^2*||x||^2 with the Paige and Saunders bidiagonalization algorithm
+// (see scipy.sparse.linalg.lsqr). A is only used through products with A and At, so it may be sparse.
+// iterations stop when the residual or the normal equations residual is below tol (relative), or after maxIter
+func lsqr(A mat.Matrix, b []float64, damp, tol float64, maxIter int) (x []float64, nIter int) {
+	nRows, nCols := A.Dims()
+	x = make([]float64, nCols)
+	u, v, w := mat.NewVecDense(nRows, nil), mat.NewVecDense(nCols, nil), make([]float64, nCols)
+	u.CopyVec(mat.NewVecDense(nRows, b))
+	beta := mat.Norm(u, 2)
+	if beta == 0 {
+		return
+	}
+	u.ScaleVec(1/beta, u)
+	v.MulVec(A.T(), u)
+	alfa := mat.Norm(v, 2)
+	if alfa == 0 {
+		return
+	}
+	v.ScaleVec(1/alfa, v)
+	copy(w, v.RawVector().Data)
+	bnorm, anorm, res2 := beta, 0., 0.
+	rhobar, phibar := alfa, beta
+	Av, Atu := mat.NewVecDense(nRows, nil), mat.NewVecDense(nCols, nil)
+	for nIter = 1; nIter <= maxIter; nIter++ {
+		// continue the bidiagonalization
+		Av.MulVec(A, v)
+		u.AddScaledVec(Av, -alfa, u)
+		beta = mat.Norm(u, 2)
+		if beta > 0 {
+			u.ScaleVec(1/beta, u)
+			anorm = math.Sqrt(anorm*anorm + alfa*alfa + beta*beta + damp*damp)
+			Atu.MulVec(A.T(), u)
+			v.AddScaledVec(Atu, -beta, v)
+			alfa = mat.Norm(v, 2)
+			if alfa > 0 {
+				v.ScaleVec(1/alfa, v)
+			}
+		}
+		// eliminate the damping, then the subdiagonal beta
+		rhobar1 := math.Hypot(rhobar, damp)
+		cs1, sn1 := rhobar/rhobar1, damp/rhobar1
+		psi := sn1 * phibar
+		phibar *= cs1
+		rho := math.Hypot(rhobar1, beta)
+		cs, sn := rhobar1/rho, beta/rho
+		theta, phi := sn*alfa, cs*phibar
+		rhobar = -cs * alfa
+		phibar *= sn
+		// update x and w
+		floats.AddScaled(x, phi/rho, w)
+		floats.AddScaledTo(w, v.RawVector().Data, -theta/rho, w)
+		// stopping criteria
+		res2 += psi * psi
+		rnorm := math.Sqrt(phibar*phibar + res2)
+		arnorm := alfa * math.Abs(sn*phi)
+		if rnorm <= tol*bnorm+tol*anorm*floats.Norm(x, 2) || arnorm <= tol*anorm*rnorm {
+			break
+		}
+	}
+	if nIter > maxIter {
+		nIter = maxIter
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package linearModel
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/optimize"
+)
+
+func TestDirectSolvers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures, nOutputs := 60, 4, 2
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, nOutputs, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	Y.Apply(func(i, o int, _ float64) float64 {
+		return 1 + float64(o) + 2*X.At(i, 0) - X.At(i, 1) + float64(o)*X.At(i, 3)
+	}, Y)
+	// integer weights are equivalent to repeated samples
+	weights := make([]float64, nSamples)
+	rows := []int{}
+	for i := range weights {
+		weights[i] = float64(1 + rnd.Intn(3))
+		for k := 0; k < int(weights[i]); k++ {
+			rows = append(rows, i)
+		}
+	}
+	Xrep := denseRows(X, rows)
+	for name := range DirectSolvers {
+		// noiseless least squares is exact
+		regr := NewLinearRegression()
+		regr.Solver, regr.Tol = name, 1e-12
+		regr.Fit(X, Y)
+		expected := mat.NewDense(nFeatures, nOutputs, []float64{2, 2, -1, -1, 0, 0, 0, 1})
+		if !mat.EqualApprox(regr.Coef, expected, 1e-8) || !mat.EqualApprox(regr.Intercept, mat.NewDense(1, nOutputs, []float64{1, 2}), 1e-8) {
+			t.Errorf("%s: unexpected coef %v intercept %v", name, regr.Coef.RawMatrix().Data, regr.Intercept.RawMatrix().Data)
+		}
+		noisy := mat.DenseCopyOf(Y)
+		noisy.Apply(func(_, _ int, v float64) float64 { return v + rnd.NormFloat64() }, noisy)
+		noisyRep := denseRows(noisy, rows)
+		for _, normalize := range []bool{false, true} {
+			for _, alpha := range []float64{0, 3} {
+				weighted := NewRidge()
+				weighted.Solver, weighted.Tol, weighted.Alpha, weighted.Normalize = name, 1e-12, alpha, normalize
+				weighted.SampleWeight = weights
+				weighted.Fit(X, noisy)
+				repeated := NewRidge()
+				repeated.Solver, repeated.Tol, repeated.Alpha, repeated.Normalize = "svd", 1e-12, alpha, normalize
+				repeated.Fit(Xrep, noisyRep)
+				if !mat.EqualApprox(weighted.Coef, repeated.Coef, 1e-8) || !mat.EqualApprox(weighted.Intercept, repeated.Intercept, 1e-8) {
+					t.Errorf("%s alpha=%g normalize=%v: weighted fit %v differs from repeated samples fit %v", name, alpha, normalize, weighted.Coef.RawMatrix().Data, repeated.Coef.RawMatrix().Data)
+				}
+			}
+		}
+	}
+	// the cholesky Ridge matches RidgeCV with the same alpha
+	ridge, ridgeCV := NewRidge(), NewRidgeCV()
+	ridge.Solver, ridge.Alpha, ridgeCV.Alphas = "cholesky", 10, []float64{10}
+	noisy := mat.DenseCopyOf(Y)
+	noisy.Apply(func(_, _ int, v float64) float64 { return v + rnd.NormFloat64() }, noisy)
+	ridge.Fit(X, noisy)
+	ridgeCV.Fit(X, noisy)
+	if !mat.EqualApprox(ridge.Coef, ridgeCV.Coef, 1e-10) || !mat.EqualApprox(ridge.Intercept, ridgeCV.Intercept, 1e-10) {
+		t.Errorf("Ridge coef %v differs from RidgeCV %v", ridge.Coef.RawMatrix().Data, ridgeCV.Coef.RawMatrix().Data)
+	}
+}
+
+func TestRidgeIterativeMatchesSolver(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures, nOutputs := 60, 4, 2
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, nOutputs, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	Y.Apply(func(i, o int, _ float64) float64 {
+		return 1 + float64(o) + 2*X.At(i, 0) - X.At(i, 1) + float64(o)*X.At(i, 3) + rnd.NormFloat64()
+	}, Y)
+	exact := NewRidge()
+	exact.Solver, exact.Alpha = "cholesky", 10
+	exact.Fit(X, Y)
+	dense, sparse := NewRidge(), NewRidge()
+	for _, regr := range []*LinearRegression{dense, sparse} {
+		regr.Alpha = 10
+		regr.Options.GOMethodCreator = func() optimize.Method { return &optimize.LBFGS{} }
+	}
+	dense.Fit(X, Y)
+	sparse.FitSparse(base.NewCSRFromMatrix(X), Y)
+	for name, regr := range map[string]*LinearRegression{"dense": dense, "sparse": sparse} {
+		if !mat.EqualApprox(regr.Coef, exact.Coef, 1e-6) || !mat.EqualApprox(regr.Intercept, exact.Intercept, 1e-6) {
+			t.Errorf("%s: iterative coef %v intercept %v differ from cholesky %v %v", name,
+				regr.Coef.RawMatrix().Data, regr.Intercept.RawMatrix().Data, exact.Coef.RawMatrix().Data, exact.Intercept.RawMatrix().Data)
+		}
+	}
+}
+
+func TestDirectSolversSingular(t *testing.T) {
+	// with a duplicated feature, the minimum norm solution splits its coefficient
+	X := mat.NewDense(4, 2, []float64{1, 1, 2, 2, 3, 3, 4, 4})
+	Y := mat.NewDense(4, 1, []float64{2, 4, 6, 8})
+	for name := range DirectSolvers {
+		regr := NewLinearRegression()
+		regr.Solver, regr.FitIntercept, regr.Tol = name, false, 1e-12
+		regr.Fit(X, Y)
+		if !mat.EqualApprox(regr.Coef, mat.NewDense(2, 1, []float64{1, 1}), 1e-8) {
+			t.Errorf("%s: expected coef [1 1], got %v", name, regr.Coef.RawMatrix().Data)
+		}
+	}
+}
+
+func TestDirectSolverPanics(t *testing.T) {
+	X, Y := mat.NewDense(3, 1, []float64{1, 2, 3}), mat.NewDense(3, 1, []float64{1, 2, 3})
+	for name, setup := range map[string]func(*LinearRegression){
+		"unknown solver": func(regr *LinearRegression) { regr.Solver = "gauss" },
+		"L1Ratio":        func(regr *LinearRegression) { regr.L1Ratio = .5 },
+		"sample weights": func(regr *LinearRegression) { regr.SampleWeight = []float64{1, 2} },
+		"sample weights without solver": func(regr *LinearRegression) {
+			regr.Solver, regr.SampleWeight = "", []float64{1, 2, 3}
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			regr := NewRidge()
+			regr.Solver = "cholesky"
+			setup(regr)
+			regr.Fit(X, Y)
+		}()
+	}
+}
@@ -1,14 +1,14 @@
 package metrics
 
-import "gonum.org/v1/gonum/mat"
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 
-//	"fmt"
-// nn "../neural_network"
-
-// LogLoss ...
-// func LogLoss(Ytrue, Ypred mat.Matrix) float64 {
-// 	return nn.LogLoss{}.Func(Ytrue, Ypred)
-// }
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
 
 // AccuracyScore reports (weighted) true values/nSamples
 func AccuracyScore(Ytrue, Ypred mat.Matrix, normalize bool, sampleWeight *mat.Dense) float64 {
@@ -97,3 +97,423 @@ func FBetaScore(Ytrue, Ypred mat.Matrix, beta float64) float64 {
 	TP, FP, _, FN := countTPFPTNFN(Ytrue, Ypred, pivot)
 	return (1 + Beta2) * TP / ((1+Beta2)*TP + Beta2*FN + FP)
 }
+
+// isMultilabel reports whether Y is a multilabel indicator matrix (more than one column) rather than a column of class labels
+func isMultilabel(Y mat.Matrix) bool {
+	_, nOutputs := Y.Dims()
+	return nOutputs > 1
+}
+
+// uniqueLabels returns the sorted union of values in the first column of each matrix
+func uniqueLabels(Ys ...mat.Matrix) []float64 {
+	seen := make(map[float64]bool)
+	labels := make([]float64, 0)
+	for _, Y := range Ys {
+		nSamples, _ := Y.Dims()
+		for i := 0; i < nSamples; i++ {
+			if v := Y.At(i, 0); !seen[v] {
+				seen[v] = true
+				labels = append(labels, v)
+			}
+		}
+	}
+	sort.Float64s(labels)
+	return labels
+}
+
+// weight returns sampleWeight[i] or 1 if sampleWeight is nil
+func weight(sampleWeight *mat.Dense, i int) float64 {
+	if sampleWeight == nil {
+		return 1
+	}
+	return sampleWeight.At(i, 0)
+}
+
+// ConfusionMatrix returns C where C[i,j] is the (weighted) number of samples of true class labels[i] predicted as labels[j].
+// Ytrue and Ypred are columns of class labels. labels defaults to the sorted labels found in Ytrue and Ypred
+func ConfusionMatrix(Ytrue, Ypred mat.Matrix, labels []float64, sampleWeight *mat.Dense) *mat.Dense {
+	if labels == nil {
+		labels = uniqueLabels(Ytrue, Ypred)
+	}
+	index := make(map[float64]int, len(labels))
+	for l, label := range labels {
+		index[label] = l
+	}
+	C := mat.NewDense(len(labels), len(labels), nil)
+	nSamples, _ := Ytrue.Dims()
+	for i := 0; i < nSamples; i++ {
+		t, tok := index[Ytrue.At(i, 0)]
+		p, pok := index[Ypred.At(i, 0)]
+		if tok && pok {
+			C.Set(t, p, C.At(t, p)+weight(sampleWeight, i))
+		}
+	}
+	return C
+}
+
+// MultilabelConfusionMatrix returns a 2x2 confusion matrix [[TN FP] [FN TP]] per output of multilabel indicators Ytrue and Ypred (Ypred is thresholded at .5)
+func MultilabelConfusionMatrix(Ytrue, Ypred mat.Matrix, sampleWeight *mat.Dense) []*mat.Dense {
+	nSamples, nOutputs := Ytrue.Dims()
+	Cs := make([]*mat.Dense, nOutputs)
+	for o := range Cs {
+		Cs[o] = mat.NewDense(2, 2, nil)
+		for i := 0; i < nSamples; i++ {
+			t, p := 0, 0
+			if Ytrue.At(i, o) >= .5 {
+				t = 1
+			}
+			if Ypred.At(i, o) >= .5 {
+				p = 1
+			}
+			Cs[o].Set(t, p, Cs[o].At(t, p)+weight(sampleWeight, i))
+		}
+	}
+	return Cs
+}
+
+// safeDiv returns 0 instead of NaN or Inf when d is 0
+func safeDiv(n, d float64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+func fbeta(precision, recall, beta2 float64) float64 {
+	return safeDiv((1+beta2)*precision*recall, beta2*precision+recall)
+}
+
+// PrecisionRecallFScoreSupport computes precision, recall, F-beta and support for each label of multiclass or multilabel data.
+// for multiclass data Ytrue and Ypred are columns of labels. labels defaults to the sorted labels found in Ytrue and Ypred.
+// for multilabel data Ytrue and Ypred are indicator matrices (Ypred is thresholded at .5) and labels are ignored.
+// average is one of "micro", "macro", "weighted", "samples" (multilabel only) or "" (no averaging).
+// without averaging, precision, recall and fscore have a column per label, else they're 1x1. support always has a column per label
+func PrecisionRecallFScoreSupport(Ytrue, Ypred mat.Matrix, beta float64, labels []float64, average string, sampleWeight *mat.Dense) (precision, recall, fscore, support *mat.Dense) {
+	var tp, fp, fn []float64
+	beta2 := beta * beta
+	if isMultilabel(Ytrue) {
+		Cs := MultilabelConfusionMatrix(Ytrue, Ypred, sampleWeight)
+		for _, C := range Cs {
+			tp, fp, fn = append(tp, C.At(1, 1)), append(fp, C.At(0, 1)), append(fn, C.At(1, 0))
+		}
+	} else {
+		if average == "samples" {
+			panic("average samples is only for multilabel data")
+		}
+		C := ConfusionMatrix(Ytrue, Ypred, labels, sampleWeight)
+		n, _ := C.Dims()
+		for l := 0; l < n; l++ {
+			row, col := mat.Sum(C.RowView(l)), mat.Sum(C.ColView(l))
+			tp, fp, fn = append(tp, C.At(l, l)), append(fp, col-C.At(l, l)), append(fn, row-C.At(l, l))
+		}
+	}
+	nLabels := len(tp)
+	support = mat.NewDense(1, nLabels, nil)
+	p, r, f := make([]float64, nLabels), make([]float64, nLabels), make([]float64, nLabels)
+	for l := range tp {
+		support.Set(0, l, tp[l]+fn[l])
+		p[l], r[l] = safeDiv(tp[l], tp[l]+fp[l]), safeDiv(tp[l], tp[l]+fn[l])
+		f[l] = fbeta(p[l], r[l], beta2)
+	}
+	switch average {
+	case "":
+		return mat.NewDense(1, nLabels, p), mat.NewDense(1, nLabels, r), mat.NewDense(1, nLabels, f), support
+	case "micro":
+		TP, FP, FN := floats.Sum(tp), floats.Sum(fp), floats.Sum(fn)
+		P, R := safeDiv(TP, TP+FP), safeDiv(TP, TP+FN)
+		return mat.NewDense(1, 1, []float64{P}), mat.NewDense(1, 1, []float64{R}), mat.NewDense(1, 1, []float64{fbeta(P, R, beta2)}), support
+	case "macro", "weighted":
+		w := make([]float64, nLabels)
+		if average == "macro" {
+			floats.AddConst(1, w)
+		} else {
+			copy(w, support.RawRowView(0))
+		}
+		sw := floats.Sum(w)
+		avg := func(v []float64) *mat.Dense { return mat.NewDense(1, 1, []float64{safeDiv(floats.Dot(v, w), sw)}) }
+		return avg(p), avg(r), avg(f), support
+	case "samples":
+		nSamples, nOutputs := Ytrue.Dims()
+		var P, R, F, W float64
+		for i := 0; i < nSamples; i++ {
+			var TP, nTrue, nPred float64
+			for o := 0; o < nOutputs; o++ {
+				t, pr := Ytrue.At(i, o) >= .5, Ypred.At(i, o) >= .5
+				if t {
+					nTrue++
+				}
+				if pr {
+					nPred++
+				}
+				if t && pr {
+					TP++
+				}
+			}
+			w := weight(sampleWeight, i)
+			pi, ri := safeDiv(TP, nPred), safeDiv(TP, nTrue)
+			P, R, F, W = P+w*pi, R+w*ri, F+w*fbeta(pi, ri, beta2), W+w
+		}
+		return mat.NewDense(1, 1, []float64{P / W}), mat.NewDense(1, 1, []float64{R / W}), mat.NewDense(1, 1, []float64{F / W}), support
+	default:
+		panic(fmt.Errorf("unknown average %s", average))
+	}
+}
+
+// MulticlassPrecisionScore returns the precision for multiclass or multilabel data. see PrecisionRecallFScoreSupport
+func MulticlassPrecisionScore(Ytrue, Ypred mat.Matrix, labels []float64, average string, sampleWeight *mat.Dense) *mat.Dense {
+	precision, _, _, _ := PrecisionRecallFScoreSupport(Ytrue, Ypred, 1, labels, average, sampleWeight)
+	return precision
+}
+
+// MulticlassRecallScore returns the recall for multiclass or multilabel data. see PrecisionRecallFScoreSupport
+func MulticlassRecallScore(Ytrue, Ypred mat.Matrix, labels []float64, average string, sampleWeight *mat.Dense) *mat.Dense {
+	_, recall, _, _ := PrecisionRecallFScoreSupport(Ytrue, Ypred, 1, labels, average, sampleWeight)
+	return recall
+}
+
+// MulticlassFBetaScore returns the F-beta score for multiclass or multilabel data. see PrecisionRecallFScoreSupport
+func MulticlassFBetaScore(Ytrue, Ypred mat.Matrix, beta float64, labels []float64, average string, sampleWeight *mat.Dense) *mat.Dense {
+	_, _, fscore, _ := PrecisionRecallFScoreSupport(Ytrue, Ypred, beta, labels, average, sampleWeight)
+	return fscore
+}
+
+// MulticlassF1Score returns the F1 score for multiclass or multilabel data. see PrecisionRecallFScoreSupport
+func MulticlassF1Score(Ytrue, Ypred mat.Matrix, labels []float64, average string, sampleWeight *mat.Dense) *mat.Dense {
+	return MulticlassFBetaScore(Ytrue, Ypred, 1, labels, average, sampleWeight)
+}
+
+// BalancedAccuracyScore is the average of recall obtained on each class of multiclass labels.
+// if adjusted, the score is rescaled so that random guessing scores 0
+func BalancedAccuracyScore(Ytrue, Ypred mat.Matrix, sampleWeight *mat.Dense, adjusted bool) float64 {
+	C := ConfusionMatrix(Ytrue, Ypred, nil, sampleWeight)
+	n, _ := C.Dims()
+	score, nClasses := 0., 0.
+	for l := 0; l < n; l++ {
+		// classes absent from Ytrue are ignored
+		if row := mat.Sum(C.RowView(l)); row > 0 {
+			score += C.At(l, l) / row
+			nClasses++
+		}
+	}
+	score /= nClasses
+	if adjusted {
+		chance := 1 / nClasses
+		score = (score - chance) / (1 - chance)
+	}
+	return score
+}
+
+// CohenKappaScore measures inter-annotator agreement between labels Y1 and Y2.
+// weights is "", "linear" or "quadratic" to penalize disagreements by distance between labels indices
+func CohenKappaScore(Y1, Y2 mat.Matrix, labels []float64, weights string, sampleWeight *mat.Dense) float64 {
+	C := ConfusionMatrix(Y1, Y2, labels, sampleWeight)
+	n, _ := C.Dims()
+	total := mat.Sum(C)
+	var observed, expected float64
+	for i := 0; i < n; i++ {
+		rowSum := mat.Sum(C.RowView(i))
+		for j := 0; j < n; j++ {
+			var w float64
+			switch weights {
+			case "":
+				if i != j {
+					w = 1
+				}
+			case "linear":
+				w = math.Abs(float64(i - j))
+			case "quadratic":
+				w = float64((i - j) * (i - j))
+			default:
+				panic(fmt.Errorf("unknown kappa weighting %s", weights))
+			}
+			observed += w * C.At(i, j)
+			expected += w * rowSum * mat.Sum(C.ColView(j)) / total
+		}
+	}
+	return 1 - observed/expected
+}
+
+// MatthewsCorrcoef returns the Matthews correlation coefficient for multiclass labels. it ranges from -1 to 1, 0 being random prediction
+func MatthewsCorrcoef(Ytrue, Ypred mat.Matrix, sampleWeight *mat.Dense) float64 {
+	C := ConfusionMatrix(Ytrue, Ypred, nil, sampleWeight)
+	n, _ := C.Dims()
+	s := mat.Sum(C)
+	c := 0.
+	var pk, pp, tt float64
+	for k := 0; k < n; k++ {
+		c += C.At(k, k)
+		t, p := mat.Sum(C.RowView(k)), mat.Sum(C.ColView(k))
+		pk += p * t
+		pp += p * p
+		tt += t * t
+	}
+	d := math.Sqrt((s*s - pp) * (s*s - tt))
+	return safeDiv(c*s-pk, d)
+}
+
+// ClassificationReportRow is a row of a ClassificationReportTable
+type ClassificationReportRow struct {
+	Label                               string
+	Precision, Recall, F1Score, Support float64
+}
+
+// ClassificationReportTable holds per-label rows followed by averages rows. Accuracy is NaN for multilabel data
+type ClassificationReportTable struct {
+	Rows     []ClassificationReportRow
+	Averages []ClassificationReportRow
+	Accuracy float64
+	Digits   int
+}
+
+// ClassificationReport returns precision, recall, F1 and support for each label and their averages.
+// targetNames are used as row labels if not nil. Digits is the number of digits used by String
+func ClassificationReport(Ytrue, Ypred mat.Matrix, labels []float64, targetNames []string, sampleWeight *mat.Dense, digits int) *ClassificationReportTable {
+	multilabel := isMultilabel(Ytrue)
+	if labels == nil && !multilabel {
+		labels = uniqueLabels(Ytrue, Ypred)
+	}
+	report := &ClassificationReportTable{Digits: digits, Accuracy: math.NaN()}
+	p, r, f, support := PrecisionRecallFScoreSupport(Ytrue, Ypred, 1, labels, "", sampleWeight)
+	_, nLabels := support.Dims()
+	for l := 0; l < nLabels; l++ {
+		var name string
+		switch {
+		case targetNames != nil:
+			name = targetNames[l]
+		case multilabel:
+			name = fmt.Sprint(l)
+		default:
+			name = fmt.Sprint(labels[l])
+		}
+		report.Rows = append(report.Rows, ClassificationReportRow{name, p.At(0, l), r.At(0, l), f.At(0, l), support.At(0, l)})
+	}
+	averages := []string{"micro", "macro", "weighted"}
+	if multilabel {
+		averages = append(averages, "samples")
+	} else {
+		C := ConfusionMatrix(Ytrue, Ypred, labels, sampleWeight)
+		report.Accuracy = mat.Trace(C) / mat.Sum(C)
+	}
+	total := mat.Sum(support)
+	for _, average := range averages {
+		p, r, f, _ := PrecisionRecallFScoreSupport(Ytrue, Ypred, 1, labels, average, sampleWeight)
+		report.Averages = append(report.Averages, ClassificationReportRow{average + " avg", p.At(0, 0), r.At(0, 0), f.At(0, 0), total})
+	}
+	return report
+}
+
+// String formats the report as a text table
+func (report *ClassificationReportTable) String() string {
+	digits := report.Digits
+	if digits <= 0 {
+		digits = 2
+	}
+	width := len("weighted avg")
+	for _, row := range report.Rows {
+		if len(row.Label) > width {
+			width = len(row.Label)
+		}
+	}
+	colWidth := digits + 8
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%*s%*s%*s%*s%*s\n\n", width, "", colWidth, "precision", colWidth, "recall", colWidth, "f1-score", colWidth, "support")
+	line := func(row ClassificationReportRow) {
+		fmt.Fprintf(b, "%*s%*.*f%*.*f%*.*f%*g\n", width, row.Label, colWidth, digits, row.Precision, colWidth, digits, row.Recall, colWidth, digits, row.F1Score, colWidth, row.Support)
+	}
+	for _, row := range report.Rows {
+		line(row)
+	}
+	b.WriteString("\n")
+	for _, row := range report.Averages {
+		if row.Label == "micro avg" && !math.IsNaN(report.Accuracy) {
+			// for multiclass data micro average equals accuracy
+			fmt.Fprintf(b, "%*s%*s%*s%*.*f%*g\n", width, "accuracy", colWidth, "", colWidth, "", colWidth, digits, report.Accuracy, colWidth, row.Support)
+			continue
+		}
+		line(row)
+	}
+	return b.String()
+}
+
+// LogLoss is the (weighted) mean negative log-likelihood of true labels given probabilities Ypred.
+// Ytrue is either an indicator matrix with the shape of Ypred, or a column of labels with a column of Ypred per sorted label.
+// a single column Ypred is the probability of the greatest label of a binary Ytrue.
+// labels defaults to the sorted labels of Ytrue. it's needed when Ytrue doesn't contain all of them, e.g. []float64{0, 1} for an all-ones Ytrue.
+// probabilities are clipped to [eps,1-eps] (eps defaults to 1e-15) and rows are renormalized
+func LogLoss(Ytrue, Ypred mat.Matrix, labels []float64, eps float64, normalize bool, sampleWeight *mat.Dense) float64 {
+	if eps <= 0 {
+		eps = 1e-15
+	}
+	nSamples, nOutputs := Ytrue.Dims()
+	_, nClasses := Ypred.Dims()
+	var index map[float64]int
+	if nOutputs == 1 {
+		if labels == nil {
+			labels = uniqueLabels(Ytrue)
+		}
+		switch {
+		case nClasses == 1 && len(labels) != 2:
+			panic(fmt.Errorf("a single column Ypred needs 2 labels, got %v. pass labels if Ytrue contains only one", labels))
+		case nClasses > 1 && len(labels) != nClasses:
+			panic(fmt.Errorf("Ytrue has %d labels but Ypred has %d columns", len(labels), nClasses))
+		}
+		index = make(map[float64]int, len(labels))
+		for l, label := range labels {
+			index[label] = l
+		}
+		for i := 0; i < nSamples; i++ {
+			if _, ok := index[Ytrue.At(i, 0)]; !ok {
+				panic(fmt.Errorf("Ytrue label %g is not in labels %v", Ytrue.At(i, 0), labels))
+			}
+		}
+	}
+	clip := func(p float64) float64 { return math.Max(eps, math.Min(1-eps, p)) }
+	probas := make([]float64, 2)
+	loss, W := 0., 0.
+	for i := 0; i < nSamples; i++ {
+		if nClasses == 1 {
+			p := clip(Ypred.At(i, 0))
+			probas[0], probas[1] = 1-p, p
+		} else {
+			probas = probas[:0]
+			for c := 0; c < nClasses; c++ {
+				probas = append(probas, clip(Ypred.At(i, c)))
+			}
+		}
+		sum := floats.Sum(probas)
+		l := 0.
+		if index != nil {
+			// for a single column Ypred, index is 0 for the negative label and 1 for the positive one
+			l = -math.Log(probas[index[Ytrue.At(i, 0)]] / sum)
+		} else {
+			for c := 0; c < nOutputs; c++ {
+				l -= Ytrue.At(i, c) * math.Log(probas[c]/sum)
+			}
+		}
+		w := weight(sampleWeight, i)
+		loss += w * l
+		W += w
+	}
+	if normalize {
+		return loss / W
+	}
+	return loss
+}
+
+// BrierScoreLoss is the (weighted) mean squared difference between the probability Yprob of the positive class and the outcome.
+// Ytrue is a column of binary labels, posLabel being positive
+func BrierScoreLoss(Ytrue, Yprob mat.Matrix, posLabel float64, sampleWeight *mat.Dense) float64 {
+	nSamples, _ := Ytrue.Dims()
+	loss, W := 0., 0.
+	for i := 0; i < nSamples; i++ {
+		y := 0.
+		if Ytrue.At(i, 0) == posLabel {
+			y = 1.
+		}
+		d := Yprob.At(i, 0) - y
+		w := weight(sampleWeight, i)
+		loss += w * d * d
+		W += w
+	}
+	return loss / W
+}
@@ -2,7 +2,10 @@ package metrics
 
 import (
 	"fmt"
+	"math"
+	"testing"
 
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -49,3 +52,69 @@ func ExempleFBetaScore() {
 	// Output:
 	// 0.23
 }
+
+func ExampleConfusionMatrix() {
+	Ytrue, Ypred := mat.NewDense(6, 1, []float64{2, 0, 2, 2, 0, 1}), mat.NewDense(6, 1, []float64{0, 0, 2, 2, 0, 2})
+	fmt.Printf("%g\n", mat.Formatted(ConfusionMatrix(Ytrue, Ypred, nil, nil)))
+	// Output:
+	// ⎡2  0  0⎤
+	// ⎢0  0  1⎥
+	// ⎣1  0  2⎦
+}
+
+func ExamplePrecisionRecallFScoreSupport() {
+	Ytrue, Ypred := mat.NewDense(6, 1, []float64{0, 1, 2, 0, 1, 2}), mat.NewDense(6, 1, []float64{0, 2, 1, 0, 0, 1})
+	for _, average := range []string{"macro", "micro", "weighted"} {
+		p, r, f, _ := PrecisionRecallFScoreSupport(Ytrue, Ypred, 1, nil, average, nil)
+		fmt.Printf("%s %.4f %.4f %.4f\n", average, p.At(0, 0), r.At(0, 0), f.At(0, 0))
+	}
+	fmt.Printf("%.2f\n", mat.Formatted(MulticlassF1Score(Ytrue, Ypred, nil, "", nil)))
+	// Output:
+	// macro 0.2222 0.3333 0.2667
+	// micro 0.3333 0.3333 0.3333
+	// weighted 0.2222 0.3333 0.2667
+	// [0.80  0.00  0.00]
+}
+
+func TestMultilabelSamplesAverage(t *testing.T) {
+	Ytrue, Ypred := mat.NewDense(2, 3, []float64{1, 0, 1, 0, 1, 0}), mat.NewDense(2, 3, []float64{1, 0, 0, 0, 1, 1})
+	p, r, f, support := PrecisionRecallFScoreSupport(Ytrue, Ypred, 1, nil, "samples", nil)
+	if !floats.EqualApprox([]float64{p.At(0, 0), r.At(0, 0), f.At(0, 0)}, []float64{.75, .75, 2. / 3}, 1e-12) {
+		t.Errorf("unexpected samples average %g %g %g", p.At(0, 0), r.At(0, 0), f.At(0, 0))
+	}
+	if fmt.Sprint(support.RawRowView(0)) != "[1 1 1]" {
+		t.Errorf("unexpected support %v", support.RawRowView(0))
+	}
+}
+
+func TestAgreementScores(t *testing.T) {
+	// expected values from sklearn.metrics
+	Y1, Y2 := mat.NewDense(6, 1, []float64{2, 0, 2, 2, 0, 1}), mat.NewDense(6, 1, []float64{0, 0, 2, 2, 0, 2})
+	if kappa := CohenKappaScore(Y1, Y2, nil, "", nil); math.Abs(kappa-9./21) > 1e-12 {
+		t.Errorf("CohenKappaScore expected %g got %g", 9./21, kappa)
+	}
+	Ytrue, Ypred := mat.NewDense(4, 1, []float64{1, 1, 1, -1}), mat.NewDense(4, 1, []float64{1, -1, 1, 1})
+	if mcc := MatthewsCorrcoef(Ytrue, Ypred, nil); math.Abs(mcc+1./3) > 1e-12 {
+		t.Errorf("MatthewsCorrcoef expected %g got %g", -1./3, mcc)
+	}
+	Ytrue, Ypred = mat.NewDense(6, 1, []float64{0, 1, 0, 0, 1, 0}), mat.NewDense(6, 1, []float64{0, 1, 0, 0, 0, 1})
+	if ba := BalancedAccuracyScore(Ytrue, Ypred, nil, false); ba != .625 {
+		t.Errorf("BalancedAccuracyScore expected %g got %g", .625, ba)
+	}
+}
+
+func ExampleClassificationReport() {
+	Ytrue, Ypred := mat.NewDense(5, 1, []float64{0, 1, 2, 2, 2}), mat.NewDense(5, 1, []float64{0, 0, 2, 2, 1})
+	report := ClassificationReport(Ytrue, Ypred, nil, []string{"class 0", "class 1", "class 2"}, nil, 2)
+	fmt.Print(report)
+	// Output:
+	//               precision    recall  f1-score   support
+	//
+	//      class 0      0.50      1.00      0.67         1
+	//      class 1      0.00      0.00      0.00         1
+	//      class 2      1.00      0.67      0.80         3
+	//
+	//     accuracy                          0.60         5
+	//    macro avg      0.50      0.56      0.49         5
+	// weighted avg      0.70      0.60      0.61         5
+}
@@ -0,0 +1,403 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gcla/sklearn/metrics/pairwise"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// clusterIndices returns the index in the sorted unique labels of the first column of labels for each sample, and the number of clusters
+func clusterIndices(labels mat.Matrix) (indices []int, nClusters int) {
+	unique := uniqueLabels(labels)
+	index := make(map[float64]int, len(unique))
+	for k, l := range unique {
+		index[l] = k
+	}
+	nSamples, _ := labels.Dims()
+	indices = make([]int, nSamples)
+	for i := range indices {
+		indices[i] = index[labels.At(i, 0)]
+	}
+	return indices, len(unique)
+}
+
+func checkNumberOfLabels(nLabels, nSamples int) {
+	if nLabels < 2 || nLabels > nSamples-1 {
+		panic(fmt.Errorf("number of labels is %d. valid values are 2 to nSamples - 1 (inclusive)", nLabels))
+	}
+}
+
+// chunkDistances calls f with the distances between rows [i0,i1) of X and all rows of X.
+// metric is "precomputed" (X is then a square distance matrix), a pairwise metric name or a pairwise.Metric
+func chunkDistances(X mat.Matrix, metric interface{}, f func(i0, i1 int, D *mat.Dense)) {
+	nSamples, nFeatures := X.Dims()
+	if metric == "precomputed" {
+		if nSamples != nFeatures {
+			panic(fmt.Errorf("precomputed distance matrix must be square, got %dx%d", nSamples, nFeatures))
+		}
+		f(0, nSamples, mat.DenseCopyOf(X))
+		return
+	}
+	pairwise.DistancesChunked(X, nil, metric, func(i0 int, D *mat.Dense) {
+		r, _ := D.Dims()
+		f(i0, i0+r, D)
+	})
+}
+
+// SilhouetteSamples returns the silhouette coefficient (b-a)/max(a,b) of each sample, where a is the mean distance to the other samples of its cluster
+// and b the mean distance to the samples of the nearest other cluster. samples alone in their cluster get 0.
+// distances are computed by chunks to bound memory use. metric is "precomputed" (X is then a square distance matrix), a pairwise metric name or a pairwise.Metric
+func SilhouetteSamples(X, labels mat.Matrix, metric interface{}) []float64 {
+	nSamples, _ := X.Dims()
+	indices, nClusters := clusterIndices(labels)
+	checkNumberOfLabels(nClusters, nSamples)
+	clusterSizes := make([]float64, nClusters)
+	for _, k := range indices {
+		clusterSizes[k]++
+	}
+	silhouettes := make([]float64, nSamples)
+	sums := make([]float64, nClusters)
+	chunkDistances(X, metric, func(i0, i1 int, D *mat.Dense) {
+		for i := i0; i < i1; i++ {
+			for k := range sums {
+				sums[k] = 0
+			}
+			for j, d := range D.RawRowView(i - i0) {
+				sums[indices[j]] += d
+			}
+			own := indices[i]
+			if clusterSizes[own] <= 1 {
+				continue
+			}
+			a := sums[own] / (clusterSizes[own] - 1)
+			b := math.Inf(1)
+			for k, sum := range sums {
+				if k != own {
+					b = math.Min(b, sum/clusterSizes[k])
+				}
+			}
+			silhouettes[i] = (b - a) / math.Max(a, b)
+		}
+	})
+	return silhouettes
+}
+
+// SilhouetteScore returns the mean silhouette coefficient of all samples. best value is 1, worst is -1
+func SilhouetteScore(X, labels mat.Matrix, metric interface{}) float64 {
+	silhouettes := SilhouetteSamples(X, labels, metric)
+	return floats.Sum(silhouettes) / float64(len(silhouettes))
+}
+
+// clusterCentroids returns the centroid of each cluster (rows) and the cluster sizes
+func clusterCentroids(X mat.Matrix, indices []int, nClusters int) (*mat.Dense, []float64) {
+	_, nFeatures := X.Dims()
+	centroids := mat.NewDense(nClusters, nFeatures, nil)
+	sizes := make([]float64, nClusters)
+	for i, k := range indices {
+		sizes[k]++
+		row := centroids.RawRowView(k)
+		for j := range row {
+			row[j] += X.At(i, j)
+		}
+	}
+	for k, size := range sizes {
+		floats.Scale(1/size, centroids.RawRowView(k))
+	}
+	return centroids, sizes
+}
+
+// CalinskiHarabaszScore returns the ratio of between-clusters dispersion to within-cluster dispersion, also known as the variance ratio criterion.
+// higher is better
+func CalinskiHarabaszScore(X, labels mat.Matrix) float64 {
+	nSamples, nFeatures := X.Dims()
+	indices, nClusters := clusterIndices(labels)
+	checkNumberOfLabels(nClusters, nSamples)
+	centroids, sizes := clusterCentroids(X, indices, nClusters)
+	mean := make([]float64, nFeatures)
+	for k, size := range sizes {
+		floats.AddScaled(mean, size/float64(nSamples), centroids.RawRowView(k))
+	}
+	extraDisp, intraDisp := 0., 0.
+	for k, size := range sizes {
+		d := floats.Distance(centroids.RawRowView(k), mean, 2)
+		extraDisp += size * d * d
+	}
+	row := make([]float64, nFeatures)
+	for i, k := range indices {
+		d := floats.Distance(mat.Row(row, i, X), centroids.RawRowView(k), 2)
+		intraDisp += d * d
+	}
+	if intraDisp == 0 {
+		return 1
+	}
+	return extraDisp * float64(nSamples-nClusters) / (intraDisp * float64(nClusters-1))
+}
+
+// DaviesBouldinScore returns the average over clusters of the maximum ratio of within-cluster distances to between-clusters distance.
+// lower is better, the minimum is 0
+func DaviesBouldinScore(X, labels mat.Matrix) float64 {
+	nSamples, nFeatures := X.Dims()
+	indices, nClusters := clusterIndices(labels)
+	checkNumberOfLabels(nClusters, nSamples)
+	centroids, sizes := clusterCentroids(X, indices, nClusters)
+	intraDists := make([]float64, nClusters)
+	row := make([]float64, nFeatures)
+	for i, k := range indices {
+		intraDists[k] += floats.Distance(mat.Row(row, i, X), centroids.RawRowView(k), 2) / sizes[k]
+	}
+	if floats.Sum(intraDists) == 0 {
+		return 0
+	}
+	score := 0.
+	for k := 0; k < nClusters; k++ {
+		worst := 0.
+		for l := 0; l < nClusters; l++ {
+			if l == k {
+				continue
+			}
+			if d := floats.Distance(centroids.RawRowView(k), centroids.RawRowView(l), 2); d > 0 {
+				worst = math.Max(worst, (intraDists[k]+intraDists[l])/d)
+			}
+		}
+		score += worst
+	}
+	return score / float64(nClusters)
+}
+
+// ContingencyMatrix returns C where C[i,j] is the number of samples of true class i in predicted cluster j.
+// classes and clusters are indexed in the order of their sorted labels
+func ContingencyMatrix(labelsTrue, labelsPred mat.Matrix) *mat.Dense {
+	classes, nClasses := clusterIndices(labelsTrue)
+	clusters, nClusters := clusterIndices(labelsPred)
+	if len(classes) != len(clusters) {
+		panic(fmt.Errorf("labelsTrue and labelsPred have different lengths %d and %d", len(classes), len(clusters)))
+	}
+	C := mat.NewDense(nClasses, nClusters, nil)
+	for i := range classes {
+		C.Set(classes[i], clusters[i], C.At(classes[i], clusters[i])+1)
+	}
+	return C
+}
+
+func comb2(n float64) float64 { return n * (n - 1) / 2 }
+
+// pairCounts returns the number of pairs of samples in the same class and cluster, in the same class, in the same cluster, and the total number of pairs
+func pairCounts(C *mat.Dense) (sameBoth, sameClass, sameCluster, pairs float64) {
+	nClasses, nClusters := C.Dims()
+	n := 0.
+	for i := 0; i < nClasses; i++ {
+		row := C.RawRowView(i)
+		sameClass += comb2(floats.Sum(row))
+		for _, nij := range row {
+			sameBoth += comb2(nij)
+			n += nij
+		}
+	}
+	for j := 0; j < nClusters; j++ {
+		sameCluster += comb2(mat.Sum(C.ColView(j)))
+	}
+	return sameBoth, sameClass, sameCluster, comb2(n)
+}
+
+// RandScore returns the proportion of pairs of samples on which both clusterings agree (same or different cluster)
+func RandScore(labelsTrue, labelsPred mat.Matrix) float64 {
+	sameBoth, sameClass, sameCluster, pairs := pairCounts(ContingencyMatrix(labelsTrue, labelsPred))
+	if pairs == 0 {
+		return 1
+	}
+	return (pairs + 2*sameBoth - sameClass - sameCluster) / pairs
+}
+
+// AdjustedRandScore returns the Rand index adjusted for chance: close to 0 for random labelings, 1 for identical ones (up to a permutation)
+func AdjustedRandScore(labelsTrue, labelsPred mat.Matrix) float64 {
+	C := ContingencyMatrix(labelsTrue, labelsPred)
+	nClasses, nClusters := C.Dims()
+	nSamples, _ := labelsTrue.Dims()
+	if nClasses == nClusters && (nClasses <= 1 || nClasses == nSamples) {
+		return 1
+	}
+	sameBoth, sameClass, sameCluster, pairs := pairCounts(C)
+	expected := sameClass * sameCluster / pairs
+	max := (sameClass + sameCluster) / 2
+	if max == expected {
+		return 1
+	}
+	return (sameBoth - expected) / (max - expected)
+}
+
+// entropyOf returns the entropy (in nats) of counts
+func entropyOf(counts []float64) float64 {
+	n := floats.Sum(counts)
+	h := 0.
+	for _, c := range counts {
+		if c > 0 {
+			h -= c / n * math.Log(c/n)
+		}
+	}
+	return h
+}
+
+// marginals returns the row and column sums of C
+func marginals(C *mat.Dense) (a, b []float64) {
+	nClasses, nClusters := C.Dims()
+	a, b = make([]float64, nClasses), make([]float64, nClusters)
+	for i := range a {
+		for j := range b {
+			a[i] += C.At(i, j)
+			b[j] += C.At(i, j)
+		}
+	}
+	return
+}
+
+func mutualInfo(C *mat.Dense) float64 {
+	a, b := marginals(C)
+	n := floats.Sum(a)
+	mi := 0.
+	for i := range a {
+		for j, nij := range C.RawRowView(i) {
+			if nij > 0 {
+				mi += nij / n * math.Log(n*nij/(a[i]*b[j]))
+			}
+		}
+	}
+	return math.Max(0, mi)
+}
+
+// MutualInfoScore returns the mutual information (in nats) between two clusterings
+func MutualInfoScore(labelsTrue, labelsPred mat.Matrix) float64 {
+	return mutualInfo(ContingencyMatrix(labelsTrue, labelsPred))
+}
+
+// generalizedMean returns the "min", "geometric", "arithmetic" (default) or "max" mean of u and v
+func generalizedMean(u, v float64, averageMethod string) float64 {
+	switch averageMethod {
+	case "min":
+		return math.Min(u, v)
+	case "geometric":
+		return math.Sqrt(u * v)
+	case "", "arithmetic":
+		return (u + v) / 2
+	case "max":
+		return math.Max(u, v)
+	default:
+		panic(fmt.Errorf("unknown averageMethod %s", averageMethod))
+	}
+}
+
+// NormalizedMutualInfoScore returns the mutual information divided by a mean of the entropies of both clusterings.
+// averageMethod is "min", "geometric", "arithmetic" (default) or "max"
+func NormalizedMutualInfoScore(labelsTrue, labelsPred mat.Matrix, averageMethod string) float64 {
+	C := ContingencyMatrix(labelsTrue, labelsPred)
+	nClasses, nClusters := C.Dims()
+	if nClasses == nClusters && nClasses <= 1 {
+		return 1
+	}
+	mi := mutualInfo(C)
+	if mi == 0 {
+		return 0
+	}
+	a, b := marginals(C)
+	normalizer := generalizedMean(entropyOf(a), entropyOf(b), averageMethod)
+	return mi / math.Max(normalizer, 0x1p-52)
+}
+
+// expectedMutualInfo returns the expected mutual information of two random clusterings with the marginals of C
+func expectedMutualInfo(C *mat.Dense) float64 {
+	a, b := marginals(C)
+	n := floats.Sum(a)
+	lgamma := func(x float64) float64 {
+		v, _ := math.Lgamma(x)
+		return v
+	}
+	lfact := func(x float64) float64 { return lgamma(x + 1) }
+	emi := 0.
+	for _, ai := range a {
+		for _, bj := range b {
+			start := math.Max(1, ai+bj-n)
+			end := math.Min(ai, bj)
+			common := lfact(ai) + lfact(bj) + lfact(n-ai) + lfact(n-bj) - lfact(n)
+			for nij := start; nij <= end; nij++ {
+				logProb := common - lfact(nij) - lfact(ai-nij) - lfact(bj-nij) - lfact(n-ai-bj+nij)
+				emi += nij / n * math.Log(n*nij/(ai*bj)) * math.Exp(logProb)
+			}
+		}
+	}
+	return emi
+}
+
+// AdjustedMutualInfoScore returns the mutual information adjusted for chance: (MI-E[MI])/(mean(H(true),H(pred))-E[MI]).
+// averageMethod is "min", "geometric", "arithmetic" (default) or "max"
+func AdjustedMutualInfoScore(labelsTrue, labelsPred mat.Matrix, averageMethod string) float64 {
+	C := ContingencyMatrix(labelsTrue, labelsPred)
+	nClasses, nClusters := C.Dims()
+	if nClasses == nClusters && nClasses <= 1 {
+		return 1
+	}
+	mi := mutualInfo(C)
+	emi := expectedMutualInfo(C)
+	a, b := marginals(C)
+	denominator := generalizedMean(entropyOf(a), entropyOf(b), averageMethod) - emi
+	// avoid 0 division while keeping the sign
+	if denominator < 0 {
+		denominator = math.Min(denominator, -0x1p-52)
+	} else {
+		denominator = math.Max(denominator, 0x1p-52)
+	}
+	return (mi - emi) / denominator
+}
+
+// HomogeneityCompletenessVMeasure returns homogeneity (each cluster contains only members of a single class),
+// completeness (all members of a class are in the same cluster) and their weighted harmonic mean, the V-measure.
+// beta>1 weights completeness more, beta<1 homogeneity. the usual value is 1
+func HomogeneityCompletenessVMeasure(labelsTrue, labelsPred mat.Matrix, beta float64) (homogeneity, completeness, vMeasure float64) {
+	nSamples, _ := labelsTrue.Dims()
+	if nSamples == 0 {
+		return 1, 1, 1
+	}
+	C := ContingencyMatrix(labelsTrue, labelsPred)
+	a, b := marginals(C)
+	entropyClasses, entropyClusters := entropyOf(a), entropyOf(b)
+	mi := mutualInfo(C)
+	homogeneity, completeness = 1, 1
+	if entropyClasses != 0 {
+		homogeneity = mi / entropyClasses
+	}
+	if entropyClusters != 0 {
+		completeness = mi / entropyClusters
+	}
+	if homogeneity+completeness > 0 {
+		vMeasure = (1 + beta) * homogeneity * completeness / (beta*homogeneity + completeness)
+	}
+	return
+}
+
+// HomogeneityScore is 1 when each cluster contains only members of a single class
+func HomogeneityScore(labelsTrue, labelsPred mat.Matrix) float64 {
+	h, _, _ := HomogeneityCompletenessVMeasure(labelsTrue, labelsPred, 1)
+	return h
+}
+
+// CompletenessScore is 1 when all members of a class are in the same cluster
+func CompletenessScore(labelsTrue, labelsPred mat.Matrix) float64 {
+	_, c, _ := HomogeneityCompletenessVMeasure(labelsTrue, labelsPred, 1)
+	return c
+}
+
+// VMeasureScore is the weighted harmonic mean of homogeneity and completeness. see HomogeneityCompletenessVMeasure
+func VMeasureScore(labelsTrue, labelsPred mat.Matrix, beta float64) float64 {
+	_, _, v := HomogeneityCompletenessVMeasure(labelsTrue, labelsPred, beta)
+	return v
+}
+
+// FowlkesMallowsScore returns the geometric mean of pairwise precision and recall
+func FowlkesMallowsScore(labelsTrue, labelsPred mat.Matrix) float64 {
+	sameBoth, sameClass, sameCluster, _ := pairCounts(ContingencyMatrix(labelsTrue, labelsPred))
+	if sameBoth == 0 {
+		return 0
+	}
+	return math.Sqrt(sameBoth/sameCluster) * math.Sqrt(sameBoth/sameClass)
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gcla/sklearn/metrics/pairwise"
+	"gonum.org/v1/gonum/mat"
+)
+
+func labelsOf(labels ...float64) *mat.Dense { return mat.NewDense(len(labels), 1, labels) }
+
+func TestClusterInternalScores(t *testing.T) {
+	X := mat.NewDense(4, 1, []float64{0, 1, 4, 5})
+	labels := labelsOf(0, 0, 1, 1)
+	expected := (3.5/4.5 + 2.5/3.5) / 2
+	if s := SilhouetteScore(X, labels, ""); math.Abs(s-expected) > 1e-12 {
+		t.Errorf("SilhouetteScore expected %g got %g", expected, s)
+	}
+	// one row per chunk
+	defer func(wm int) { pairwise.WorkingMemory = wm }(pairwise.WorkingMemory)
+	pairwise.WorkingMemory = 0
+	if s := SilhouetteScore(X, labels, "manhattan"); math.Abs(s-expected) > 1e-12 {
+		t.Errorf("chunked SilhouetteScore expected %g got %g", expected, s)
+	}
+	D := mat.NewDense(4, 4, nil)
+	D.Apply(func(i, j int, _ float64) float64 { return math.Abs(X.At(i, 0) - X.At(j, 0)) }, D)
+	if s := SilhouetteScore(D, labels, "precomputed"); math.Abs(s-expected) > 1e-12 {
+		t.Errorf("precomputed SilhouetteScore expected %g got %g", expected, s)
+	}
+	if ch := CalinskiHarabaszScore(X, labels); math.Abs(ch-32) > 1e-12 {
+		t.Errorf("CalinskiHarabaszScore expected %g got %g", 32., ch)
+	}
+	if db := DaviesBouldinScore(X, labels); math.Abs(db-.25) > 1e-12 {
+		t.Errorf("DaviesBouldinScore expected %g got %g", .25, db)
+	}
+}
+
+func ExampleAdjustedRandScore() {
+	// from sklearn.metrics docstrings
+	fmt.Printf("%.2f\n", AdjustedRandScore(labelsOf(0, 0, 1, 1), labelsOf(1, 1, 0, 0)))
+	fmt.Printf("%.2f\n", AdjustedRandScore(labelsOf(0, 0, 1, 2), labelsOf(0, 0, 1, 1)))
+	fmt.Printf("%.2f\n", AdjustedRandScore(labelsOf(0, 0, 0, 0), labelsOf(0, 1, 2, 3)))
+	fmt.Printf("%.2f\n", RandScore(labelsOf(0, 0, 1, 2), labelsOf(0, 0, 1, 1)))
+	// Output:
+	// 1.00
+	// 0.57
+	// 0.00
+	// 0.83
+}
+
+func ExampleHomogeneityCompletenessVMeasure() {
+	// from sklearn.metrics docstrings
+	for _, pair := range [][2]*mat.Dense{
+		{labelsOf(0, 0, 1, 1), labelsOf(0, 0, 1, 2)},
+		{labelsOf(0, 0, 1, 1), labelsOf(0, 0, 0, 0)},
+		{labelsOf(0, 1, 2, 3), labelsOf(0, 0, 1, 1)},
+		{labelsOf(0, 0, 1, 1), labelsOf(0, 1, 0, 1)},
+	} {
+		h, c, v := HomogeneityCompletenessVMeasure(pair[0], pair[1], 1)
+		fmt.Printf("%.2f %.2f %.2f %.2f\n", h, c, v, FowlkesMallowsScore(pair[0], pair[1]))
+	}
+	// Output:
+	// 1.00 0.67 0.80 0.71
+	// 0.00 1.00 0.00 0.58
+	// 0.50 1.00 0.67 0.00
+	// 0.00 0.00 0.00 0.00
+}
+
+func TestMutualInfoScores(t *testing.T) {
+	if nmi := NormalizedMutualInfoScore(labelsOf(0, 0, 1, 1), labelsOf(1, 1, 0, 0), ""); math.Abs(nmi-1) > 1e-12 {
+		t.Errorf("NormalizedMutualInfoScore expected 1 got %g", nmi)
+	}
+	if nmi := NormalizedMutualInfoScore(labelsOf(0, 0, 0, 0), labelsOf(0, 1, 2, 3), ""); nmi != 0 {
+		t.Errorf("NormalizedMutualInfoScore expected 0 got %g", nmi)
+	}
+	// the expected mutual information is the mean over all permutations of labelsPred
+	labelsTrue, labelsPred := []float64{0, 0, 0, 1, 1, 2}, []float64{0, 0, 1, 1, 2, 2}
+	sum, count := 0., 0.
+	var permute func(k int)
+	permute = func(k int) {
+		if k == len(labelsPred) {
+			sum += MutualInfoScore(labelsOf(labelsTrue...), labelsOf(append([]float64(nil), labelsPred...)...))
+			count++
+			return
+		}
+		for i := k; i < len(labelsPred); i++ {
+			labelsPred[k], labelsPred[i] = labelsPred[i], labelsPred[k]
+			permute(k + 1)
+			labelsPred[k], labelsPred[i] = labelsPred[i], labelsPred[k]
+		}
+	}
+	permute(0)
+	C := ContingencyMatrix(labelsOf(labelsTrue...), labelsOf(labelsPred...))
+	if emi := expectedMutualInfo(C); math.Abs(emi-sum/count) > 1e-12 {
+		t.Errorf("expectedMutualInfo expected %g got %g", sum/count, emi)
+	}
+	mi, a, b := mutualInfo(C), entropyOf([]float64{3, 2, 1}), entropyOf([]float64{2, 2, 2})
+	for _, averageMethod := range []string{"min", "geometric", "arithmetic", "max"} {
+		expected := (mi - sum/count) / (generalizedMean(a, b, averageMethod) - sum/count)
+		if ami := AdjustedMutualInfoScore(labelsOf(labelsTrue...), labelsOf(labelsPred...), averageMethod); math.Abs(ami-expected) > 1e-12 {
+			t.Errorf("AdjustedMutualInfoScore %s expected %g got %g", averageMethod, expected, ami)
+		}
+	}
+	if ami := AdjustedMutualInfoScore(labelsOf(0, 0, 1, 1), labelsOf(1, 1, 0, 0), ""); math.Abs(ami-1) > 1e-12 {
+		t.Errorf("AdjustedMutualInfoScore expected 1 got %g", ami)
+	}
+}
@@ -0,0 +1,108 @@
+package pairwise
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/mat"
+)
+
+// defaultGamma returns gamma, or 1/nFeatures if gamma <= 0
+func defaultGamma(gamma float64, X mat.Matrix) float64 {
+	if gamma > 0 {
+		return gamma
+	}
+	_, nFeatures := X.Dims()
+	return 1 / float64(nFeatures)
+}
+
+// LinearKernel returns X Yᵀ. Y nil means X
+func LinearKernel(X, Y mat.Matrix) *mat.Dense {
+	Xd := asDense(X)
+	Yd := Xd
+	if Y != nil {
+		Yd = asDense(Y)
+	}
+	nX, nFeatures := Xd.Dims()
+	nY, nYFeatures := Yd.Dims()
+	if nFeatures != nYFeatures {
+		panic(fmt.Errorf("X and Y have different numbers of features %d and %d", nFeatures, nYFeatures))
+	}
+	K := mat.NewDense(nX, nY, nil)
+	if nX > 0 && nY > 0 {
+		base.MatParallelGemm(blas.NoTrans, blas.Trans, 1, Xd.RawMatrix(), Yd.RawMatrix(), 0, K.RawMatrix())
+	}
+	return K
+}
+
+// PolynomialKernel returns (gamma X Yᵀ + coef0)^degree. gamma<=0 means 1/nFeatures
+func PolynomialKernel(X, Y mat.Matrix, degree float64, gamma, coef0 float64) *mat.Dense {
+	gamma = defaultGamma(gamma, X)
+	K := LinearKernel(X, Y)
+	K.Apply(func(_, _ int, v float64) float64 { return math.Pow(gamma*v+coef0, degree) }, K)
+	return K
+}
+
+// SigmoidKernel returns tanh(gamma X Yᵀ + coef0). gamma<=0 means 1/nFeatures
+func SigmoidKernel(X, Y mat.Matrix, gamma, coef0 float64) *mat.Dense {
+	gamma = defaultGamma(gamma, X)
+	K := LinearKernel(X, Y)
+	K.Apply(func(_, _ int, v float64) float64 { return math.Tanh(gamma*v + coef0) }, K)
+	return K
+}
+
+// RBFKernel returns exp(-gamma ||x-y||²). gamma<=0 means 1/nFeatures
+func RBFKernel(X, Y mat.Matrix, gamma float64) *mat.Dense {
+	gamma = defaultGamma(gamma, X)
+	K := Distances(X, Y, "sqeuclidean")
+	K.Apply(func(_, _ int, d float64) float64 { return math.Exp(-gamma * d) }, K)
+	return K
+}
+
+// LaplacianKernel returns exp(-gamma ||x-y||₁). gamma<=0 means 1/nFeatures
+func LaplacianKernel(X, Y mat.Matrix, gamma float64) *mat.Dense {
+	gamma = defaultGamma(gamma, X)
+	K := Distances(X, Y, "manhattan")
+	K.Apply(func(_, _ int, d float64) float64 { return math.Exp(-gamma * d) }, K)
+	return K
+}
+
+// CosineSimilarity returns X Yᵀ with rows of X and Y normalized. rows with zero norm have zero similarity
+func CosineSimilarity(X, Y mat.Matrix) *mat.Dense {
+	K := Distances(X, Y, "cosine")
+	K.Apply(func(_, _ int, d float64) float64 { return 1 - d }, K)
+	return K
+}
+
+// chi2 returns Σ (x-y)²/(x+y) over features with x+y != 0
+func chi2(x, y []float64) float64 {
+	s := 0.
+	for k := range x {
+		if x[k] < 0 || y[k] < 0 {
+			panic("negative values in data passed to chi2 kernel")
+		}
+		if d := x[k] + y[k]; d != 0 {
+			s += (x[k] - y[k]) * (x[k] - y[k]) / d
+		}
+	}
+	return s
+}
+
+// AdditiveChi2Kernel returns -Σ (x-y)²/(x+y). X and Y must be non negative, like histograms
+func AdditiveChi2Kernel(X, Y mat.Matrix) *mat.Dense {
+	K := Distances(X, Y, Metric(chi2))
+	K.Scale(-1, K)
+	return K
+}
+
+// Chi2Kernel returns exp(-gamma Σ (x-y)²/(x+y)). X and Y must be non negative. gamma<=0 means 1
+func Chi2Kernel(X, Y mat.Matrix, gamma float64) *mat.Dense {
+	if gamma <= 0 {
+		gamma = 1
+	}
+	K := Distances(X, Y, Metric(chi2))
+	K.Apply(func(_, _ int, d float64) float64 { return math.Exp(-gamma * d) }, K)
+	return K
+}
@@ -0,0 +1,253 @@
+package pairwise
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// WorkingMemory is the max size in MiB of the chunks of pairwise matrices computed at once
+var WorkingMemory = 1024
+
+// Metric computes the distance between two vectors
+type Metric func(x, y []float64) float64
+
+// Euclidean distance
+func Euclidean(x, y []float64) float64 { return floats.Distance(x, y, 2) }
+
+// SqEuclidean is the squared euclidean distance
+func SqEuclidean(x, y []float64) float64 { d := floats.Distance(x, y, 2); return d * d }
+
+// Manhattan distance, aka cityblock or L1
+func Manhattan(x, y []float64) float64 { return floats.Distance(x, y, 1) }
+
+// Chebyshev distance, aka L∞
+func Chebyshev(x, y []float64) float64 { return floats.Distance(x, y, math.Inf(1)) }
+
+// Cosine distance is 1-cosine similarity. it's 1 if x or y is zero
+func Cosine(x, y []float64) float64 {
+	nx, ny := floats.Norm(x, 2), floats.Norm(y, 2)
+	if nx == 0 || ny == 0 {
+		return 1
+	}
+	return 1 - floats.Dot(x, y)/(nx*ny)
+}
+
+// Minkowski returns the L-p distance
+func Minkowski(p float64) Metric {
+	if p < 1 {
+		panic(fmt.Errorf("minkowski p must be at least 1, got %g", p))
+	}
+	return func(x, y []float64) float64 { return floats.Distance(x, y, p) }
+}
+
+// Haversine is the great circle distance on the unit sphere between points given as (latitude, longitude) in radians
+func Haversine(x, y []float64) float64 {
+	if len(x) != 2 || len(y) != 2 {
+		panic("haversine distance is only valid in 2 dimensions")
+	}
+	sinLat, sinLon := math.Sin((y[0]-x[0])/2), math.Sin((y[1]-x[1])/2)
+	return 2 * math.Asin(math.Sqrt(sinLat*sinLat+math.Cos(x[0])*math.Cos(y[0])*sinLon*sinLon))
+}
+
+// Metrics are the metrics known by name. euclidean, sqeuclidean and cosine distance matrices are computed with a matrix product
+var Metrics = map[string]Metric{
+	"euclidean":   Euclidean,
+	"sqeuclidean": SqEuclidean,
+	"manhattan":   Manhattan,
+	"cityblock":   Manhattan,
+	"l1":          Manhattan,
+	"l2":          Euclidean,
+	"chebyshev":   Chebyshev,
+	"cosine":      Cosine,
+	"haversine":   Haversine,
+}
+
+// asDense returns X as a *mat.Dense, copying it if needed
+func asDense(X mat.Matrix) *mat.Dense {
+	if X, ok := X.(*mat.Dense); ok {
+		return X
+	}
+	return mat.DenseCopyOf(X)
+}
+
+// parallelFor calls f(i) for i in [0,n) from runtime.NumCPU() goroutines
+func parallelFor(n int, f func(i int)) {
+	nJobs := runtime.NumCPU()
+	if nJobs > n {
+		nJobs = n
+	}
+	var wg sync.WaitGroup
+	for job := 0; job < nJobs; job++ {
+		wg.Add(1)
+		go func(job int) {
+			defer wg.Done()
+			for i := job; i < n; i += nJobs {
+				f(i)
+			}
+		}(job)
+	}
+	wg.Wait()
+}
+
+// rowNorms returns the squared L2 norm of each row of X
+func rowNorms(X *mat.Dense) []float64 {
+	r, _ := X.Dims()
+	norms := make([]float64, r)
+	for i := range norms {
+		row := X.RawRowView(i)
+		norms[i] = floats.Dot(row, row)
+	}
+	return norms
+}
+
+// chunkRows returns the number of rows of a chunk with nColumns fitting in WorkingMemory
+func chunkRows(nColumns int) int {
+	rows := WorkingMemory << 20 / (8 * nColumns)
+	if rows < 1 {
+		rows = 1
+	}
+	return rows
+}
+
+// resolveMetric returns the metric function and name for a metric given as a name or a Metric.
+// name is "" for custom metrics
+func resolveMetric(metric interface{}) (Metric, string) {
+	switch m := metric.(type) {
+	case nil:
+		return Euclidean, "euclidean"
+	case string:
+		if m == "" {
+			m = "euclidean"
+		}
+		f, ok := Metrics[m]
+		if !ok {
+			panic(fmt.Errorf("unknown metric %s", m))
+		}
+		return f, m
+	case Metric:
+		return m, ""
+	case func(x, y []float64) float64:
+		return m, ""
+	default:
+		panic(fmt.Errorf("metric must be a string or a Metric, got %T", metric))
+	}
+}
+
+// DistancesChunked calls reduce with the distances between rows [i0,i0+rows of D) of X and all rows of Y, for successive chunks of rows of X.
+// chunks fit in WorkingMemory so the full distance matrix is never allocated. D is reused between calls.
+// Y nil means X. metric is a name in Metrics or a Metric, defaults to "euclidean".
+// dot product based metrics use base.MatParallelGemm, others are computed concurrently by rows
+func DistancesChunked(X, Y mat.Matrix, metric interface{}, reduce func(i0 int, D *mat.Dense)) {
+	f, name := resolveMetric(metric)
+	Xd := asDense(X)
+	same := Y == nil
+	Yd := Xd
+	if !same {
+		Yd = asDense(Y)
+	}
+	nX, nFeatures := Xd.Dims()
+	nY, nYFeatures := Yd.Dims()
+	if nFeatures != nYFeatures {
+		panic(fmt.Errorf("X and Y have different numbers of features %d and %d", nFeatures, nYFeatures))
+	}
+	if nX == 0 || nY == 0 {
+		return
+	}
+	var xNorms, yNorms []float64
+	if name == "euclidean" || name == "l2" || name == "sqeuclidean" || name == "cosine" {
+		xNorms = rowNorms(Xd)
+		yNorms = xNorms
+		if !same {
+			yNorms = rowNorms(Yd)
+		}
+	}
+	chunk := chunkRows(nY)
+	if chunk > nX {
+		chunk = nX
+	}
+	buf := make([]float64, chunk*nY)
+	for i0 := 0; i0 < nX; i0 += chunk {
+		i1 := i0 + chunk
+		if i1 > nX {
+			i1 = nX
+		}
+		D := mat.NewDense(i1-i0, nY, buf[:(i1-i0)*nY])
+		Xc := base.MatDenseRowSlice(Xd, i0, i1)
+		if xNorms != nil {
+			base.MatParallelGemm(blas.NoTrans, blas.Trans, 1, Xc.RawMatrix(), Yd.RawMatrix(), 0, D.RawMatrix())
+			parallelFor(i1-i0, func(i int) {
+				row := D.RawRowView(i)
+				nx := xNorms[i0+i]
+				for j, dot := range row {
+					switch {
+					case same && i0+i == j && (name != "cosine" || nx != 0):
+						// exact 0 on the diagonal, but a zero row is at cosine distance 1 of itself
+						row[j] = 0
+					case name == "cosine":
+						if nx == 0 || yNorms[j] == 0 {
+							row[j] = 1
+						} else {
+							row[j] = 1 - dot/math.Sqrt(nx*yNorms[j])
+						}
+					case name == "sqeuclidean":
+						row[j] = math.Max(0, nx+yNorms[j]-2*dot)
+					default:
+						row[j] = math.Sqrt(math.Max(0, nx+yNorms[j]-2*dot))
+					}
+				}
+			})
+		} else {
+			parallelFor(i1-i0, func(i int) {
+				row, x := D.RawRowView(i), Xc.RawRowView(i)
+				for j := range row {
+					row[j] = f(x, Yd.RawRowView(j))
+				}
+			})
+		}
+		reduce(i0, D)
+	}
+}
+
+// Distances returns the matrix of distances between rows of X and rows of Y. Y nil means X. see DistancesChunked for metric
+func Distances(X, Y mat.Matrix, metric interface{}) *mat.Dense {
+	nX, _ := X.Dims()
+	nY := nX
+	if Y != nil {
+		nY, _ = Y.Dims()
+	}
+	D := mat.NewDense(nX, nY, nil)
+	DistancesChunked(X, Y, metric, func(i0 int, chunk *mat.Dense) {
+		r, _ := chunk.Dims()
+		base.MatDenseRowSlice(D, i0, i0+r).Copy(chunk)
+	})
+	return D
+}
+
+// ArgminMin returns for each row of X the index of the nearest row of Y and the distance to it, without allocating the full distance matrix.
+// see DistancesChunked for metric
+func ArgminMin(X, Y mat.Matrix, metric interface{}) (argmin []int, min []float64) {
+	nX, _ := X.Dims()
+	argmin, min = make([]int, nX), make([]float64, nX)
+	DistancesChunked(X, Y, metric, func(i0 int, D *mat.Dense) {
+		r, _ := D.Dims()
+		for i := 0; i < r; i++ {
+			row := D.RawRowView(i)
+			j := floats.MinIdx(row)
+			argmin[i0+i], min[i0+i] = j, row[j]
+		}
+	})
+	return
+}
+
+// Argmin returns for each row of X the index of the nearest row of Y. see ArgminMin
+func Argmin(X, Y mat.Matrix, metric interface{}) []int {
+	argmin, _ := ArgminMin(X, Y, metric)
+	return argmin
+}
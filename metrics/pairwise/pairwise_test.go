@@ -0,0 +1,90 @@
+package pairwise
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func ExampleDistances() {
+	X := mat.NewDense(3, 2, []float64{0, 1, 1, 1, 3, 1})
+	Y := mat.NewDense(2, 2, []float64{0, 0, 4, 4})
+	fmt.Printf("%.3f\n", mat.Formatted(Distances(X, Y, "euclidean")))
+	fmt.Println(ArgminMin(X, Y, "manhattan"))
+	// distance between Buenos Aires and Paris in km
+	bsas, paris := []float64{-34.83333, -58.5166646}, []float64{49.0083899664, 2.53844117956}
+	floats.Scale(math.Pi/180, bsas)
+	floats.Scale(math.Pi/180, paris)
+	fmt.Printf("%.2f\n", 6371*Distances(mat.NewDense(1, 2, bsas), mat.NewDense(1, 2, paris), "haversine").At(0, 0))
+	// Output:
+	// ⎡1.000  5.000⎤
+	// ⎢1.414  4.243⎥
+	// ⎣3.162  3.162⎦
+	// [0 0 0] [1 2 4]
+	// 11099.54
+}
+
+func randomMatrix(r, c int, rnd *rand.Rand) *mat.Dense {
+	X := mat.NewDense(r, c, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	return X
+}
+
+func TestDistancesChunked(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	X, Y := randomMatrix(17, 5, rnd), randomMatrix(11, 5, rnd)
+	defer func(wm int) { WorkingMemory = wm }(WorkingMemory)
+	for _, wm := range []int{1024, 0} {
+		WorkingMemory = wm
+		for name, metric := range Metrics {
+			if name == "haversine" {
+				continue
+			}
+			D := Distances(X, Y, name)
+			expected := Distances(X, Y, Metric(metric))
+			if !mat.EqualApprox(D, expected, 1e-12) {
+				t.Errorf("%s distances differ from the metric function", name)
+			}
+			for i, j := range Argmin(X, Y, name) {
+				if D.At(i, j) != floats.Min(D.RawRowView(i)) {
+					t.Errorf("%s argmin %d is not the min of row %d", name, j, i)
+				}
+			}
+		}
+		D := Distances(X, nil, "euclidean")
+		if !mat.EqualApprox(D, D.T(), 1e-12) || D.At(3, 3) != 0 {
+			t.Error("distances of X to itself must be symmetric with zero diagonal")
+		}
+	}
+	if d := Minkowski(3)([]float64{0, 0}, []float64{1, 2}); math.Abs(d-math.Cbrt(9)) > 1e-12 {
+		t.Errorf("Minkowski(3) expected %g got %g", math.Cbrt(9), d)
+	}
+	if d := Chebyshev([]float64{0, 0}, []float64{1, -2}); d != 2 {
+		t.Errorf("Chebyshev expected 2 got %g", d)
+	}
+}
+
+func TestKernels(t *testing.T) {
+	X := mat.NewDense(2, 2, []float64{1, 0, 1, 1})
+	check := func(name string, K *mat.Dense, expected []float64) {
+		if !floats.EqualApprox(K.RawMatrix().Data, expected, 1e-12) {
+			t.Errorf("%s expected %g got %g", name, expected, K.RawMatrix().Data)
+		}
+	}
+	check("LinearKernel", LinearKernel(X, nil), []float64{1, 1, 1, 2})
+	check("PolynomialKernel", PolynomialKernel(X, nil, 2, 1, 1), []float64{4, 4, 4, 9})
+	check("SigmoidKernel", SigmoidKernel(X, nil, .5, 0), []float64{math.Tanh(.5), math.Tanh(.5), math.Tanh(.5), math.Tanh(1)})
+	check("RBFKernel", RBFKernel(X, nil, 0), []float64{1, math.Exp(-.5), math.Exp(-.5), 1})
+	check("LaplacianKernel", LaplacianKernel(X, nil, 2), []float64{1, math.Exp(-2), math.Exp(-2), 1})
+	check("CosineSimilarity", CosineSimilarity(X, nil), []float64{1, math.Sqrt(.5), math.Sqrt(.5), 1})
+	// a zero row has zero similarity, even with itself
+	Xzero := mat.NewDense(2, 2, []float64{1, 1, 0, 0})
+	check("CosineSimilarity zero row", CosineSimilarity(Xzero, nil), []float64{1, 0, 0, 0})
+	check("CosineSimilarity zero row with Y", CosineSimilarity(Xzero, Xzero), []float64{1, 0, 0, 0})
+	check("AdditiveChi2Kernel", AdditiveChi2Kernel(X, nil), []float64{0, -1, -1, 0})
+	check("Chi2Kernel", Chi2Kernel(X, nil, 0), []float64{1, math.Exp(-1), math.Exp(-1), 1})
+}
@@ -0,0 +1,389 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Auc computes the area under the curve (x,y) using the trapezoidal rule. x must be monotonic
+func Auc(x, y []float64) float64 {
+	area := 0.
+	for i := 1; i < len(x); i++ {
+		area += (x[i] - x[i-1]) * (y[i] + y[i-1]) / 2
+	}
+	return math.Abs(area)
+}
+
+// binaryClfCurve returns (weighted) false and true positives counts for each distinct decision threshold of yScore, in decreasing order.
+// yTrue and yScore are the first columns of the matrices, samples with yTrue==posLabel are positive
+func binaryClfCurve(yTrue, yScore mat.Matrix, posLabel float64, sampleWeight *mat.Dense) (fps, tps, thresholds []float64) {
+	nSamples, _ := yTrue.Dims()
+	order := make([]int, nSamples)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return yScore.At(order[a], 0) > yScore.At(order[b], 0) })
+	var tp, fp float64
+	for n, i := range order {
+		w := weight(sampleWeight, i)
+		if yTrue.At(i, 0) == posLabel {
+			tp += w
+		} else {
+			fp += w
+		}
+		// keep only the last sample of each group of equal scores
+		if n == nSamples-1 || yScore.At(order[n+1], 0) != yScore.At(i, 0) {
+			fps, tps, thresholds = append(fps, fp), append(tps, tp), append(thresholds, yScore.At(i, 0))
+		}
+	}
+	return
+}
+
+// positiveLabel returns the greatest label of yTrue, which is the positive class for binary problems
+func positiveLabel(yTrue mat.Matrix) float64 {
+	labels := uniqueLabels(yTrue)
+	return labels[len(labels)-1]
+}
+
+// RocCurve computes the receiver operating characteristic of binary yTrue (the first column) for scores yScore.
+// if dropIntermediate, suboptimal thresholds that don't change the curve shape are dropped.
+// thresholds[0] is +Inf and has fpr=tpr=0. it panics if yTrue has no positive or no negative sample, the rates being undefined
+func RocCurve(yTrue, yScore mat.Matrix, posLabel float64, sampleWeight *mat.Dense, dropIntermediate bool) (fpr, tpr, thresholds []float64) {
+	fps, tps, thr := binaryClfCurve(yTrue, yScore, posLabel, sampleWeight)
+	checkPositives(tps, posLabel)
+	if fps[len(fps)-1] == 0 {
+		panic(fmt.Errorf("no negative sample in yTrue (posLabel %g): false positive rate is undefined", posLabel))
+	}
+	if dropIntermediate && len(fps) > 2 {
+		// keep points where the slope changes
+		keep := []int{0}
+		for i := 1; i < len(fps)-1; i++ {
+			if (fps[i+1]-fps[i])*(tps[i]-tps[i-1]) != (tps[i+1]-tps[i])*(fps[i]-fps[i-1]) {
+				keep = append(keep, i)
+			}
+		}
+		keep = append(keep, len(fps)-1)
+		var f, t, th []float64
+		for _, i := range keep {
+			f, t, th = append(f, fps[i]), append(t, tps[i]), append(th, thr[i])
+		}
+		fps, tps, thr = f, t, th
+	}
+	fps, tps = append([]float64{0}, fps...), append([]float64{0}, tps...)
+	thresholds = append([]float64{math.Inf(1)}, thr...)
+	fpr, tpr = fps, tps
+	floats.Scale(1/fps[len(fps)-1], fpr)
+	floats.Scale(1/tps[len(tps)-1], tpr)
+	return
+}
+
+// checkPositives panics if the last true positives count of a binaryClfCurve is 0
+func checkPositives(tps []float64, posLabel float64) {
+	if len(tps) == 0 || tps[len(tps)-1] == 0 {
+		panic(fmt.Errorf("no positive sample in yTrue (posLabel %g): true positive rate is undefined", posLabel))
+	}
+}
+
+func binaryRocAuc(yTrue, yScore mat.Matrix, posLabel float64, sampleWeight *mat.Dense) float64 {
+	fpr, tpr, _ := RocCurve(yTrue, yScore, posLabel, sampleWeight, false)
+	return Auc(fpr, tpr)
+}
+
+// columnOf returns column o of Y as a *mat.Dense
+func columnOf(Y mat.Matrix, o int) *mat.Dense {
+	nSamples, _ := Y.Dims()
+	return mat.NewDense(nSamples, 1, mat.Col(nil, o, Y))
+}
+
+// averageScores averages per-label scores. average is "macro", "weighted" (by weights) or "" (no averaging)
+func averageScores(scores, weights []float64, average string) *mat.Dense {
+	switch average {
+	case "":
+		return mat.NewDense(1, len(scores), scores)
+	case "macro":
+		return mat.NewDense(1, 1, []float64{floats.Sum(scores) / float64(len(scores))})
+	case "weighted":
+		return mat.NewDense(1, 1, []float64{floats.Dot(scores, weights) / floats.Sum(weights)})
+	default:
+		panic(fmt.Errorf("unknown average %s", average))
+	}
+}
+
+// multilabelAverage computes binaryMetric for each column of indicators Ytrue and scores Yscore, then averages them.
+// "micro" average computes binaryMetric on all the values at once. "weighted" uses the number of positives per label
+func multilabelAverage(Ytrue, Yscore mat.Matrix, average string, sampleWeight *mat.Dense, binaryMetric func(yTrue, yScore mat.Matrix, sampleWeight *mat.Dense) float64) *mat.Dense {
+	nSamples, nLabels := Ytrue.Dims()
+	if average == "micro" {
+		yTrue, yScore := mat.NewDense(nSamples*nLabels, 1, nil), mat.NewDense(nSamples*nLabels, 1, nil)
+		var w *mat.Dense
+		if sampleWeight != nil {
+			w = mat.NewDense(nSamples*nLabels, 1, nil)
+		}
+		for i := 0; i < nSamples; i++ {
+			for o := 0; o < nLabels; o++ {
+				yTrue.Set(i*nLabels+o, 0, Ytrue.At(i, o))
+				yScore.Set(i*nLabels+o, 0, Yscore.At(i, o))
+				if w != nil {
+					w.Set(i*nLabels+o, 0, sampleWeight.At(i, 0))
+				}
+			}
+		}
+		return mat.NewDense(1, 1, []float64{binaryMetric(yTrue, yScore, w)})
+	}
+	scores, weights := make([]float64, nLabels), make([]float64, nLabels)
+	for o := range scores {
+		yTrue := columnOf(Ytrue, o)
+		scores[o] = binaryMetric(yTrue, columnOf(Yscore, o), sampleWeight)
+		for i := 0; i < nSamples; i++ {
+			weights[o] += yTrue.At(i, 0) * weight(sampleWeight, i)
+		}
+	}
+	return averageScores(scores, weights, average)
+}
+
+// RocAucScore computes the area under the ROC curve.
+// binary: Ytrue is a column of 2 labels (the greatest being positive) and Yscore has one column.
+// multilabel: Ytrue is an indicator matrix with the shape of Yscore. average is "micro", "macro", "weighted" or "" (per label).
+// multiclass: Ytrue is a column of labels and Yscore has a column of probabilities per sorted label.
+// multiClass is "ovr" (one-vs-rest, average "macro" or "weighted" by prevalence) or "ovo" (one-vs-one, Hand & Till, average "macro" or "weighted")
+func RocAucScore(Ytrue, Yscore mat.Matrix, average, multiClass string, sampleWeight *mat.Dense) *mat.Dense {
+	_, nOutputs := Ytrue.Dims()
+	_, nScores := Yscore.Dims()
+	binary := func(yTrue, yScore mat.Matrix, sampleWeight *mat.Dense) float64 {
+		return binaryRocAuc(yTrue, yScore, 1, sampleWeight)
+	}
+	switch {
+	case nOutputs > 1:
+		return multilabelAverage(Ytrue, Yscore, average, sampleWeight, binary)
+	case nScores == 1:
+		return mat.NewDense(1, 1, []float64{binaryRocAuc(Ytrue, Yscore, positiveLabel(Ytrue), sampleWeight)})
+	}
+	labels := uniqueLabels(Ytrue)
+	if len(labels) != nScores {
+		panic(fmt.Errorf("Ytrue has %d labels but Yscore has %d columns", len(labels), nScores))
+	}
+	nSamples, _ := Ytrue.Dims()
+	prevalence := make([]float64, len(labels))
+	for l, label := range labels {
+		for i := 0; i < nSamples; i++ {
+			if Ytrue.At(i, 0) == label {
+				prevalence[l] += weight(sampleWeight, i)
+			}
+		}
+	}
+	switch multiClass {
+	case "", "ovr":
+		scores := make([]float64, len(labels))
+		for l, label := range labels {
+			scores[l] = binaryRocAuc(Ytrue, columnOf(Yscore, l), label, sampleWeight)
+		}
+		return averageScores(scores, prevalence, average)
+	case "ovo":
+		var scores, weights []float64
+		for a := range labels {
+			for b := a + 1; b < len(labels); b++ {
+				var rows []int
+				for i := 0; i < nSamples; i++ {
+					if y := Ytrue.At(i, 0); y == labels[a] || y == labels[b] {
+						rows = append(rows, i)
+					}
+				}
+				yTrue, w := mat.NewDense(len(rows), 1, nil), (*mat.Dense)(nil)
+				scoreA, scoreB := mat.NewDense(len(rows), 1, nil), mat.NewDense(len(rows), 1, nil)
+				if sampleWeight != nil {
+					w = mat.NewDense(len(rows), 1, nil)
+				}
+				for r, i := range rows {
+					yTrue.Set(r, 0, Ytrue.At(i, 0))
+					scoreA.Set(r, 0, Yscore.At(i, a))
+					scoreB.Set(r, 0, Yscore.At(i, b))
+					if w != nil {
+						w.Set(r, 0, sampleWeight.At(i, 0))
+					}
+				}
+				aucAB := binaryRocAuc(yTrue, scoreA, labels[a], w)
+				aucBA := binaryRocAuc(yTrue, scoreB, labels[b], w)
+				scores = append(scores, (aucAB+aucBA)/2)
+				weights = append(weights, prevalence[a]+prevalence[b])
+			}
+		}
+		return averageScores(scores, weights, average)
+	default:
+		panic(fmt.Errorf("unknown multiClass %s", multiClass))
+	}
+}
+
+// PrecisionRecallCurve computes precision-recall pairs for each threshold of yScore for binary yTrue.
+// precision and recall have a last value of 1 and 0 with no threshold, thresholds are increasing.
+// it panics if yTrue has no positive sample, recall being undefined
+func PrecisionRecallCurve(yTrue, yScore mat.Matrix, posLabel float64, sampleWeight *mat.Dense) (precision, recall, thresholds []float64) {
+	fps, tps, thr := binaryClfCurve(yTrue, yScore, posLabel, sampleWeight)
+	checkPositives(tps, posLabel)
+	// stop when full recall is attained
+	last := len(tps) - 1
+	for last > 0 && tps[last-1] == tps[len(tps)-1] {
+		last--
+	}
+	for i := last; i >= 0; i-- {
+		precision = append(precision, safeDiv(tps[i], tps[i]+fps[i]))
+		recall = append(recall, tps[i]/tps[len(tps)-1])
+		thresholds = append(thresholds, thr[i])
+	}
+	precision, recall = append(precision, 1), append(recall, 0)
+	return
+}
+
+func binaryAveragePrecision(yTrue, yScore mat.Matrix, posLabel float64, sampleWeight *mat.Dense) float64 {
+	precision, recall, _ := PrecisionRecallCurve(yTrue, yScore, posLabel, sampleWeight)
+	ap := 0.
+	for i := 0; i < len(precision)-1; i++ {
+		ap -= (recall[i+1] - recall[i]) * precision[i]
+	}
+	return ap
+}
+
+// AveragePrecisionScore summarizes the precision-recall curve as the weighted mean of precisions at each threshold.
+// binary: Ytrue is a column of 2 labels (the greatest being positive) and Yscore has one column.
+// multilabel: Ytrue is an indicator matrix with the shape of Yscore. average is "micro", "macro", "weighted" or ""
+func AveragePrecisionScore(Ytrue, Yscore mat.Matrix, average string, sampleWeight *mat.Dense) *mat.Dense {
+	if _, nOutputs := Ytrue.Dims(); nOutputs > 1 {
+		return multilabelAverage(Ytrue, Yscore, average, sampleWeight, func(yTrue, yScore mat.Matrix, sampleWeight *mat.Dense) float64 {
+			return binaryAveragePrecision(yTrue, yScore, 1, sampleWeight)
+		})
+	}
+	return mat.NewDense(1, 1, []float64{binaryAveragePrecision(Ytrue, Yscore, positiveLabel(Ytrue), sampleWeight)})
+}
+
+// TopKAccuracyScore is the (weighted) fraction of samples whose label (first column of Ytrue) is among the k highest scores of Yscore.
+// Yscore has a column per label. labels defaults to the sorted labels of Ytrue
+func TopKAccuracyScore(Ytrue, Yscore mat.Matrix, k int, labels []float64, normalize bool, sampleWeight *mat.Dense) float64 {
+	if labels == nil {
+		labels = uniqueLabels(Ytrue)
+	}
+	nSamples, nScores := Yscore.Dims()
+	if len(labels) != nScores {
+		panic(fmt.Errorf("%d labels but Yscore has %d columns", len(labels), nScores))
+	}
+	index := make(map[float64]int, len(labels))
+	for l, label := range labels {
+		index[label] = l
+	}
+	N, D := 0., 0.
+	for i := 0; i < nSamples; i++ {
+		w := weight(sampleWeight, i)
+		l, ok := index[Ytrue.At(i, 0)]
+		if ok {
+			// count labels scoring strictly higher than the true one
+			higher := 0
+			for o := 0; o < nScores; o++ {
+				if Yscore.At(i, o) > Yscore.At(i, l) {
+					higher++
+				}
+			}
+			if higher < k {
+				N += w
+			}
+		}
+		D += w
+	}
+	if normalize {
+		return N / D
+	}
+	return N
+}
+
+// dcg returns the discounted cumulative gain of relevances yTrue ordered by decreasing yScore, up to rank k (k<=0 for all).
+// gains of tied scores are averaged
+func dcg(yTrue, yScore []float64, k int) float64 {
+	n := len(yScore)
+	if k <= 0 || k > n {
+		k = n
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return yScore[order[a]] > yScore[order[b]] })
+	sum := 0.
+	for start := 0; start < k; {
+		end := start + 1
+		for end < n && yScore[order[end]] == yScore[order[start]] {
+			end++
+		}
+		gain := 0.
+		for _, i := range order[start:end] {
+			gain += yTrue[i]
+		}
+		gain /= float64(end - start)
+		for r := start; r < end && r < k; r++ {
+			sum += gain / math.Log2(float64(r+2))
+		}
+		start = end
+	}
+	return sum
+}
+
+// DCGScore returns the (weighted) mean discounted cumulative gain of rows of Ytrue (relevances) ranked by Yscore, up to rank k (k<=0 for all)
+func DCGScore(Ytrue, Yscore mat.Matrix, k int, sampleWeight *mat.Dense) float64 {
+	nSamples, _ := Ytrue.Dims()
+	sum, W := 0., 0.
+	for i := 0; i < nSamples; i++ {
+		w := weight(sampleWeight, i)
+		sum += w * dcg(mat.Row(nil, i, Ytrue), mat.Row(nil, i, Yscore), k)
+		W += w
+	}
+	return sum / W
+}
+
+// NDCGScore returns the (weighted) mean of DCG normalized by the ideal DCG for each row. rows with no relevance score 0
+func NDCGScore(Ytrue, Yscore mat.Matrix, k int, sampleWeight *mat.Dense) float64 {
+	nSamples, _ := Ytrue.Dims()
+	sum, W := 0., 0.
+	for i := 0; i < nSamples; i++ {
+		w := weight(sampleWeight, i)
+		yTrue := mat.Row(nil, i, Ytrue)
+		sum += w * safeDiv(dcg(yTrue, mat.Row(nil, i, Yscore), k), dcg(yTrue, yTrue, k))
+		W += w
+	}
+	return sum / W
+}
+
+// LabelRankingAveragePrecisionScore is, for each relevant label of each sample of indicator Ytrue,
+// the fraction of labels ranked above it by Yscore that are relevant, averaged over labels and (weighted) samples
+func LabelRankingAveragePrecisionScore(Ytrue, Yscore mat.Matrix, sampleWeight *mat.Dense) float64 {
+	nSamples, nLabels := Ytrue.Dims()
+	sum, W := 0., 0.
+	for i := 0; i < nSamples; i++ {
+		w := weight(sampleWeight, i)
+		W += w
+		var relevant []int
+		for o := 0; o < nLabels; o++ {
+			if Ytrue.At(i, o) > 0 {
+				relevant = append(relevant, o)
+			}
+		}
+		if len(relevant) == 0 || len(relevant) == nLabels {
+			sum += w
+			continue
+		}
+		score := 0.
+		for _, j := range relevant {
+			rank, relevantRank := 0., 0.
+			for o := 0; o < nLabels; o++ {
+				if Yscore.At(i, o) >= Yscore.At(i, j) {
+					rank++
+					if Ytrue.At(i, o) > 0 {
+						relevantRank++
+					}
+				}
+			}
+			score += relevantRank / rank
+		}
+		sum += w * score / float64(len(relevant))
+	}
+	return sum / W
+}
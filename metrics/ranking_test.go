@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func ExampleRocCurve() {
+	yTrue, yScore := mat.NewDense(4, 1, []float64{0, 0, 1, 1}), mat.NewDense(4, 1, []float64{.1, .4, .35, .8})
+	fpr, tpr, thresholds := RocCurve(yTrue, yScore, 1, nil, true)
+	fmt.Println(fpr, tpr, thresholds)
+	fmt.Println(RocAucScore(yTrue, yScore, "", "", nil).At(0, 0))
+	// Output:
+	// [0 0 0.5 0.5 1] [0 0.5 0.5 1 1] [+Inf 0.8 0.4 0.35 0.1]
+	// 0.75
+}
+
+func ExamplePrecisionRecallCurve() {
+	yTrue, yScore := mat.NewDense(4, 1, []float64{0, 0, 1, 1}), mat.NewDense(4, 1, []float64{.1, .4, .35, .8})
+	precision, recall, thresholds := PrecisionRecallCurve(yTrue, yScore, 1, nil)
+	fmt.Printf("%.3f %.3f %.3f\n", precision, recall, thresholds)
+	fmt.Printf("%.4f\n", AveragePrecisionScore(yTrue, yScore, "", nil).At(0, 0))
+	// Output:
+	// [0.667 0.500 1.000 1.000] [1.000 0.500 0.500 0.000] [0.350 0.400 0.800]
+	// 0.8333
+}
+
+func TestRocAucMulti(t *testing.T) {
+	Ytrue := mat.NewDense(6, 1, []float64{0, 1, 2, 0, 1, 2})
+	Yscore := mat.NewDense(6, 3, []float64{.8, .1, .1, .2, .7, .1, .1, .2, .7, .6, .3, .1, .3, .6, .1, .2, .1, .7})
+	for _, multiClass := range []string{"ovr", "ovo"} {
+		for _, average := range []string{"macro", "weighted"} {
+			if auc := RocAucScore(Ytrue, Yscore, average, multiClass, nil).At(0, 0); auc != 1 {
+				t.Errorf("%s %s expected 1 got %g", multiClass, average, auc)
+			}
+		}
+	}
+	// multilabel with the same data is the one-vs-rest average
+	Yind := mat.NewDense(6, 3, nil)
+	for i := 0; i < 6; i++ {
+		Yind.Set(i, int(Ytrue.At(i, 0)), 1)
+	}
+	Yscore.Set(0, 1, .75)
+	perLabel := RocAucScore(Yind, Yscore, "", "", nil)
+	if !floats.Equal(perLabel.RawRowView(0), []float64{1, .75, 1}) {
+		t.Errorf("unexpected per-label AUC %g", perLabel.RawRowView(0))
+	}
+	ovr := RocAucScore(Ytrue, Yscore, "macro", "ovr", nil).At(0, 0)
+	if math.Abs(ovr-2.75/3) > 1e-12 {
+		t.Errorf("ovr expected %g got %g", 2.75/3, ovr)
+	}
+}
+
+func TestRankingSingleClass(t *testing.T) {
+	ones, zeros, yScore := mat.NewDense(3, 1, []float64{1, 1, 1}), mat.NewDense(3, 1, nil), mat.NewDense(3, 1, []float64{.2, .5, .9})
+	for name, test := range map[string]struct {
+		f       func()
+		message string
+	}{
+		"roc without negatives":   {func() { RocCurve(ones, yScore, 1, nil, false) }, "no negative sample"},
+		"roc without positives":   {func() { RocCurve(zeros, yScore, 1, nil, false) }, "no positive sample"},
+		"auc with a single class": {func() { RocAucScore(ones, yScore, "", "", nil) }, "no negative sample"},
+		"pr without positives":    {func() { PrecisionRecallCurve(zeros, yScore, 1, nil) }, "no positive sample"},
+	} {
+		func() {
+			defer func() {
+				if err, ok := recover().(error); !ok || !strings.Contains(err.Error(), test.message) {
+					t.Errorf("%s: expected a %q panic, got %v", name, test.message, err)
+				}
+			}()
+			test.f()
+		}()
+	}
+	// precision is defined without negatives
+	if precision, _, _ := PrecisionRecallCurve(ones, yScore, 1, nil); precision[0] != 1 {
+		t.Errorf("expected precision 1 got %g", precision[0])
+	}
+}
+
+func TestProbabilisticLosses(t *testing.T) {
+	// expected values from sklearn.metrics
+	Ytrue := mat.NewDense(4, 1, []float64{1, 0, 0, 1})
+	Ypred := mat.NewDense(4, 2, []float64{.1, .9, .9, .1, .8, .2, .35, .65})
+	if l := LogLoss(Ytrue, Ypred, nil, 0, true, nil); math.Abs(l-0.21616187) > 1e-8 {
+		t.Errorf("LogLoss expected %g got %g", 0.21616187, l)
+	}
+	if l := LogLoss(Ytrue, mat.NewDense(4, 1, mat.Col(nil, 1, Ypred)), nil, 0, true, nil); math.Abs(l-0.21616187) > 1e-8 {
+		t.Errorf("LogLoss binary expected %g got %g", 0.21616187, l)
+	}
+	// an all-ones Ytrue needs labels to know 1 is the positive label
+	ones, proba := mat.NewDense(3, 1, []float64{1, 1, 1}), mat.NewDense(3, 1, []float64{.9, .8, .7})
+	if l, expected := LogLoss(ones, proba, []float64{0, 1}, 0, true, nil), -math.Log(.9*.8*.7)/3; math.Abs(l-expected) > 1e-12 {
+		t.Errorf("LogLoss all ones expected %g got %g", expected, l)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("LogLoss should panic for a single label Ytrue without labels")
+			}
+		}()
+		LogLoss(ones, proba, nil, 0, true, nil)
+	}()
+	brier := BrierScoreLoss(mat.NewDense(4, 1, []float64{0, 1, 1, 0}), mat.NewDense(4, 1, []float64{.1, .9, .8, .3}), 1, nil)
+	if math.Abs(brier-.0375) > 1e-12 {
+		t.Errorf("BrierScoreLoss expected %g got %g", .0375, brier)
+	}
+	Ytrue = mat.NewDense(4, 1, []float64{0, 1, 2, 2})
+	Yscore := mat.NewDense(4, 3, []float64{.5, .2, .2, .3, .4, .2, .2, .4, .3, .7, .2, .1})
+	if acc := TopKAccuracyScore(Ytrue, Yscore, 2, nil, true, nil); acc != .75 {
+		t.Errorf("TopKAccuracyScore expected %g got %g", .75, acc)
+	}
+}
+
+func TestRankingScores(t *testing.T) {
+	// expected values from sklearn.metrics
+	Ytrue, Yscore := mat.NewDense(1, 5, []float64{10, 0, 0, 1, 5}), mat.NewDense(1, 5, []float64{.1, .2, .3, 4, 70})
+	if dcg := DCGScore(Ytrue, Yscore, 0, nil); math.Abs(dcg-9.4995) > 1e-4 {
+		t.Errorf("DCGScore expected %g got %g", 9.4995, dcg)
+	}
+	if ndcg := NDCGScore(Ytrue, Yscore, 0, nil); math.Abs(ndcg-0.69569) > 1e-5 {
+		t.Errorf("NDCGScore expected %g got %g", 0.69569, ndcg)
+	}
+	// ties are averaged
+	if ndcg := NDCGScore(Ytrue, mat.NewDense(1, 5, []float64{1, 0, 0, 0, 1}), 1, nil); math.Abs(ndcg-.75) > 1e-12 {
+		t.Errorf("NDCGScore with ties expected %g got %g", .75, ndcg)
+	}
+	Ytrue, Yscore = mat.NewDense(2, 3, []float64{1, 0, 0, 0, 0, 1}), mat.NewDense(2, 3, []float64{.75, .5, 1, 1, .2, .1})
+	if lrap := LabelRankingAveragePrecisionScore(Ytrue, Yscore, nil); math.Abs(lrap-5./12) > 1e-12 {
+		t.Errorf("LabelRankingAveragePrecisionScore expected %g got %g", 5./12, lrap)
+	}
+}
@@ -1,11 +1,13 @@
 package metrics
 
 import (
-	//	"fmt"
+	"fmt"
 	"math"
+	"sort"
 
 	"github.com/gcla/sklearn/base"
 
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -112,19 +114,7 @@ func R2Score(yTrue, yPred *mat.Dense, sampleWeight *mat.Dense, multioutput strin
 		d := math.Max(denominator.At(i, j), 1e-20)
 		return 1. - numerator.At(i, j)/d
 	}, r2score)
-	switch multioutput {
-	case "raw_values":
-		return r2score
-	case "variance_weighted":
-		r2 := mat.NewDense(1, 1, nil)
-		r2.Mul(denominator, r2score.T())
-		sumden := mat.Sum(denominator)
-		r2.Scale(1./sumden, r2)
-		return r2
-	default: // "uniform_average":
-		return mat.NewDense(1, 1, []float64{mat.Sum(r2score) / float64(nOutputs)})
-	}
-
+	return multioutputAverage(r2score, multioutput, denominator.RawRowView(0))
 }
 
 // MeanSquaredError regression loss
@@ -151,28 +141,8 @@ func R2Score(yTrue, yPred *mat.Dense, sampleWeight *mat.Dense, multioutput strin
 //     A non-negative floating point value (the best value is 0.0), or an
 //     array of floating point values, one for each individual target.
 func MeanSquaredError(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
-	nSamples, nOutputs := yTrue.Dims()
-	tmp := mat.NewDense(1, nOutputs, nil)
-
-	tmp.Apply(func(_ int, j int, v float64) float64 {
-		N, D := 0., 0.
-		for i := 0; i < nSamples; i++ {
-			ydiff := yPred.At(i, j) - yTrue.At(i, j)
-			w := 1.
-			if sampleWeight != nil {
-				w = sampleWeight.At(0, j)
-			}
-			N += w * (ydiff * ydiff)
-			D += w
-		}
-		return N / D
-	}, tmp)
-	switch multioutput {
-	case "raw_values":
-		return tmp
-	default: // "uniform_average":
-		return mat.NewDense(1, 1, []float64{mat.Sum(tmp) / float64(nOutputs)})
-	}
+	errors := meanLoss(yTrue, yPred, sampleWeight, func(yt, yp float64) float64 { return (yp - yt) * (yp - yt) })
+	return multioutputAverage(errors, multioutput, outputVariances(yTrue, sampleWeight))
 }
 
 // MeanAbsoluteError regression loss
@@ -218,27 +188,257 @@ func MeanSquaredError(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multiout
 // ... # doctest: +ELLIPSIS
 // 0.849...
 func MeanAbsoluteError(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	errors := meanLoss(yTrue, yPred, sampleWeight, func(yt, yp float64) float64 { return math.Abs(yp - yt) })
+	return multioutputAverage(errors, multioutput, outputVariances(yTrue, sampleWeight))
+}
+
+// multioutputAverage aggregates the 1×nOutputs values of a regression metric.
+// multioutput is "raw_values", "variance_weighted" or "uniform_average" (the default).
+// variances are the weights used by "variance_weighted"
+func multioutputAverage(values *mat.Dense, multioutput string, variances []float64) *mat.Dense {
+	switch multioutput {
+	case "raw_values":
+		return values
+	case "variance_weighted":
+		if floats.Sum(variances) != 0 {
+			return MultioutputWeighted(values, variances)
+		}
+		// constant outputs: fall back to uniform average
+	}
+	raw := values.RawRowView(0)
+	return mat.NewDense(1, 1, []float64{floats.Sum(raw) / float64(len(raw))})
+}
+
+// MultioutputWeighted averages the "raw_values" of a regression metric with outputWeights,
+// like scikit-learn multioutput array-like, e.g. MultioutputWeighted(MeanAbsoluteError(yTrue, yPred, nil, "raw_values"), []float64{.3, .7})
+func MultioutputWeighted(rawValues *mat.Dense, outputWeights []float64) *mat.Dense {
+	raw := rawValues.RawRowView(0)
+	if len(outputWeights) != len(raw) {
+		panic(fmt.Errorf("there must be one multioutput weight per output. expected %d got %d", len(raw), len(outputWeights)))
+	}
+	return mat.NewDense(1, 1, []float64{floats.Dot(raw, outputWeights) / floats.Sum(outputWeights)})
+}
+
+// outputVariances returns the weighted variance of each column of yTrue
+func outputVariances(yTrue mat.Matrix, sampleWeight *mat.Dense) []float64 {
 	nSamples, nOutputs := yTrue.Dims()
-	tmp := mat.NewDense(1, nOutputs, nil)
+	variances := make([]float64, nOutputs)
+	for j := range variances {
+		sum, sumw := 0., 0.
+		for i := 0; i < nSamples; i++ {
+			w := weight(sampleWeight, i)
+			sum += w * yTrue.At(i, j)
+			sumw += w
+		}
+		mean := sum / sumw
+		for i := 0; i < nSamples; i++ {
+			d := yTrue.At(i, j) - mean
+			variances[j] += weight(sampleWeight, i) * d * d / sumw
+		}
+	}
+	return variances
+}
 
-	tmp.Apply(func(_ int, j int, v float64) float64 {
+// meanLoss returns the sampleWeight weighted mean of loss for each output
+func meanLoss(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, loss func(yt, yp float64) float64) *mat.Dense {
+	nSamples, nOutputs := yTrue.Dims()
+	if r, c := yPred.Dims(); r != nSamples || c != nOutputs {
+		panic(fmt.Errorf("yTrue and yPred have different shapes %dx%d and %dx%d", nSamples, nOutputs, r, c))
+	}
+	losses := mat.NewDense(1, nOutputs, nil)
+	for j := 0; j < nOutputs; j++ {
 		N, D := 0., 0.
 		for i := 0; i < nSamples; i++ {
-			ydiff := yPred.At(i, j) - yTrue.At(i, j)
-			w := 1.
-			if sampleWeight != nil {
-				w = sampleWeight.At(0, j)
-			}
-			N += w * math.Abs(ydiff)
+			w := weight(sampleWeight, i)
+			N += w * loss(yTrue.At(i, j), yPred.At(i, j))
 			D += w
 		}
-		return N / D
-	}, tmp)
+		losses.Set(0, j, N/D)
+	}
+	return losses
+}
 
-	switch multioutput {
-	case "raw_values":
-		return tmp
-	default: // "uniform_average":
-		return mat.NewDense(1, 1, []float64{mat.Sum(tmp) / float64(nOutputs)})
+// ExplainedVarianceScore is 1 - Var(yTrue-yPred)/Var(yTrue). best possible score is 1
+func ExplainedVarianceScore(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	nSamples, nOutputs := yTrue.Dims()
+	diff := mat.NewDense(nSamples, nOutputs, nil)
+	diff.Sub(yTrue, yPred)
+	numerator, denominator := outputVariances(diff, sampleWeight), outputVariances(yTrue, sampleWeight)
+	scores := mat.NewDense(1, nOutputs, nil)
+	for j := 0; j < nOutputs; j++ {
+		switch {
+		case denominator[j] != 0:
+			scores.Set(0, j, 1-numerator[j]/denominator[j])
+		case numerator[j] != 0:
+			// arbitrary set to zero to avoid -inf scores for constant yTrue
+			scores.Set(0, j, 0)
+		default:
+			scores.Set(0, j, 1)
+		}
+	}
+	return multioutputAverage(scores, multioutput, denominator)
+}
+
+// RootMeanSquaredError is the square root of MeanSquaredError, computed for each output before averaging
+func RootMeanSquaredError(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	errors := MeanSquaredError(yTrue, yPred, sampleWeight, "raw_values")
+	errors.Apply(func(_, _ int, v float64) float64 { return math.Sqrt(v) }, errors)
+	return multioutputAverage(errors, multioutput, outputVariances(yTrue, sampleWeight))
+}
+
+// MeanSquaredLogError is the mean of (log(1+yTrue)-log(1+yPred))². it panics if yTrue or yPred contains negative values
+func MeanSquaredLogError(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	errors := meanLoss(yTrue, yPred, sampleWeight, func(yt, yp float64) float64 {
+		if yt < 0 || yp < 0 {
+			panic("MeanSquaredLogError cannot be used when targets contain negative values")
+		}
+		d := math.Log1p(yt) - math.Log1p(yp)
+		return d * d
+	})
+	return multioutputAverage(errors, multioutput, outputVariances(yTrue, sampleWeight))
+}
+
+// MeanAbsolutePercentageError is the mean of |yTrue-yPred|/|yTrue|. it is not multiplied by 100.
+// |yTrue| is bounded below by machine epsilon, so the loss is huge but finite where yTrue is 0
+func MeanAbsolutePercentageError(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	const eps = 0x1p-52
+	errors := meanLoss(yTrue, yPred, sampleWeight, func(yt, yp float64) float64 {
+		return math.Abs(yp-yt) / math.Max(math.Abs(yt), eps)
+	})
+	return multioutputAverage(errors, multioutput, outputVariances(yTrue, sampleWeight))
+}
+
+// MedianAbsoluteError is the median of |yTrue-yPred|. it is robust to outliers.
+// with sampleWeight, it's the weighted 50th percentile of the absolute errors
+func MedianAbsoluteError(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	nSamples, nOutputs := yTrue.Dims()
+	errors := mat.NewDense(1, nOutputs, nil)
+	absErr := make([]float64, nSamples)
+	for j := 0; j < nOutputs; j++ {
+		for i := range absErr {
+			absErr[i] = math.Abs(yPred.At(i, j) - yTrue.At(i, j))
+		}
+		if sampleWeight == nil {
+			errors.Set(0, j, median(absErr))
+		} else {
+			errors.Set(0, j, weightedPercentile(absErr, sampleWeight, 50))
+		}
+	}
+	return multioutputAverage(errors, multioutput, outputVariances(yTrue, sampleWeight))
+}
+
+// median returns the median of x. x is sorted in place
+func median(x []float64) float64 {
+	sort.Float64s(x)
+	n := len(x)
+	if n%2 == 1 {
+		return x[n/2]
+	}
+	return (x[n/2-1] + x[n/2]) / 2
+}
+
+// weightedPercentile returns the smallest value of x for which the cumulated weight reaches percentile % of the total weight
+func weightedPercentile(x []float64, sampleWeight *mat.Dense, percentile float64) float64 {
+	idx := make([]int, len(x))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return x[idx[a]] < x[idx[b]] })
+	total := 0.
+	for i := range x {
+		total += weight(sampleWeight, i)
+	}
+	cum := 0.
+	for _, i := range idx {
+		cum += weight(sampleWeight, i)
+		if cum >= percentile/100*total {
+			return x[i]
+		}
+	}
+	return x[idx[len(idx)-1]]
+}
+
+// MaxError is the maximum absolute residual of each output. samples with a zero sampleWeight are ignored
+func MaxError(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	nSamples, nOutputs := yTrue.Dims()
+	errors := mat.NewDense(1, nOutputs, nil)
+	for j := 0; j < nOutputs; j++ {
+		for i := 0; i < nSamples; i++ {
+			if weight(sampleWeight, i) != 0 {
+				errors.Set(0, j, math.Max(errors.At(0, j), math.Abs(yPred.At(i, j)-yTrue.At(i, j))))
+			}
+		}
+	}
+	return multioutputAverage(errors, multioutput, outputVariances(yTrue, sampleWeight))
+}
+
+// MeanTweedieDeviance is the mean Tweedie deviance of yPred with the given power:
+// power 0 is the normal distribution (squared error), 1 Poisson, 2 Gamma, 3 inverse Gaussian, 1<power<2 compound Poisson-Gamma.
+// power in (0,1) is not a valid distribution. yPred must be strictly positive for power>0 (any value for power=0);
+// yTrue must be non negative for 1<=power<2 and strictly positive for power>=2
+func MeanTweedieDeviance(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string, power float64) *mat.Dense {
+	if power > 0 && power < 1 {
+		panic(fmt.Errorf("Tweedie deviance is only defined for power<=0 and power>=1, got %g", power))
+	}
+	check := func(yt, yp float64) {
+		var ok bool
+		switch {
+		case power < 0:
+			ok = yp > 0
+		case power == 0:
+			ok = true
+		case power < 2:
+			ok = yt >= 0 && yp > 0
+		default:
+			ok = yt > 0 && yp > 0
+		}
+		if !ok {
+			panic(fmt.Errorf("Tweedie deviance with power=%g is undefined for yTrue=%g and yPred=%g", power, yt, yp))
+		}
 	}
+	deviances := meanLoss(yTrue, yPred, sampleWeight, func(yt, yp float64) float64 {
+		check(yt, yp)
+		switch power {
+		case 0:
+			return (yt - yp) * (yt - yp)
+		case 1:
+			dev := yp - yt
+			if yt > 0 {
+				dev += yt * math.Log(yt/yp)
+			}
+			return 2 * dev
+		case 2:
+			return 2 * (math.Log(yp/yt) + yt/yp - 1)
+		default:
+			return 2 * (math.Pow(math.Max(yt, 0), 2-power)/((1-power)*(2-power)) -
+				yt*math.Pow(yp, 1-power)/(1-power) +
+				math.Pow(yp, 2-power)/(2-power))
+		}
+	})
+	return multioutputAverage(deviances, multioutput, outputVariances(yTrue, sampleWeight))
+}
+
+// MeanPoissonDeviance is MeanTweedieDeviance with power 1
+func MeanPoissonDeviance(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	return MeanTweedieDeviance(yTrue, yPred, sampleWeight, multioutput, 1)
+}
+
+// MeanGammaDeviance is MeanTweedieDeviance with power 2
+func MeanGammaDeviance(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense {
+	return MeanTweedieDeviance(yTrue, yPred, sampleWeight, multioutput, 2)
+}
+
+// MeanPinballLoss is the quantile regression loss for quantile alpha in [0,1]:
+// alpha*(yTrue-yPred) where yTrue>=yPred, else (1-alpha)*(yPred-yTrue). alpha=.5 gives half the MeanAbsoluteError
+func MeanPinballLoss(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string, alpha float64) *mat.Dense {
+	if alpha < 0 || alpha > 1 {
+		panic(fmt.Errorf("alpha must be in [0,1], got %g", alpha))
+	}
+	losses := meanLoss(yTrue, yPred, sampleWeight, func(yt, yp float64) float64 {
+		if yt >= yp {
+			return alpha * (yt - yp)
+		}
+		return (1 - alpha) * (yp - yt)
+	})
+	return multioutputAverage(losses, multioutput, outputVariances(yTrue, sampleWeight))
 }
@@ -1,8 +1,13 @@
 package metrics
 
-import "testing"
-import "math"
-import "gonum.org/v1/gonum/mat"
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
 
 func TestR2Score(t *testing.T) {
 	//1st example of sklearn metrics r2score
@@ -48,20 +53,20 @@ func TestR2Score(t *testing.T) {
 // """
 
 func TestExplainedVarianceScore(t *testing.T) {
-	//1st example of sklearn metrics r2score
-	// yTrue := mat.NewDense(4, 1, []float64{3, -0.5, 2, 7})
-	// yPred := mat.NewDense(4, 1, []float64{2.5, 0.0, 2, 8})
-	// Score := ExplainedVariancescore(yTrue, yPred, nil, "")
-	// eps := 1e-3
-	// if math.Abs(0.957-Score.At(0, 0)) > eps {
-	// 	t.Error("expected 0.957")
-	// }
-	// yTrue = mat.NewDense(3, 2, []float64{0.5, 1, -1, 1, 7, -6})
-	// yPred = mat.NewDense(3, 2, []float64{0, 2, -1, 2, 8, -5})
-	// Score = ExplainedVariancescore(yTrue, yPred, nil, "").At(0, 0)
-	// if math.Abs(0.983-Score) >= 1e-3 {
-	// 	t.Errorf("%g expected 0.983", Score)
-	// }
+	//1st example of sklearn metrics explained_variance_score
+	yTrue := mat.NewDense(4, 1, []float64{3, -0.5, 2, 7})
+	yPred := mat.NewDense(4, 1, []float64{2.5, 0.0, 2, 8})
+	Score := ExplainedVarianceScore(yTrue, yPred, nil, "")
+	eps := 1e-3
+	if math.Abs(0.957-Score.At(0, 0)) > eps {
+		t.Error("expected 0.957")
+	}
+	yTrue = mat.NewDense(3, 2, []float64{0.5, 1, -1, 1, 7, -6})
+	yPred = mat.NewDense(3, 2, []float64{0, 2, -1, 2, 8, -5})
+	score := ExplainedVarianceScore(yTrue, yPred, nil, "").At(0, 0)
+	if math.Abs(0.983-score) >= 1e-3 {
+		t.Errorf("%g expected 0.983", score)
+	}
 }
 
 // >>> from sklearn.metrics import mean_squared_error
@@ -123,3 +128,101 @@ func TestMeanAbsoluteError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSampleWeight(t *testing.T) {
+	yTrue := mat.NewDense(3, 1, []float64{1, 2, 3})
+	yPred := mat.NewDense(3, 1, []float64{2, 2, 5})
+	sampleWeight := mat.NewDense(3, 1, []float64{1, 1, 2})
+	if mse := MeanSquaredError(yTrue, yPred, sampleWeight, "").At(0, 0); mse != 9./4 {
+		t.Errorf("MeanSquaredError expected %g got %g", 9./4, mse)
+	}
+	if mae := MeanAbsoluteError(yTrue, yPred, sampleWeight, "").At(0, 0); mae != 5./4 {
+		t.Errorf("MeanAbsoluteError expected %g got %g", 5./4, mae)
+	}
+	if medae := MedianAbsoluteError(yTrue, yPred, sampleWeight, "").At(0, 0); medae != 1 {
+		t.Errorf("MedianAbsoluteError expected %g got %g", 1., medae)
+	}
+	if maxe := MaxError(yTrue, yPred, mat.NewDense(3, 1, []float64{1, 1, 0}), "").At(0, 0); maxe != 1 {
+		t.Errorf("MaxError expected %g got %g", 1., maxe)
+	}
+}
+
+func TestRegressionLosses(t *testing.T) {
+	// expected values from sklearn.metrics docstrings
+	yTrue := mat.NewDense(4, 1, []float64{3, -0.5, 2, 7})
+	yPred := mat.NewDense(4, 1, []float64{2.5, 0.0, 2, 8})
+	YTrue := mat.NewDense(3, 2, []float64{0.5, 1, -1, 1, 7, -6})
+	YPred := mat.NewDense(3, 2, []float64{0, 2, -1, 2, 8, -5})
+	type metric func(yTrue, yPred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense
+	for _, tc := range []struct {
+		name             string
+		metric           metric
+		yTrue, yPred     *mat.Dense
+		multioutput      string
+		outputWeights    []float64
+		expected, within float64
+	}{
+		{"MedianAbsoluteError", MedianAbsoluteError, yTrue, yPred, "", nil, .5, 1e-12},
+		{"MedianAbsoluteError", MedianAbsoluteError, YTrue, YPred, "", nil, .75, 1e-12},
+		{"MedianAbsoluteError", MedianAbsoluteError, YTrue, YPred, "raw_values", []float64{.3, .7}, .85, 1e-12},
+		{"MeanAbsoluteError", MeanAbsoluteError, YTrue, YPred, "raw_values", []float64{.3, .7}, .85, 1e-12},
+		{"MeanSquaredError", MeanSquaredError, YTrue, YPred, "raw_values", []float64{.3, .7}, .825, 1e-3},
+		{"MeanAbsolutePercentageError", MeanAbsolutePercentageError, yTrue, yPred, "", nil, .3273, 1e-4},
+		{"MeanAbsolutePercentageError", MeanAbsolutePercentageError, YTrue, YPred, "", nil, .5515, 1e-4},
+		{"MeanAbsolutePercentageError", MeanAbsolutePercentageError, YTrue, YPred, "raw_values", []float64{.3, .7}, .6198, 1e-4},
+		{"MaxError", MaxError, mat.NewDense(4, 1, []float64{3, 2, 7, 1}), mat.NewDense(4, 1, []float64{4, 2, 7, 1}), "", nil, 1, 0},
+		{"MeanSquaredLogError", MeanSquaredLogError, mat.NewDense(4, 1, []float64{3, 5, 2.5, 7}), mat.NewDense(4, 1, []float64{2.5, 5, 4, 8}), "", nil, .039, 1e-3},
+		{"MeanSquaredLogError", MeanSquaredLogError, mat.NewDense(3, 2, []float64{.5, 1, 1, 2, 7, 6}), mat.NewDense(3, 2, []float64{.5, 2, 1, 2.5, 8, 8}), "", nil, .044, 1e-3},
+		{"MeanSquaredLogError", MeanSquaredLogError, mat.NewDense(3, 2, []float64{.5, 1, 1, 2, 7, 6}), mat.NewDense(3, 2, []float64{.5, 2, 1, 2.5, 8, 8}), "raw_values", []float64{.3, .7}, .060, 1e-3},
+		{"RootMeanSquaredError", RootMeanSquaredError, yTrue, yPred, "", nil, .612, 1e-3},
+		{"RootMeanSquaredError", RootMeanSquaredError, YTrue, YPred, "", nil, .822, 1e-3},
+		{"MeanPoissonDeviance", MeanPoissonDeviance, mat.NewDense(4, 1, []float64{2, 0, 1, 4}), mat.NewDense(4, 1, []float64{.5, .5, 2, 2}), "", nil, 1.4260, 1e-4},
+		{"MeanGammaDeviance", MeanGammaDeviance, mat.NewDense(4, 1, []float64{2, .5, 1, 4}), mat.NewDense(4, 1, []float64{.5, .5, 2, 2}), "", nil, 1.0568, 1e-4},
+		{"MeanSquaredError", MeanSquaredError, YTrue, YPred, "variance_weighted", nil, (5./12*(108.5/9) + 98./9) / ((108.5 + 98.) / 9), 1e-12},
+	} {
+		got := tc.metric(tc.yTrue, tc.yPred, nil, tc.multioutput)
+		if tc.outputWeights != nil {
+			got = MultioutputWeighted(got, tc.outputWeights)
+		}
+		if math.Abs(got.At(0, 0)-tc.expected) > tc.within {
+			t.Errorf("%s %s%v expected %g got %g", tc.name, tc.multioutput, tc.outputWeights, tc.expected, got.At(0, 0))
+		}
+	}
+	yTrue, yPred = mat.NewDense(4, 1, []float64{2, 0, 1, 4}), mat.NewDense(4, 1, []float64{.5, .5, 2, 2})
+	for _, power := range []float64{0, 1, 2} {
+		var expected float64
+		switch power {
+		case 0:
+			expected = MeanSquaredError(yTrue, yPred, nil, "").At(0, 0)
+		case 1:
+			expected = MeanPoissonDeviance(yTrue, yPred, nil, "").At(0, 0)
+		case 2:
+			yTrue.Set(1, 0, .5)
+			expected = MeanGammaDeviance(yTrue, yPred, nil, "").At(0, 0)
+		}
+		// the general formula must agree with the special cases
+		nearPower := power + 1e-9
+		if power == 0 {
+			nearPower = -1e-9
+		}
+		got := MeanTweedieDeviance(yTrue, yPred, nil, "", nearPower).At(0, 0)
+		if math.Abs(got-expected) > 1e-6 {
+			t.Errorf("MeanTweedieDeviance power=%g expected %g got %g", power, expected, got)
+		}
+	}
+}
+
+func ExampleMeanPinballLoss() {
+	yTrue := mat.NewDense(3, 1, []float64{1, 2, 3})
+	for _, alpha := range []float64{.1, .9} {
+		fmt.Printf("%.4f %.4f\n",
+			MeanPinballLoss(yTrue, mat.NewDense(3, 1, []float64{0, 2, 3}), nil, "", alpha).At(0, 0),
+			MeanPinballLoss(yTrue, mat.NewDense(3, 1, []float64{1, 2, 4}), nil, "", alpha).At(0, 0))
+	}
+	raw := MeanPinballLoss(mat.NewDense(2, 2, []float64{1, 0, 2, 0}), mat.NewDense(2, 2, []float64{0, 1, 2, 1}), nil, "raw_values", .25)
+	fmt.Println(floats.Equal(raw.RawRowView(0), []float64{.125, .75}))
+	// Output:
+	// 0.0333 0.3000
+	// 0.3000 0.0333
+	// true
+}
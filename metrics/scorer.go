@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Scorer scores a fitted estimator on X and Y. scores are always greater-is-better: losses are negated
+type Scorer struct {
+	Name string
+	// Metric computes a score or a loss from true Y and the estimator response
+	Metric          func(Ytrue, Ypred *mat.Dense) float64
+	GreaterIsBetter bool
+	// ResponseMethod is the estimator method giving the Metric input: "predict" (default), "predict_proba" or "decision_function".
+	// "predict_proba" and "decision_function" fall back on each other, then on Predict
+	ResponseMethod string
+}
+
+// MakeScorer returns a *Scorer for a custom metric. see Scorer for responseMethod
+func MakeScorer(name string, metric func(Ytrue, Ypred *mat.Dense) float64, greaterIsBetter bool, responseMethod string) *Scorer {
+	switch responseMethod {
+	case "", "predict", "predict_proba", "decision_function":
+	default:
+		panic(fmt.Errorf("unknown responseMethod %s", responseMethod))
+	}
+	return &Scorer{Name: name, Metric: metric, GreaterIsBetter: greaterIsBetter, ResponseMethod: responseMethod}
+}
+
+// ScorePredictions returns Metric(Ytrue, Ypred), negated if !GreaterIsBetter
+func (s *Scorer) ScorePredictions(Ytrue, Ypred *mat.Dense) float64 {
+	score := s.Metric(Ytrue, Ypred)
+	if !s.GreaterIsBetter {
+		score = -score
+	}
+	return score
+}
+
+// Score returns the score of a fitted estimator on X,Y.
+// estimator must have a Predict(X, Y *mat.Dense) method, with or without a return value, a PredictProba or a DecisionFunction method
+func (s *Scorer) Score(estimator interface{}, X, Y *mat.Dense) float64 {
+	nSamples, _ := X.Dims()
+	_, nOutputs := Y.Dims()
+	Ypred := mat.NewDense(nSamples, nOutputs, nil)
+	Response(estimator, s.ResponseMethod, X, Ypred)
+	return s.ScorePredictions(Y, Ypred)
+}
+
+type predicter interface {
+	Predict(X, Y *mat.Dense)
+}
+type regressorPredicter interface {
+	Predict(X, Y *mat.Dense) base.Regressor
+}
+type probaPredicter interface {
+	PredictProba(X mat.Matrix, Y *mat.Dense)
+}
+type decisionFunctioner interface {
+	DecisionFunction(X mat.Matrix, Y *mat.Dense)
+}
+
+// Response fills Ypred with the output of the estimator method named by responseMethod, or its fallbacks. see Scorer
+func Response(estimator interface{}, responseMethod string, X, Ypred *mat.Dense) {
+	methods := []string{"predict", "decision_function"}
+	switch responseMethod {
+	case "predict_proba":
+		methods = []string{"predict_proba", "decision_function", "predict"}
+	case "decision_function":
+		methods = []string{"decision_function", "predict_proba", "predict"}
+	}
+	for _, method := range methods {
+		switch method {
+		case "predict_proba":
+			if e, ok := estimator.(probaPredicter); ok {
+				e.PredictProba(X, Ypred)
+				return
+			}
+		case "decision_function":
+			if e, ok := estimator.(decisionFunctioner); ok {
+				e.DecisionFunction(X, Ypred)
+				return
+			}
+		default:
+			switch e := estimator.(type) {
+			case predicter:
+				e.Predict(X, Ypred)
+				return
+			case regressorPredicter:
+				e.Predict(X, Ypred)
+				return
+			}
+		}
+	}
+	panic(fmt.Errorf("%T has no method for response %s", estimator, responseMethod))
+}
+
+// uniformAverage returns the uniform average of a regression metric over outputs
+func uniformAverage(metric func(Ytrue, Ypred mat.Matrix, sampleWeight *mat.Dense, multioutput string) *mat.Dense) func(Ytrue, Ypred *mat.Dense) float64 {
+	return func(Ytrue, Ypred *mat.Dense) float64 { return metric(Ytrue, Ypred, nil, "").At(0, 0) }
+}
+
+// prfScore returns the averaged precision (which=0), recall (1) or F1 score (2)
+func prfScore(which int, average string) func(Ytrue, Ypred *mat.Dense) float64 {
+	return func(Ytrue, Ypred *mat.Dense) float64 {
+		p, r, f, _ := PrecisionRecallFScoreSupport(Ytrue, Ypred, 1, nil, average, nil)
+		return [...]*mat.Dense{p, r, f}[which].At(0, 0)
+	}
+}
+
+func rocAuc(average, multiClass string) func(Ytrue, Ypred *mat.Dense) float64 {
+	return func(Ytrue, Yscore *mat.Dense) float64 {
+		return RocAucScore(Ytrue, Yscore, average, multiClass, nil).At(0, 0)
+	}
+}
+
+func clusterScore(metric func(labelsTrue, labelsPred mat.Matrix) float64) func(Ytrue, Ypred *mat.Dense) float64 {
+	return func(Ytrue, Ypred *mat.Dense) float64 { return metric(Ytrue, Ypred) }
+}
+
+// Scorers is the registry of scorers by name. it can be extended with MakeScorer
+var Scorers = map[string]*Scorer{
+	// regression
+	"explained_variance":                 {Metric: uniformAverage(ExplainedVarianceScore), GreaterIsBetter: true},
+	"r2":                                 {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return R2Score(Ytrue, Ypred, nil, "").At(0, 0) }, GreaterIsBetter: true},
+	"max_error":                          {Metric: uniformAverage(MaxError)},
+	"neg_mean_absolute_error":            {Metric: uniformAverage(MeanAbsoluteError)},
+	"neg_mean_squared_error":             {Metric: uniformAverage(MeanSquaredError)},
+	"neg_root_mean_squared_error":        {Metric: uniformAverage(RootMeanSquaredError)},
+	"neg_mean_squared_log_error":         {Metric: uniformAverage(MeanSquaredLogError)},
+	"neg_median_absolute_error":          {Metric: uniformAverage(MedianAbsoluteError)},
+	"neg_mean_absolute_percentage_error": {Metric: uniformAverage(MeanAbsolutePercentageError)},
+	"neg_mean_poisson_deviance":          {Metric: uniformAverage(MeanPoissonDeviance)},
+	"neg_mean_gamma_deviance":            {Metric: uniformAverage(MeanGammaDeviance)},
+	// classification
+	"accuracy": {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return AccuracyScore(Ytrue, Ypred, true, nil) }, GreaterIsBetter: true},
+	"balanced_accuracy": {Metric: func(Ytrue, Ypred *mat.Dense) float64 {
+		return BalancedAccuracyScore(Ytrue, Ypred, nil, false)
+	}, GreaterIsBetter: true},
+	"precision":            {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return PrecisionScore(Ytrue, Ypred) }, GreaterIsBetter: true},
+	"recall":               {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return RecallScore(Ytrue, Ypred) }, GreaterIsBetter: true},
+	"f1":                   {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return F1Score(Ytrue, Ypred) }, GreaterIsBetter: true},
+	"matthews_corrcoef":    {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return MatthewsCorrcoef(Ytrue, Ypred, nil) }, GreaterIsBetter: true},
+	"roc_auc":              {Metric: rocAuc("macro", ""), GreaterIsBetter: true, ResponseMethod: "decision_function"},
+	"roc_auc_ovr":          {Metric: rocAuc("macro", "ovr"), GreaterIsBetter: true, ResponseMethod: "predict_proba"},
+	"roc_auc_ovo":          {Metric: rocAuc("macro", "ovo"), GreaterIsBetter: true, ResponseMethod: "predict_proba"},
+	"roc_auc_ovr_weighted": {Metric: rocAuc("weighted", "ovr"), GreaterIsBetter: true, ResponseMethod: "predict_proba"},
+	"roc_auc_ovo_weighted": {Metric: rocAuc("weighted", "ovo"), GreaterIsBetter: true, ResponseMethod: "predict_proba"},
+	"average_precision": {Metric: func(Ytrue, Yscore *mat.Dense) float64 {
+		return AveragePrecisionScore(Ytrue, Yscore, "macro", nil).At(0, 0)
+	}, GreaterIsBetter: true, ResponseMethod: "decision_function"},
+	"neg_log_loss": {Metric: func(Ytrue, Yprob *mat.Dense) float64 {
+		return LogLoss(Ytrue, Yprob, nil, 0, true, nil)
+	}, ResponseMethod: "predict_proba"},
+	"neg_brier_score": {Metric: func(Ytrue, Yprob *mat.Dense) float64 {
+		return BrierScoreLoss(Ytrue, Yprob, 1, nil)
+	}, ResponseMethod: "predict_proba"},
+	// clustering
+	"adjusted_rand_score":          {Metric: clusterScore(AdjustedRandScore), GreaterIsBetter: true},
+	"rand_score":                   {Metric: clusterScore(RandScore), GreaterIsBetter: true},
+	"homogeneity_score":            {Metric: clusterScore(HomogeneityScore), GreaterIsBetter: true},
+	"completeness_score":           {Metric: clusterScore(CompletenessScore), GreaterIsBetter: true},
+	"v_measure_score":              {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return VMeasureScore(Ytrue, Ypred, 1) }, GreaterIsBetter: true},
+	"mutual_info_score":            {Metric: clusterScore(MutualInfoScore), GreaterIsBetter: true},
+	"fowlkes_mallows_score":        {Metric: clusterScore(FowlkesMallowsScore), GreaterIsBetter: true},
+	"adjusted_mutual_info_score":   {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return AdjustedMutualInfoScore(Ytrue, Ypred, "") }, GreaterIsBetter: true},
+	"normalized_mutual_info_score": {Metric: func(Ytrue, Ypred *mat.Dense) float64 { return NormalizedMutualInfoScore(Ytrue, Ypred, "") }, GreaterIsBetter: true},
+}
+
+func init() {
+	for which, metric := range []string{"precision", "recall", "f1"} {
+		for _, average := range []string{"macro", "micro", "weighted", "samples"} {
+			Scorers[metric+"_"+average] = &Scorer{Metric: prfScore(which, average), GreaterIsBetter: true}
+		}
+	}
+	for name, scorer := range Scorers {
+		scorer.Name = name
+	}
+}
+
+// GetScorer returns a copy of the registered scorer with this name, so callers may modify it
+func GetScorer(name string) *Scorer {
+	scorer, ok := Scorers[name]
+	if !ok {
+		panic(fmt.Errorf("unknown scorer %s. valid scorers are %s", name, strings.Join(ScorerNames(), ", ")))
+	}
+	s := *scorer
+	return &s
+}
+
+// ScorerNames returns the sorted names of registered scorers
+func ScorerNames() []string {
+	names := make([]string, 0, len(Scorers))
+	for name := range Scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+type thresholdClassifier struct{}
+
+func (c *thresholdClassifier) Predict(X, Y *mat.Dense) {
+	Y.Apply(func(i, o int, _ float64) float64 { return math.Floor(X.At(i, 0) + .5) }, Y)
+}
+
+func (c *thresholdClassifier) PredictProba(X mat.Matrix, Y *mat.Dense) {
+	Y.Apply(func(i, o int, _ float64) float64 { return X.At(i, 0) }, Y)
+}
+
+func ExampleGetScorer() {
+	X := mat.NewDense(4, 1, []float64{.1, .4, .35, .8})
+	Y := mat.NewDense(4, 1, []float64{0, 0, 1, 1})
+	for _, name := range []string{"accuracy", "roc_auc", "neg_brier_score", "neg_mean_squared_error"} {
+		fmt.Printf("%s %.4f\n", name, GetScorer(name).Score(&thresholdClassifier{}, X, Y))
+	}
+	// Output:
+	// accuracy 0.7500
+	// roc_auc 0.7500
+	// neg_brier_score -0.1581
+	// neg_mean_squared_error -0.2500
+}
+
+func TestMakeScorer(t *testing.T) {
+	maxAbs := func(Ytrue, Ypred *mat.Dense) float64 { return MaxError(Ytrue, Ypred, nil, "").At(0, 0) }
+	scorer := MakeScorer("max_abs", maxAbs, false, "predict_proba")
+	X := mat.NewDense(3, 1, []float64{.2, .5, .9})
+	Y := mat.NewDense(3, 1, []float64{0, 1, 1})
+	if score := scorer.Score(&thresholdClassifier{}, X, Y); math.Abs(score+.5) > 1e-12 {
+		t.Errorf("expected -0.5 got %g", score)
+	}
+	if score := scorer.ScorePredictions(Y, X); math.Abs(score+.5) > 1e-12 {
+		t.Errorf("expected -0.5 got %g", score)
+	}
+	for _, name := range ScorerNames() {
+		if GetScorer(name).Name != name {
+			t.Errorf("scorer %s has name %s", name, GetScorer(name).Name)
+		}
+	}
+	modified := GetScorer("r2")
+	modified.GreaterIsBetter = false
+	if !GetScorer("r2").GreaterIsBetter || !Scorers["r2"].GreaterIsBetter {
+		t.Error("modifying a scorer from GetScorer should not change the registry")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("GetScorer should panic for unknown names")
+		}
+	}()
+	GetScorer("unknown")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 
+	"github.com/gcla/sklearn/base"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -56,15 +57,82 @@ func (reluActivation) Grad(z, h, grad *mat.Dense) {
 
 }
 
-// SupportedActivations is a map[Sing]ActivationFunctions for the supproted activation functions (identity,logistic,tanh,relu)
+// softmaxActivation is the row-wise softmax, for multiclass output layers.
+// its jacobian is not diagonal so its gradient is only computed fused with the log loss, see softmaxLogLoss
+type softmaxActivation struct{ activationStruct }
+
+func (softmaxActivation) Func(z, h *mat.Dense) {
+	logSoftmax(z, h)
+	h.Apply(func(_, _ int, logh float64) float64 { return math.Exp(logh) }, h)
+}
+func (softmaxActivation) Grad(z, h, grad *mat.Dense) {
+	panic(fmt.Errorf("softmax gradient is only available for output layers with log or cross-entropy loss"))
+}
+
+// logSoftmax puts log(softmax(z)) in logh, using the log-sum-exp of each row of z
+func logSoftmax(z, logh *mat.Dense) {
+	nSamples, _ := z.Dims()
+	for i := 0; i < nSamples; i++ {
+		zi, loghi := z.RawRowView(i), logh.RawRowView(i)
+		lse := logSumExp(zi)
+		for o, zo := range zi {
+			loghi[o] = zo - lse
+		}
+	}
+}
+
+// logSumExp returns log(sum(exp(z))) without overflow
+func logSumExp(z []float64) float64 {
+	max := math.Inf(-1)
+	for _, zo := range z {
+		max = math.Max(max, zo)
+	}
+	if math.IsInf(max, 0) {
+		return max
+	}
+	sum := 0.
+	for _, zo := range z {
+		sum += math.Exp(zo - max)
+	}
+	return max + math.Log(sum)
+}
+
+// scalarActivation applies a base.Activation elementwise. Grad uses FprimeX(z) when available, else Fprime(h)
+type scalarActivation struct{ base.Activation }
+
+func (a scalarActivation) Func(z, h *mat.Dense) { matx{Dense: h}.CopyApplied(z, a.F) }
+func (a scalarActivation) Grad(z, h, grad *mat.Dense) {
+	if d, ok := a.Activation.(base.InputDerivable); ok {
+		matx{Dense: grad}.CopyApplied(z, d.FprimeX)
+		return
+	}
+	matx{Dense: grad}.CopyApplied(h, a.Fprime)
+}
+
+// NewLeakyReLU returns a leaky relu ActivationFunctions with slope slope for z<0
+func NewLeakyReLU(slope float64) ActivationFunctions {
+	return scalarActivation{base.LeakyReLU{Slope: slope}}
+}
+
+// SupportedActivations is a map[Sing]ActivationFunctions for the supproted activation functions (identity,logistic,tanh,relu,
+// leaky_relu,elu,selu,gelu,softplus,swish,silu,hard_sigmoid,softmax)
 var SupportedActivations = map[string]ActivationFunctions{
-	"identity": identityActivation{},
-	"logistic": logisticActivation{},
-	"tanh":     tanhActivation{},
-	"relu":     reluActivation{},
+	"identity":     identityActivation{},
+	"logistic":     logisticActivation{},
+	"tanh":         tanhActivation{},
+	"relu":         reluActivation{},
+	"leaky_relu":   NewLeakyReLU(.01),
+	"elu":          scalarActivation{base.ELU{Alpha: 1}},
+	"selu":         scalarActivation{base.SELU{}},
+	"gelu":         scalarActivation{base.GELU{}},
+	"softplus":     scalarActivation{base.Softplus{}},
+	"swish":        scalarActivation{base.Swish{}},
+	"silu":         scalarActivation{base.Swish{}},
+	"hard_sigmoid": scalarActivation{base.HardSigmoid{}},
+	"softmax":      softmaxActivation{},
 }
 
-// NewActivation return ActivationFunctions (Func and Grad) from its name (identity,logistic,tanh,relu)
+// NewActivation return ActivationFunctions (Func and Grad) from its name. see SupportedActivations
 func NewActivation(name string) ActivationFunctions {
 	activation, ok := SupportedActivations[name]
 	if !ok {
@@ -22,6 +22,35 @@ func TestReLU(t *testing.T) {
 	testActivationDerivatives(t, "relu")
 }
 
+func TestActivationsDerivatives(t *testing.T) {
+	Z := mat.NewDense(2, 4, []float64{-3, -1.2, -.4, -.05, .05, .4, 1.2, 3})
+	H, G := mat.NewDense(2, 4, nil), mat.NewDense(2, 4, nil)
+	for name, a := range SupportedActivations {
+		if name == "softmax" {
+			// not elementwise, see TestSoftmax
+			continue
+		}
+		a.Func(Z, H)
+		a.Grad(Z, H, G)
+		Z.Apply(func(i, o int, z float64) float64 {
+			f := func(z float64) float64 {
+				Z1, H1 := mat.NewDense(1, 1, []float64{z}), mat.NewDense(1, 1, nil)
+				a.Func(Z1, H1)
+				return H1.At(0, 0)
+			}
+			if expected := fd.Derivative(f, z, &fd.Settings{Step: 1e-6}); math.Abs(G.At(i, o)-expected) > 1e-5 {
+				t.Errorf("%s z:%g g:%g fd:%g", name, z, G.At(i, o), expected)
+			}
+			return z
+		}, Z)
+	}
+	leaky := NewLeakyReLU(.2)
+	leaky.Func(Z, H)
+	if math.Abs(H.At(0, 0)+.6) > 1e-12 {
+		t.Errorf("expected leaky relu(-3)=-.6 got %g", H.At(0, 0))
+	}
+}
+
 func testActivationDerivatives(t *testing.T, activation string) {
 	nSamples, nOutputs := 1, 1
 	if _, ok := SupportedActivations[activation]; !ok {
@@ -60,3 +89,21 @@ func testActivationDerivatives(t *testing.T, activation string) {
 	}
 
 }
+
+func TestSoftmax(t *testing.T) {
+	Z := mat.NewDense(2, 3, []float64{1000, 0, -1000, 1, 2, 3})
+	H, logH := mat.NewDense(2, 3, nil), mat.NewDense(2, 3, nil)
+	NewActivation("softmax").Func(Z, H)
+	logSoftmax(Z, logH)
+	for i := 0; i < 2; i++ {
+		if sum := mat.Sum(H.RowView(i)); math.Abs(sum-1) > 1e-12 {
+			t.Errorf("row %d sums to %g", i, sum)
+		}
+	}
+	if H.At(0, 0) != 1 || logH.At(0, 2) != -2000 {
+		t.Errorf("unexpected softmax %g or log softmax %g", H.At(0, 0), logH.At(0, 2))
+	}
+	if expected := 1 - math.Log(math.Exp(1)+math.Exp(2)+math.Exp(3)); math.Abs(logH.At(1, 0)-expected) > 1e-12 {
+		t.Errorf("expected %g got %g", expected, logH.At(1, 0))
+	}
+}
@@ -0,0 +1,79 @@
+package neuralNetwork
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Initializer fills the weights Theta of a layer using rnd. the first row of Theta holds the biases
+type Initializer func(Theta *mat.Dense, rnd *rand.Rand)
+
+// Initializers is the map of available weight initializers. except for "default" (uniform in [-0.5,1.5) then orthonormalized),
+// biases are initialized to zero and the scale of the weights depends on fanIn (the number of inputs without the bias) and fanOut
+var Initializers = map[string]Initializer{
+	"default": func(Theta *mat.Dense, rnd *rand.Rand) {
+		Theta.Apply(func(_, _ int, _ float64) float64 { return -.5 + 2*rnd.Float64() }, Theta)
+		matx{Dense: Theta}.Orthonormalize()
+	},
+	"zeros": func(Theta *mat.Dense, _ *rand.Rand) { Theta.Scale(0, Theta) },
+	// Glorot and Bengio http://proceedings.mlr.press/v9/glorot10a/glorot10a.pdf
+	"glorot_uniform": uniformInitializer(func(fanIn, fanOut float64) float64 { return math.Sqrt(6 / (fanIn + fanOut)) }),
+	"glorot_normal":  normalInitializer(func(fanIn, fanOut float64) float64 { return math.Sqrt(2 / (fanIn + fanOut)) }),
+	// He et al. https://arxiv.org/abs/1502.01852
+	"he_uniform": uniformInitializer(func(fanIn, fanOut float64) float64 { return math.Sqrt(6 / fanIn) }),
+	"he_normal":  normalInitializer(func(fanIn, fanOut float64) float64 { return math.Sqrt(2 / fanIn) }),
+	// LeCun et al. http://yann.lecun.com/exdb/publis/pdf/lecun-98b.pdf
+	"lecun_uniform": uniformInitializer(func(fanIn, fanOut float64) float64 { return math.Sqrt(3 / fanIn) }),
+	"lecun_normal":  normalInitializer(func(fanIn, fanOut float64) float64 { return math.Sqrt(1 / fanIn) }),
+	// Saxe et al. https://arxiv.org/abs/1312.6120
+	"orthogonal": func(Theta *mat.Dense, rnd *rand.Rand) {
+		weights := zeroBiases(Theta)
+		weights.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, weights)
+		matx{Dense: weights}.Orthonormalize()
+	},
+}
+
+// NewInitializer returns the Initializer named name, "default" if name is ""
+func NewInitializer(name string) Initializer {
+	if name == "" {
+		name = "default"
+	}
+	initializer, ok := Initializers[name]
+	if !ok {
+		panic(fmt.Errorf("unknown initializer %s", name))
+	}
+	return initializer
+}
+
+// zeroBiases sets the bias row of Theta to zero and returns the weights rows
+func zeroBiases(Theta *mat.Dense) *mat.Dense {
+	inputs, outputs := Theta.Dims()
+	for o := 0; o < outputs; o++ {
+		Theta.Set(0, o, 0)
+	}
+	return base.MatDenseSlice(Theta, 1, inputs, 0, outputs)
+}
+
+// uniformInitializer returns an Initializer drawing weights uniformly in [-limit,limit)
+func uniformInitializer(limit func(fanIn, fanOut float64) float64) Initializer {
+	return func(Theta *mat.Dense, rnd *rand.Rand) {
+		weights := zeroBiases(Theta)
+		fanIn, fanOut := weights.Dims()
+		l := limit(float64(fanIn), float64(fanOut))
+		weights.Apply(func(_, _ int, _ float64) float64 { return l * (2*rnd.Float64() - 1) }, weights)
+	}
+}
+
+// normalInitializer returns an Initializer drawing weights from a centered normal distribution
+func normalInitializer(stddev func(fanIn, fanOut float64) float64) Initializer {
+	return func(Theta *mat.Dense, rnd *rand.Rand) {
+		weights := zeroBiases(Theta)
+		fanIn, fanOut := weights.Dims()
+		s := stddev(float64(fanIn), float64(fanOut))
+		weights.Apply(func(_, _ int, _ float64) float64 { return s * rnd.NormFloat64() }, weights)
+	}
+}
@@ -0,0 +1,83 @@
+package neuralNetwork
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gcla/sklearn/base"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+func TestInitializers(t *testing.T) {
+	fanIn, fanOut := 200, 100
+	expectedStd := map[string]float64{
+		"glorot_uniform": math.Sqrt(2. / float64(fanIn+fanOut)),
+		"glorot_normal":  math.Sqrt(2. / float64(fanIn+fanOut)),
+		"he_uniform":     math.Sqrt(2. / float64(fanIn)),
+		"he_normal":      math.Sqrt(2. / float64(fanIn)),
+		"lecun_uniform":  math.Sqrt(1. / float64(fanIn)),
+		"lecun_normal":   math.Sqrt(1. / float64(fanIn)),
+		"orthogonal":     math.Sqrt(1. / float64(fanIn)),
+	}
+	for name, std := range expectedStd {
+		Theta := mat.NewDense(1+fanIn, fanOut, nil)
+		NewInitializer(name)(Theta, rand.New(rand.NewSource(7)))
+		if floats.Norm(Theta.RawRowView(0), 1) != 0 {
+			t.Errorf("%s: biases are not zero", name)
+		}
+		weights := base.MatDenseSlice(Theta, 1, 1+fanIn, 0, fanOut)
+		w := mat.DenseCopyOf(weights).RawMatrix().Data
+		if actual := stat.StdDev(w, nil); math.Abs(actual-std) > .05*std {
+			t.Errorf("%s: expected std %g got %g", name, std, actual)
+		}
+	}
+
+	Theta := mat.NewDense(1+fanIn, fanOut, nil)
+	NewInitializer("orthogonal")(Theta, rand.New(rand.NewSource(7)))
+	weights := base.MatDenseSlice(Theta, 1, 1+fanIn, 0, fanOut)
+	WtW := mat.NewDense(fanOut, fanOut, nil)
+	WtW.Mul(weights.T(), weights)
+	eye := mat.NewDense(fanOut, fanOut, nil)
+	eye.Apply(func(i, j int, _ float64) float64 {
+		if i == j {
+			return 1
+		}
+		return 0
+	}, eye)
+	if !mat.EqualApprox(WtW, eye, 1e-9) {
+		t.Error("orthogonal: weights columns are not orthonormal")
+	}
+}
+
+func TestMLPRegressorInitializer(t *testing.T) {
+	X, Y := mat.NewDense(100, 3, nil), mat.NewDense(100, 1, nil)
+	rnd := rand.New(rand.NewSource(7))
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) }, Y)
+	fit := func(seed int64) *MLPRegressor {
+		regr := NewMLPRegressor([]int{5}, "relu", "adam", 0)
+		regr.Initializer = "he_normal"
+		regr.LayerInitializers = []string{"", "glorot_uniform"}
+		regr.RandomState = rand.New(rand.NewSource(seed))
+		regr.Epochs = 10
+		regr.Fit(X, Y)
+		return regr
+	}
+	regr1, regr2 := fit(1), fit(1)
+	if !floats.Equal(regr1.thetaSlice, regr2.thetaSlice) {
+		t.Error("fits with the same RandomState differ")
+	}
+	if floats.Equal(regr1.thetaSlice, fit(2).thetaSlice) {
+		t.Error("fits with different RandomState are equal")
+	}
+
+	regr := NewMLPRegressor([]int{5}, "relu", "adam", 0)
+	regr.LayerInitializers = []string{"zeros", "he_uniform"}
+	regr.allocLayers(3, 1, nil)
+	if mat.Norm(regr.Layers[0].Theta, 1) != 0 || mat.Norm(regr.Layers[1].Theta, 1) == 0 {
+		t.Error("LayerInitializers not applied")
+	}
+}
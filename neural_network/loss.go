@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 
+	"github.com/gcla/sklearn/base"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -18,10 +19,10 @@ type squareLoss struct{ lossBaseStruct }
 
 func (squareLoss) Loss(Ytrue, Ypred, Grad *mat.Dense) float64 {
 	nSamples, _ := Ytrue.Dims()
-	// J:=(h-y)^2/2
+	// J:=(h-y)^2/2/nSamples
 	// Ydiff := matSub{A: Ypred, B: Ytrue}
 	// J := metrics.MeanSquaredError(Ytrue, Ypred, nil, "").At(0, 0)
-	J := matx{}.SumApplied2(Ytrue, Ypred, func(y, h float64) float64 { yd := h - y; return yd * yd / 2. })
+	J := matx{}.SumApplied2(Ytrue, Ypred, func(y, h float64) float64 { yd := h - y; return yd * yd / 2. }) / float64(nSamples)
 	// Grad:=(h-y)
 	if Grad != nil {
 		//Grad.Scale(1./float64(nSamples), Ydiff)
@@ -79,6 +80,25 @@ func (crossEntropyLoss) Loss(Ytrue, Ypred, Grad *mat.Dense) float64 {
 	return J
 }
 
+// softmaxLogLoss is the multiclass cross-entropy -sum(y*log(softmax(z)))/nSamples, with log(softmax(z)) computed from Z by log-sum-exp.
+// H must hold softmax(Z). Grad gets the gradient wrt Z (not H): (h-y)/nSamples
+func softmaxLogLoss(Ytrue, Z, H, Grad *mat.Dense) float64 {
+	nSamples, nOutputs := Z.Dims()
+	J := 0.
+	for i := 0; i < nSamples; i++ {
+		lse := logSumExp(Z.RawRowView(i))
+		for o := 0; o < nOutputs; o++ {
+			if y := Ytrue.At(i, o); y != 0 {
+				J -= y * (Z.At(i, o) - lse)
+			}
+		}
+	}
+	if Grad != nil {
+		matx{Dense: Grad}.CopyScaledApplied2(Ytrue, H, 1./float64(nSamples), func(y, h float64) float64 { return h - y })
+	}
+	return J / float64(nSamples)
+}
+
 // SupportedLoss are the map[string]Losser of available matrix loss function providers
 var SupportedLoss = map[string]LossFunctions{
 	"square":        squareLoss{},
@@ -94,3 +114,23 @@ func NewLoss(name string) LossFunctions {
 	}
 	return loss
 }
+
+// CheckLossGradient compares the gradient of loss(Ytrue,activation(Z)) wrt Z, computed from loss and activation Grad as in backprop,
+// with its central differences. step is the finite differences step, 0 for the default one
+func CheckLossGradient(activation ActivationFunctions, loss LossFunctions, Ytrue, Z *mat.Dense, step float64) *base.GradientCheck {
+	nSamples, nOutputs := Z.Dims()
+	H, G, Hgrad := mat.NewDense(nSamples, nOutputs, nil), mat.NewDense(nSamples, nOutputs, nil), mat.NewDense(nSamples, nOutputs, nil)
+	J := func(z []float64) float64 {
+		activation.Func(mat.NewDense(nSamples, nOutputs, z), H)
+		return loss.Loss(Ytrue, H, nil)
+	}
+	dJ := func(dst, z []float64) {
+		Z := mat.NewDense(nSamples, nOutputs, z)
+		activation.Func(Z, H)
+		loss.Loss(Ytrue, H, G)
+		activation.Grad(Z, H, Hgrad)
+		G.MulElem(G, Hgrad)
+		copy(dst, G.RawMatrix().Data)
+	}
+	return base.CheckGradient(J, dJ, mat.DenseCopyOf(Z).RawMatrix().Data, step)
+}
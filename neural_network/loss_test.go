@@ -1,14 +1,10 @@
 package neuralNetwork
 
 import (
-	"fmt"
-	"math"
 	"math/rand"
 
 	"testing"
 
-	"gonum.org/v1/gonum/floats"
-	"gonum.org/v1/gonum/diff/fd"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -26,38 +22,43 @@ func TestCrossEntropyLoss(t *testing.T) {
 
 func testLossDerivatives(t *testing.T, loss string) {
 	nSamples, nOutputs := 100, 2
-	unused(fd.Gradient, floats.Sum, fmt.Println)
 	losser := NewLoss(loss)
 
 	Ytrue := mat.NewDense(nSamples, nOutputs, nil)
-	YpredSlice := make([]float64, nSamples*nOutputs, nSamples*nOutputs)
-	Ypred := mat.NewDense(nSamples, nOutputs, YpredSlice)
-	gradSlice := make([]float64, nSamples*nOutputs, nSamples*nOutputs)
-	gradSliceFd := make([]float64, nSamples*nOutputs, nSamples*nOutputs)
-	Grad := mat.NewDense(nSamples, nOutputs, gradSlice)
+	Ypred := mat.NewDense(nSamples, nOutputs, nil)
 
+	// keep Ytrue away from 0 and 1 where log and cross-entropy are not differentiable
 	Ytrue.Apply(func(i int, j int, v float64) float64 {
-		return rand.Float64()
+		return .1 + .8*rand.Float64()
 
 	}, Ytrue)
-	Ypred.Apply(func(i int, j int, v float64) float64 {
-
-		return v + 1e-3*rand.NormFloat64()
-	}, Ytrue)
-
-	lossFd := func(YpredSlice []float64) float64 {
-		return losser.Loss(Ytrue, mat.NewDense(nSamples, nOutputs, YpredSlice), nil)
-	}
-	//fmt.Printf("TestLoss %#v\n", activation)
 	for i := 0; i < 10; i++ {
-		// Alpha := 0.
-		// L1Ratio := 0.
-		losser.Loss(Ytrue, Ypred, Grad)
-		fd.Gradient(gradSliceFd, lossFd, YpredSlice, &fd.Settings{Step: 1e-8})
-
-		//fmt.Printf("%t %g %g\n", activation, grad.At(0, 0), dJ)
-		if math.Abs(gradSlice[0]-gradSliceFd[0]) > 1e-2 {
-			t.Errorf("%s %g fd:%g\n", loss, gradSlice[0], gradSliceFd[0])
+		Ypred.Apply(func(i int, j int, v float64) float64 {
+			return v + 1e-3*rand.NormFloat64()
+		}, Ytrue)
+		// identity activation checks the loss gradient wrt Ypred
+		gc := CheckLossGradient(identityActivation{}, losser, Ytrue, Ypred, 0)
+		if gc.MaxRelErr > 1e-4 {
+			t.Errorf("%s %g fd:%g\n", loss, gc.Analytic[gc.ArgMax], gc.Numeric[gc.ArgMax])
+		}
+	}
+}
+
+func TestCheckLossGradient(t *testing.T) {
+	nSamples, nOutputs := 100, 2
+	Ytrue, Z := mat.NewDense(nSamples, nOutputs, nil), mat.NewDense(nSamples, nOutputs, nil)
+	Ytrue.Apply(func(_, _ int, _ float64) float64 { return rand.Float64() }, Ytrue)
+	Z.Apply(func(_, _ int, _ float64) float64 { return rand.NormFloat64() }, Z)
+	for _, loss := range []string{"square", "log", "cross-entropy"} {
+		for _, activation := range []string{"identity", "logistic", "tanh"} {
+			// log and cross-entropy need h in ]0,1[
+			if loss != "square" && activation != "logistic" {
+				continue
+			}
+			gc := CheckLossGradient(NewActivation(activation), NewLoss(loss), Ytrue, Z, 0)
+			if gc.MaxRelErr > 1e-4 {
+				t.Errorf("%s %s grad:%g fd:%g", loss, activation, gc.Analytic[gc.ArgMax], gc.Numeric[gc.ArgMax])
+			}
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package neuralNetwork
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
@@ -12,6 +13,7 @@ import (
 	"github.com/gcla/sklearn/base"
 	"github.com/gcla/sklearn/preprocessing"
 	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/floats"
 
 	"gonum.org/v1/gonum/mat"
 )
@@ -20,25 +22,60 @@ import (
 type Optimizer = base.Optimizer
 
 // Layer represents a layer in a neural network. its mainly an Activation and a Theta
+// XSparse is the input of the first layer when fitted with sparse data (X1 is then unused)
+// L1 and L2 are penalties on the layer weights added to the regressor ones
 type Layer struct {
 	Activation                                string
 	X1, Ytrue, Z, Ypred, NextX1, Ydiff, Hgrad *mat.Dense
+	XSparse                                   *base.CSR
 	Theta, Grad, Update                       *mat.Dense
 	Optimizer                                 Optimizer
+	L1, L2                                    float64
+	// Dropout is the fraction of the layer outputs zeroed during training, the others being scaled by 1/(1-Dropout) (inverted dropout)
+	Dropout float64
+	// BatchNormalization normalizes Z with the mini-batch mean and variance during training, and with RunningMean and RunningVar
+	// when predicting, then scales it by gamma (row 0 of BN) and shifts it by beta (row 1 of BN).
+	// BN is learned like Theta, using BNGrad, BNUpdate and BNOptimizer
+	BatchNormalization      bool
+	BN, BNGrad, BNUpdate    *mat.Dense
+	BNOptimizer             Optimizer
+	RunningMean, RunningVar []float64
+	// run values: dropout mask, normalized Z and its standard deviation
+	mask, Zhat *mat.Dense
+	std        []float64
 }
 
-// NewLayer creates a randomly initialized layer
-func NewLayer(inputs, outputs int, activation string, optimCreator base.OptimCreator, thetaSlice, gradSlice, updateSlice []float64, rnd func() float64) *Layer {
+// bnMomentum is the weight of running values in RunningMean and RunningVar updates, bnEpsilon is added to variances
+const bnMomentum, bnEpsilon = .9, 1e-5
+
+// LayerSpec describes a hidden layer of an MLPRegressor
+type LayerSpec struct {
+	// Size is the number of units. Activation is one of the keys of SupportedActivations, "" for the regressor Activation
+	Size       int
+	Activation string
+	// Dropout is the fraction of the layer outputs zeroed during training
+	Dropout float64
+	// L1 and L2 are penalties on the layer weights added to the regressor ones (Alpha*L1Ratio and Alpha*(1-L1Ratio))
+	L1, L2 float64
+	// BatchNormalization normalizes the layer inputs of the activation
+	BatchNormalization bool
+}
+
+// NewLayer creates a layer initialized by initializer ("default" one if nil) drawing from rnd (seeded from math/rand if nil)
+func NewLayer(inputs, outputs int, activation string, optimCreator base.OptimCreator, thetaSlice, gradSlice, updateSlice []float64, initializer Initializer, rnd *rand.Rand) *Layer {
 
 	Theta := mat.NewDense(inputs, outputs, thetaSlice)
+	if initializer == nil {
+		initializer = NewInitializer("default")
+	}
 	if rnd == nil {
-		rnd = func() float64 { return -.5 + 2*rand.Float64() }
+		rnd = rand.New(rand.NewSource(rand.Int63()))
 	}
-	Theta.Apply(func(feature, output int, _ float64) float64 { return rnd() }, Theta)
-	matx{Dense: Theta}.Orthonormalize()
+	initializer(Theta, rnd)
 	var optimizer base.Optimizer
 	if optimCreator != nil {
 		optimizer = optimCreator()
+		optimizer.SetTheta(Theta)
 	}
 	return &Layer{Activation: activation,
 		Theta:     Theta,
@@ -62,6 +99,9 @@ func (L *Layer) allocOutputs(nSamples, nOutputs int) {
 	mk(&L.NextX1, nSamples, 1+nOutputs)
 	mk(&L.Ydiff, nSamples, nOutputs)
 	mk(&L.Hgrad, nSamples, nOutputs)
+	if L.BatchNormalization {
+		mk(&L.Zhat, nSamples, nOutputs)
+	}
 
 	for sample := 0; sample < nSamples; sample++ {
 		L.NextX1.Set(sample, 0, 1.)
@@ -70,6 +110,110 @@ func (L *Layer) allocOutputs(nSamples, nOutputs int) {
 
 }
 
+// optimizers returns the optimizers of Theta and BN
+func (L *Layer) optimizers() []Optimizer {
+	if L.BNOptimizer != nil {
+		return []Optimizer{L.Optimizer, L.BNOptimizer}
+	}
+	return []Optimizer{L.Optimizer}
+}
+
+// initBatchNormalization sets BN, BNGrad and BNUpdate on slices of 2*outputs elements, with gamma=1 and beta=0
+func (L *Layer) initBatchNormalization(optimCreator base.OptimCreator, bnSlice, gradSlice, updateSlice []float64) {
+	_, outputs := L.Theta.Dims()
+	L.BatchNormalization = true
+	L.BN = mat.NewDense(2, outputs, bnSlice)
+	L.BNGrad = mat.NewDense(2, outputs, gradSlice)
+	L.BNUpdate = mat.NewDense(2, outputs, updateSlice)
+	L.RunningMean, L.RunningVar, L.std = make([]float64, outputs), make([]float64, outputs), make([]float64, outputs)
+	for o := 0; o < outputs; o++ {
+		L.BN.Set(0, o, 1)
+		L.BN.Set(1, o, 0)
+		L.RunningVar[o] = 1
+	}
+	if optimCreator != nil {
+		L.BNOptimizer = optimCreator()
+		L.BNOptimizer.SetTheta(L.BN)
+	}
+}
+
+// normalize replaces Z by gamma*Zhat+beta where Zhat is Z normalized with the batch statistics during training (updating
+// RunningMean and RunningVar), else with RunningMean and RunningVar
+func (L *Layer) normalize(training bool) {
+	nSamples, outputs := L.Z.Dims()
+	n := float64(nSamples)
+	for o := 0; o < outputs; o++ {
+		mean, variance := L.RunningMean[o], L.RunningVar[o]
+		if training {
+			mean, variance = 0, 0
+			for i := 0; i < nSamples; i++ {
+				mean += L.Z.At(i, o)
+			}
+			mean /= n
+			for i := 0; i < nSamples; i++ {
+				d := L.Z.At(i, o) - mean
+				variance += d * d
+			}
+			variance /= n
+			L.RunningMean[o] = bnMomentum*L.RunningMean[o] + (1-bnMomentum)*mean
+			L.RunningVar[o] = bnMomentum*L.RunningVar[o] + (1-bnMomentum)*variance
+		}
+		L.std[o] = math.Sqrt(variance + bnEpsilon)
+		gamma, beta := L.BN.At(0, o), L.BN.At(1, o)
+		for i := 0; i < nSamples; i++ {
+			zhat := (L.Z.At(i, o) - mean) / L.std[o]
+			L.Zhat.Set(i, o, zhat)
+			L.Z.Set(i, o, gamma*zhat+beta)
+		}
+	}
+}
+
+// normalizationGrad puts the gradient of gamma and beta in BNGrad and replaces Ydiff (dJ/dZ after normalization)
+// by dJ/dZ before normalization
+func (L *Layer) normalizationGrad() {
+	nSamples, outputs := L.Ydiff.Dims()
+	n := float64(nSamples)
+	for o := 0; o < outputs; o++ {
+		dGamma, dBeta := 0., 0.
+		for i := 0; i < nSamples; i++ {
+			dz := L.Ydiff.At(i, o)
+			dGamma += dz * L.Zhat.At(i, o)
+			dBeta += dz
+		}
+		L.BNGrad.Set(0, o, dGamma)
+		L.BNGrad.Set(1, o, dBeta)
+		scale := L.BN.At(0, o) / n / L.std[o]
+		for i := 0; i < nSamples; i++ {
+			L.Ydiff.Set(i, o, scale*(n*L.Ydiff.At(i, o)-dBeta-L.Zhat.At(i, o)*dGamma))
+		}
+	}
+}
+
+// dropout computes Hgrad, then zeroes a Dropout fraction of Ypred and Hgrad and scales the others by 1/(1-Dropout).
+// the mask is kept if keepMask and the batch size is unchanged
+func (L *Layer) dropout(rnd func() float64, keepMask bool) {
+	NewActivation(L.Activation).Grad(L.Z, L.Ypred, L.Hgrad)
+	nSamples, outputs := L.Ypred.Dims()
+	if r, _ := L.maskDims(); !keepMask || r != nSamples {
+		L.mask = mat.NewDense(nSamples, outputs, nil)
+		L.mask.Apply(func(_, _ int, _ float64) float64 {
+			if rnd() < L.Dropout {
+				return 0
+			}
+			return 1 / (1 - L.Dropout)
+		}, L.mask)
+	}
+	L.Ypred.MulElem(L.Ypred, L.mask)
+	L.Hgrad.MulElem(L.Hgrad, L.mask)
+}
+
+func (L *Layer) maskDims() (int, int) {
+	if L.mask == nil {
+		return 0, 0
+	}
+	return L.mask.Dims()
+}
+
 // Regressors is the list of regressors in this package
 var Regressors = []base.Regressor{&MLPRegressor{}}
 
@@ -80,27 +224,68 @@ type MLPRegressor struct {
 	Activation       string
 	Solver           string
 	HiddenLayerSizes []int
-	RandomState      *rand.Rand
+	// HiddenLayers, if not nil, is used instead of HiddenLayerSizes and Activation to describe each hidden layer
+	HiddenLayers []LayerSpec
+	// OutputActivation is the activation of the output layer. if "", it's logistic for log and cross-entropy losses, else Activation
+	OutputActivation string
+	// RandomState, if not nil, is used for weights initialization, the validation split and dense data shuffling, making runs reproducible
+	RandomState *rand.Rand
+	// Initializer is the name of the weights initializer of the layers (one of the keys of Initializers), "" for "default".
+	// LayerInitializers, if not nil, holds the initializer names of the hidden layers then of the output layer, "" for Initializer
+	Initializer       string
+	LayerInitializers []string
 
 	Layers                           []*Layer
 	Alpha, L1Ratio, GradientClipping float64
 	Epochs, MiniBatchSize            int
 
 	Loss string
+	// LRScheduler, if not nil, is shared by the layers optimizers. a base.LossObserver observes the opposite of the validation score
+	// with EarlyStopping, else the training loss. see base.LRSchedulers
+	LRScheduler base.LRScheduler
+	// Scorer is used by Score when not nil
+	Scorer *metrics.Scorer
+
+	// EarlyStopping holds out ValidationFraction of the samples and stops when the validation score
+	// has not improved by at least Tol for NIterNoChange epochs. the best weights are restored at the end.
+	// without EarlyStopping, a positive NIterNoChange stops when the training loss has not decreased by at least Tol.
+	// both are ignored by gonum/optimize solvers
+	EarlyStopping      bool
+	ValidationFraction float64
+	NIterNoChange      int
+	Tol                float64
+	// LossCurve is the training loss of each epoch. ValidationScores is the validation score of each epoch when EarlyStopping
+	LossCurve, ValidationScores []float64
+	BestValidationScore         float64
+	// NIter is the number of epochs run by Fit
+	NIter int
+	// Callbacks are called on epoch begin and end and after each mini-batch. see base.CallbackInfo.
+	// with gonum/optimize solvers, epochs are major iterations and OnMiniBatchEnd is not called
+	Callbacks base.Callbacks
+
 	// run values
 	thetaSlice, gradSlice, updateSlice []float64
 	// Loss value after Fit
 	JFirst, J float64
+	// stop is set when a callback stops training
+	stop bool
+	// gradientOnly is set by CheckGradient to have backprop compute gradients without clipping them nor updating weights
+	gradientOnly bool
+	// training is set while predictZH is called for backprop, enabling dropout and batch statistics
+	training bool
+	// multiclass is set by MLPClassifier unless Multilabel, to default to a softmax output layer
+	multiclass bool
 }
 
 // OptimCreator is an Optimizer creator function
 type OptimCreator = base.OptimCreator
 
 // NewMLPRegressor returns a *MLPRegressor with defaults
-// activation is one of identity,logistic,tanh,relu
-// solver is on of agd,adagrad,rmsprop,adadelta,adam (one of the keys of base.Solvers) defaults to "adam"
+// activation is one of identity,logistic,tanh,relu,leaky_relu,elu,selu,gelu,softplus,swish,silu,hard_sigmoid
+// solver is one of the keys of base.Solvers (sgd,adagrad,rmsprop,adadelta,adam,nesterov,nadam,adamw,amsgrad,adamax,lamb) defaults to "adam"
 // Alpha is the regularization parameter
 // Loss is one of square,log,cross-entropy defaults: square for identity, log for logistic,tanh,relu
+// set HiddenLayers and OutputActivation on the result for a per-layer architecture
 func NewMLPRegressor(hiddenLayerSizes []int, activation string, solver string, Alpha float64) *MLPRegressor {
 	if activation == "" {
 		activation = "relu"
@@ -117,6 +302,9 @@ func NewMLPRegressor(hiddenLayerSizes []int, activation string, solver string, A
 		Loss:             "square",
 		Activation:       activation,
 		Alpha:            Alpha,
+
+		ValidationFraction: .1,
+		Tol:                1e-4,
 	}
 	if activation != "identity" {
 		regr.Loss = "log"
@@ -139,40 +327,98 @@ func (regr *MLPRegressor) inputs(prevOutputs int) (inputs int) {
 	return
 }
 
-func (regr *MLPRegressor) allocLayers(nFeatures, nOutputs int, rnd func() float64) {
+// hiddenLayers returns HiddenLayers, or specs built from HiddenLayerSizes and Activation. Activation replaces empty activations
+func (regr *MLPRegressor) hiddenLayers() []LayerSpec {
+	if regr.HiddenLayers == nil {
+		specs := make([]LayerSpec, len(regr.HiddenLayerSizes))
+		for i, size := range regr.HiddenLayerSizes {
+			specs[i] = LayerSpec{Size: size, Activation: regr.Activation}
+		}
+		return specs
+	}
+	specs := append([]LayerSpec(nil), regr.HiddenLayers...)
+	for i, spec := range specs {
+		switch {
+		case spec.Size <= 0:
+			panic(fmt.Errorf("hidden layer %d size must be > 0, got %d", i, spec.Size))
+		case spec.Dropout < 0 || spec.Dropout >= 1:
+			panic(fmt.Errorf("hidden layer %d dropout must be in [0,1), got %g", i, spec.Dropout))
+		}
+		if spec.Activation == "" {
+			specs[i].Activation = regr.Activation
+		}
+	}
+	return specs
+}
+
+// allocLayers creates layers with weights initialized by initializer, or by the Initializer and LayerInitializers if it's nil
+func (regr *MLPRegressor) allocLayers(nFeatures, nOutputs int, initializer Initializer) {
 	var thetaLen, thetaOffset, thetaLen1 int
 	regr.Layers = make([]*Layer, 0)
 
-	if rnd == nil && regr.RandomState != nil {
-		rnd = func() float64 { return -.5 + 2*regr.RandomState.Float64() }
+	rnd := regr.RandomState
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(rand.Int63()))
+	}
+	layerInitializer := func(layer int) Initializer {
+		if initializer != nil {
+			return initializer
+		}
+		if layer < len(regr.LayerInitializers) && regr.LayerInitializers[layer] != "" {
+			return NewInitializer(regr.LayerInitializers[layer])
+		}
+		return NewInitializer(regr.Initializer)
 	}
 
 	prevOutputs := nFeatures
+	hiddenLayers := regr.hiddenLayers()
 
-	for _, outputs := range regr.HiddenLayerSizes {
-		thetaLen += regr.inputs(prevOutputs) * outputs
-		prevOutputs = outputs
+	for _, spec := range hiddenLayers {
+		thetaLen += regr.inputs(prevOutputs) * spec.Size
+		if spec.BatchNormalization {
+			thetaLen += 2 * spec.Size
+		}
+		prevOutputs = spec.Size
 	}
 	thetaLen += regr.inputs(prevOutputs) * nOutputs
 	regr.thetaSlice = make([]float64, thetaLen, thetaLen)
 	regr.gradSlice = make([]float64, thetaLen, thetaLen)
 	regr.updateSlice = make([]float64, thetaLen, thetaLen)
 	prevOutputs = nFeatures
-	for _, outputs := range regr.HiddenLayerSizes {
+	for _, spec := range hiddenLayers {
+		outputs := spec.Size
 		thetaLen1 = regr.inputs(prevOutputs) * outputs
-		regr.Layers = append(regr.Layers, NewLayer(regr.inputs(prevOutputs), outputs, regr.Activation, regr.Optimizer,
+		L := NewLayer(regr.inputs(prevOutputs), outputs, spec.Activation, regr.Optimizer,
 			regr.thetaSlice[thetaOffset:thetaOffset+thetaLen1],
 			regr.gradSlice[thetaOffset:thetaOffset+thetaLen1],
 			regr.updateSlice[thetaOffset:thetaOffset+thetaLen1],
-			rnd))
+			layerInitializer(len(regr.Layers)), rnd)
+		L.L1, L.L2, L.Dropout = spec.L1, spec.L2, spec.Dropout
 		thetaOffset += thetaLen1
+		if spec.BatchNormalization {
+			// gamma and beta follow the layer weights in thetaSlice
+			L.initBatchNormalization(regr.Optimizer,
+				regr.thetaSlice[thetaOffset:thetaOffset+2*outputs],
+				regr.gradSlice[thetaOffset:thetaOffset+2*outputs],
+				regr.updateSlice[thetaOffset:thetaOffset+2*outputs])
+			thetaOffset += 2 * outputs
+		}
+		regr.Layers = append(regr.Layers, L)
 		prevOutputs = outputs
 	}
-	var lastActivation string
-	if regr.Loss == "cross-entropy" || regr.Loss == "log" {
-		lastActivation = "logistic"
-	} else {
-		lastActivation = regr.Activation
+	lastActivation := regr.OutputActivation
+	if lastActivation == "" {
+		if regr.Loss == "cross-entropy" || regr.Loss == "log" {
+			lastActivation = "logistic"
+			if regr.multiclass && nOutputs > 1 {
+				lastActivation = "softmax"
+			}
+		} else {
+			lastActivation = regr.Activation
+		}
+	}
+	if lastActivation == "softmax" && regr.Loss != "cross-entropy" && regr.Loss != "log" {
+		panic(fmt.Errorf("softmax output layer needs log or cross-entropy loss, got %s", regr.Loss))
 	}
 	// add output layer
 	thetaLen1 = regr.inputs(prevOutputs) * nOutputs
@@ -181,44 +427,251 @@ func (regr *MLPRegressor) allocLayers(nFeatures, nOutputs int, rnd func() float6
 		regr.thetaSlice[thetaOffset:thetaOffset+thetaLen1],
 		regr.gradSlice[thetaOffset:thetaOffset+thetaLen1],
 		regr.updateSlice[thetaOffset:thetaOffset+thetaLen1],
-		rnd))
+		layerInitializer(len(regr.Layers)), rnd))
 
 }
 
 // Fit fits an MLPRegressor
 func (regr *MLPRegressor) Fit(X, Y *mat.Dense) base.Transformer {
+	return regr.fit(X, Y)
+}
+
+// FitSparse fits an MLPRegressor with sparse X. only the first layer sees X so the other layers are unchanged
+func (regr *MLPRegressor) FitSparse(X *base.CSR, Y *mat.Dense) base.Transformer {
+	return regr.fit(X, Y)
+}
+
+// FitContext is Fit stopping at the next epoch once ctx is done.
+// it then keeps the best weights so far (on the validation set with EarlyStopping, else on the training loss) and returns ctx.Err()
+func (regr *MLPRegressor) FitContext(ctx context.Context, X, Y *mat.Dense) (base.Transformer, error) {
+	return regr.fitContext(ctx, X, Y)
+}
+
+// FitSparseContext is FitSparse stopping at the next epoch once ctx is done. see FitContext
+func (regr *MLPRegressor) FitSparseContext(ctx context.Context, X *base.CSR, Y *mat.Dense) (base.Transformer, error) {
+	return regr.fitContext(ctx, X, Y)
+}
+
+func (regr *MLPRegressor) fitContext(ctx context.Context, X mat.Matrix, Y *mat.Dense) (base.Transformer, error) {
+	if ctx.Done() == nil {
+		return regr.fit(X, Y), nil
+	}
+	checkpoint := &base.Checkpoint{}
+	// checkpointStatistics are the batch normalization running statistics of the checkpoint epoch
+	var checkpointStatistics []float64
+	snapshot := base.CallbackFuncs{EpochEnd: func(info *base.CallbackInfo) {
+		if checkpoint.Epoch == info.Epoch {
+			checkpointStatistics = regr.runningStatistics()
+		}
+	}}
+	defer func(callbacks base.Callbacks) { regr.Callbacks = callbacks }(regr.Callbacks)
+	regr.Callbacks = append(regr.Callbacks.WithContext(ctx), checkpoint, snapshot)
+	regr.fit(X, Y)
+	if ctx.Err() != nil && !regr.EarlyStopping && checkpoint.Theta != nil {
+		copy(regr.thetaSlice, checkpoint.Theta)
+		regr.setRunningStatistics(checkpointStatistics)
+	}
+	return regr, ctx.Err()
+}
+
+func (regr *MLPRegressor) fit(X mat.Matrix, Y *mat.Dense) base.Transformer {
+	var Xval mat.Matrix
+	var Yval *mat.Dense
+	if regr.EarlyStopping && !isGOMethodOnly(regr.Solver) {
+		X, Y, Xval, Yval = regr.validationSplit(X, Y)
+	}
 	nSamples, nFeatures := X.Dims()
 	_, nOutputs := Y.Dims()
 	// create layers
-	var rnd func() float64
-	regr.allocLayers(nFeatures, nOutputs, rnd)
+	regr.allocLayers(nFeatures, nOutputs, nil)
+	if regr.LRScheduler != nil {
+		for _, L := range regr.Layers {
+			for _, optimizer := range L.optimizers() {
+				if s, ok := optimizer.(base.LRScheduled); ok {
+					s.SetLRScheduler(regr.LRScheduler)
+				}
+			}
+		}
+	}
+	lossObserver, observesLoss := regr.LRScheduler.(base.LossObserver)
 	// J is the loss value
 	regr.J = math.Inf(1)
 	if regr.Epochs <= 0 {
 		regr.Epochs = 1e6 / nSamples
 	}
+	regr.LossCurve, regr.ValidationScores = nil, nil
+	regr.BestValidationScore = math.Inf(-1)
+	regr.stop = false
 	switch {
 	case isGOMethodOnly(regr.Solver):
 		regr.fitGOM(X, Y)
 
 	default:
+		nIterNoChange := regr.NIterNoChange
+		if regr.EarlyStopping && nIterNoChange <= 0 {
+			nIterNoChange = 10
+		}
+		var bestTheta, bestStatistics []float64
+		bestLoss, noImprovement := math.Inf(1), 0
 		for epoch := 0; epoch < regr.Epochs; epoch++ {
-			regr.fitEpoch(X, Y, epoch)
+			if regr.notify(base.Callbacks.OnEpochBegin, epoch, 0, regr.J) {
+				break
+			}
+			J := regr.fitEpoch(X, Y, epoch)
+			regr.LossCurve = append(regr.LossCurve, J)
+			regr.NIter = epoch + 1
+			if regr.EarlyStopping {
+				score := regr.validationScore(Xval, Yval)
+				regr.ValidationScores = append(regr.ValidationScores, score)
+				if score > regr.BestValidationScore {
+					bestTheta = append(bestTheta[:0], regr.thetaSlice...)
+					bestStatistics = regr.runningStatistics()
+				}
+				if score > regr.BestValidationScore+regr.Tol {
+					noImprovement = 0
+				} else {
+					noImprovement++
+				}
+				regr.BestValidationScore = math.Max(regr.BestValidationScore, score)
+			} else if nIterNoChange > 0 {
+				if J < bestLoss-regr.Tol {
+					noImprovement = 0
+				} else {
+					noImprovement++
+				}
+				bestLoss = math.Min(bestLoss, J)
+			}
+			if observesLoss {
+				loss := J
+				if regr.EarlyStopping {
+					loss = -regr.ValidationScores[epoch]
+				}
+				lossObserver.ObserveLoss(loss)
+			}
+			if regr.notify(base.Callbacks.OnEpochEnd, epoch, 0, J) || regr.stop {
+				break
+			}
+			if nIterNoChange > 0 && noImprovement >= nIterNoChange {
+				break
+			}
+		}
+		if bestTheta != nil {
+			copy(regr.thetaSlice, bestTheta)
+			regr.setRunningStatistics(bestStatistics)
 		}
 	}
 	return regr
 }
 
+// runningStatistics returns a copy of the RunningMean and RunningVar of batch normalization layers, which aren't in thetaSlice
+func (regr *MLPRegressor) runningStatistics() (statistics []float64) {
+	for _, L := range regr.Layers {
+		statistics = append(append(statistics, L.RunningMean...), L.RunningVar...)
+	}
+	return
+}
+
+// setRunningStatistics restores statistics returned by runningStatistics
+func (regr *MLPRegressor) setRunningStatistics(statistics []float64) {
+	for _, L := range regr.Layers {
+		statistics = statistics[copy(L.RunningMean, statistics):]
+		statistics = statistics[copy(L.RunningVar, statistics):]
+	}
+}
+
+// notify calls event on Callbacks with the current weights and step size, and applies a step size changed by a callback to all layers.
+// it returns true if a callback stopped training
+func (regr *MLPRegressor) notify(event func(base.Callbacks, *base.CallbackInfo), epoch, miniBatch int, J float64) bool {
+	if len(regr.Callbacks) == 0 {
+		return false
+	}
+	info := &base.CallbackInfo{Epoch: epoch, MiniBatch: miniBatch, Loss: J, GradNorm: floats.Norm(regr.gradSlice, 2), Theta: regr.thetaSlice}
+	stepSizer, hasStepSize := regr.Layers[0].Optimizer.(base.StepSizer)
+	if hasStepSize {
+		info.StepSize = stepSizer.GetStepSize()
+	}
+	stepSize := info.StepSize
+	event(regr.Callbacks, info)
+	if hasStepSize && info.StepSize != stepSize {
+		for _, L := range regr.Layers {
+			for _, optimizer := range L.optimizers() {
+				if s, ok := optimizer.(base.StepSizer); ok {
+					s.SetStepSize(info.StepSize)
+				}
+			}
+		}
+	}
+	return info.Stop
+}
+
+// validationSplit returns a random split of X,Y (copies) with ValidationFraction of the samples in Xval,Yval
+func (regr *MLPRegressor) validationSplit(X mat.Matrix, Y *mat.Dense) (Xtrain mat.Matrix, Ytrain *mat.Dense, Xval mat.Matrix, Yval *mat.Dense) {
+	nSamples, _ := X.Dims()
+	fraction := regr.ValidationFraction
+	if fraction <= 0 || fraction >= 1 {
+		fraction = .1
+	}
+	nVal := int(math.Ceil(fraction * float64(nSamples)))
+	if nVal >= nSamples {
+		panic(fmt.Errorf("validation set of %d samples leaves no training samples", nVal))
+	}
+	var perm []int
+	if regr.RandomState != nil {
+		perm = regr.RandomState.Perm(nSamples)
+	} else {
+		perm = rand.Perm(nSamples)
+	}
+	rows := func(M mat.Matrix, idx []int) mat.Matrix {
+		if Xs, ok := M.(*base.CSR); ok {
+			return Xs.RowsSubset(idx)
+		}
+		_, c := M.Dims()
+		sub := mat.NewDense(len(idx), c, nil)
+		for i, r := range idx {
+			mat.Row(sub.RawRowView(i), r, M)
+		}
+		return sub
+	}
+	trainIdx, valIdx := perm[nVal:], perm[:nVal]
+	return rows(X, trainIdx), rows(Y, trainIdx).(*mat.Dense), rows(X, valIdx), rows(Y, valIdx).(*mat.Dense)
+}
+
+// validationScore returns the score of the current weights on Xval,Yval. see Score
+func (regr *MLPRegressor) validationScore(Xval mat.Matrix, Yval *mat.Dense) float64 {
+	nSamples, nOutputs := Yval.Dims()
+	Ypred := mat.NewDense(nSamples, nOutputs, nil)
+	regr.predictZH(Xval, Ypred)
+	if regr.Layers[len(regr.Layers)-1].Activation == "softmax" {
+		oneHotArgmax(Ypred)
+	}
+	return regr.scorePredictions(Yval, Ypred)
+}
+
+// oneHotArgmax replaces each row of Y by the one-hot encoding of its argmax
+func oneHotArgmax(Y *mat.Dense) {
+	nSamples, _ := Y.Dims()
+	for i := 0; i < nSamples; i++ {
+		y := Y.RawRowView(i)
+		best := floats.MaxIdx(y)
+		for o := range y {
+			y[o] = 0
+		}
+		y[best] = 1
+	}
+}
+
 // fitGOM fits with a gonum/optimize Method
 
-func (regr *MLPRegressor) fitGOM(X, Y *mat.Dense) float64 {
+func (regr *MLPRegressor) fitGOM(X mat.Matrix, Y *mat.Dense) float64 {
 	epoch := 0
 
 	p := optimize.Problem{
 		Func: func(thetaSlice []float64) float64 {
 			copy(regr.thetaSlice, thetaSlice)
 			J := regr.fitEpoch(X, Y, epoch)
+			regr.LossCurve = append(regr.LossCurve, J)
 			epoch++
+			regr.NIter = epoch
 			return J
 		},
 		Grad: func(gradSlice []float64, thetaSlice []float64) {
@@ -228,9 +681,12 @@ func (regr *MLPRegressor) fitGOM(X, Y *mat.Dense) float64 {
 	method := base.GOMethodCreators[regr.Solver]()
 	settings := optimize.DefaultSettings()
 	settings.FuncEvaluations = regr.Epochs
+	if len(regr.Callbacks) > 0 {
+		settings.Recorder = &base.CallbackRecorder{Callbacks: regr.Callbacks}
+	}
 
 	ret, err := optimize.Local(p, regr.thetaSlice, settings, method)
-	if err != nil {
+	if err != nil && err != base.ErrTrainingStopped {
 		fmt.Println(err)
 	}
 	copy(regr.thetaSlice, ret.X)
@@ -238,15 +694,30 @@ func (regr *MLPRegressor) fitGOM(X, Y *mat.Dense) float64 {
 	return ret.F
 }
 
-// fitEpoch fits one epoch
-func (regr *MLPRegressor) fitEpoch(Xfull, Yfull *mat.Dense, epoch int) float64 {
+// fitEpoch fits one epoch. Xfull is a *mat.Dense or a *base.CSR
+func (regr *MLPRegressor) fitEpoch(Xfull mat.Matrix, Yfull *mat.Dense, epoch int) float64 {
 	nSamples, nFeatures := Xfull.Dims()
 	_, nOutputs := Yfull.Dims()
-	var shuffler preprocessing.InverseTransformer
+	XfullSparse, isSparse := Xfull.(*base.CSR)
+	// perm is the sample order of sparse data, whose minibatches are gathered from it instead of shuffling a copy of the data
+	var perm []int
 	if regr.Shuffle {
-		shuffler = preprocessing.NewShuffler()
-		shuffler.Fit(Xfull, Yfull).Transform(Xfull, Yfull)
-		defer shuffler.InverseTransform(Xfull, Yfull)
+		if isSparse {
+			if regr.RandomState != nil {
+				perm = regr.RandomState.Perm(nSamples)
+			} else {
+				perm = rand.Perm(nSamples)
+			}
+		} else {
+			shuffler := preprocessing.NewShuffler()
+			if regr.RandomState != nil {
+				shuffler.Perm = regr.RandomState.Perm(nSamples)
+			} else {
+				shuffler.Fit(Xfull.(*mat.Dense), Yfull)
+			}
+			shuffler.Transform(Xfull.(*mat.Dense), Yfull)
+			defer shuffler.InverseTransform(Xfull.(*mat.Dense), Yfull)
+		}
 	}
 	var miniBatchSize int
 	switch {
@@ -260,15 +731,38 @@ func (regr *MLPRegressor) fitEpoch(Xfull, Yfull *mat.Dense, epoch int) float64 {
 			miniBatchSize = 200
 		}
 	}
+	var Yperm *mat.Dense
+	if perm != nil {
+		Yperm = mat.NewDense(miniBatchSize, nOutputs, nil)
+	}
 	miniBatchStart, miniBatchEnd := 0, miniBatchSize
 	Jsum := 0.
-	for miniBatchStart < nSamples {
+	for miniBatch := 0; miniBatchStart < nSamples; miniBatch++ {
 		miniBatchLen := miniBatchEnd - miniBatchStart
-		X := base.MatDenseSlice(Xfull, miniBatchStart, miniBatchEnd, 0, nFeatures)
-		Y := base.MatDenseSlice(Yfull, miniBatchStart, miniBatchEnd, 0, nOutputs)
+		var X mat.Matrix
+		var Y *mat.Dense
+		switch {
+		case perm != nil:
+			rows := perm[miniBatchStart:miniBatchEnd]
+			X = XfullSparse.RowsSubset(rows)
+			Y = base.MatDenseSlice(Yperm, 0, miniBatchLen, 0, nOutputs)
+			for i, row := range rows {
+				Y.SetRow(i, Yfull.RawRowView(row))
+			}
+		case isSparse:
+			X = XfullSparse.RowSlice(miniBatchStart, miniBatchEnd)
+			Y = base.MatDenseSlice(Yfull, miniBatchStart, miniBatchEnd, 0, nOutputs)
+		default:
+			X = base.MatDenseSlice(Xfull.(*mat.Dense), miniBatchStart, miniBatchEnd, 0, nFeatures)
+			Y = base.MatDenseSlice(Yfull, miniBatchStart, miniBatchEnd, 0, nOutputs)
+		}
 
 		Jmini := regr.fitMiniBatch(X, Y, epoch, miniBatchLen, nSamples)
 		Jsum += Jmini
+		if !isGOMethodOnly(regr.Solver) && regr.notify(base.Callbacks.OnMiniBatchEnd, epoch, miniBatch, Jmini) {
+			regr.stop = true
+			break
+		}
 		miniBatchStart, miniBatchEnd = miniBatchStart+miniBatchLen, miniBatchEnd+miniBatchLen
 		if miniBatchEnd > nSamples {
 			miniBatchEnd = nSamples
@@ -282,8 +776,10 @@ func (regr *MLPRegressor) fitEpoch(Xfull, Yfull *mat.Dense, epoch int) float64 {
 }
 
 // fitMiniBatch fit one minibatch
-func (regr *MLPRegressor) fitMiniBatch(Xmini, Ymini *mat.Dense, epoch, miniBatchLen, nSamples int) float64 {
+func (regr *MLPRegressor) fitMiniBatch(Xmini mat.Matrix, Ymini *mat.Dense, epoch, miniBatchLen, nSamples int) float64 {
+	regr.training = true
 	regr.predictZH(Xmini, nil)
+	regr.training = false
 	Jmini := regr.backprop(Xmini, Ymini, epoch, miniBatchLen, nSamples)
 	return Jmini
 }
@@ -304,7 +800,7 @@ func (regr *MLPRegressor) backprop(X, Y mat.Matrix, epoch, miniBatchLen, nSample
 			L.Ytrue.Copy(Y)
 			L.Ydiff.Sub(L.Ypred, Y)
 		} else {
-			// compute ydiff and ytrue for non-terminal layer
+			// compute ydiff for non-terminal layer: dJ/dH = next layer dJ/dZ * next layer Theta without its bias row
 			//delta2 = (delta3 * Theta2) .* [1 a2(t,:)] .* (1-[1 a2(t,:)])
 			nextLayer := regr.Layers[l+1]
 
@@ -318,82 +814,126 @@ func (regr *MLPRegressor) backprop(X, Y mat.Matrix, epoch, miniBatchLen, nSample
 				L.Ydiff.Mul(nextLayer.Ydiff, base.MatFirstColumnRemoved{Matrix: nextLayer.Theta.T()})
 
 			}
-
-			//L.Ydiff.Apply(func(_, _ int, v float64) float64 { return panicIfNaN(v) }, L.Ydiff)
-			L.Ydiff.MulElem(rm(L.Ydiff), rm(L.Hgrad))
-			//L.Ydiff.Apply(func(_, _ int, v float64) float64 { return panicIfNaN(v) }, L.Ydiff)
-			L.Ytrue.Sub(rm(L.Ypred), rm(L.Ydiff))
-			//L.Ytrue.Apply(func(_, _ int, v float64) float64 { return panicIfNaN(v) }, L.Ytrue)
 		}
 
 		// compute loss J and Grad, put loss gradient in Ydiff
-		if l == outputLayer {
+		softmaxOutput := l == outputLayer && L.Activation == "softmax"
+		if softmaxOutput {
+			// fused softmax and log loss gradient: Ydiff is dJ/dZ
+			J = softmaxLogLoss(L.Ytrue, L.Z, L.Ypred, L.Ydiff) * miniBatchPart
+		} else if l == outputLayer {
 			lastLoss := regr.Loss
 			if lastLoss == "log" && nOutputs == 1 {
 				lastLoss = "cross-entropy"
 			}
 			J = NewLoss(lastLoss).Loss(L.Ytrue, L.Ypred, L.Ydiff) * miniBatchPart
-		} else {
-			NewLoss("square").Loss(L.Ytrue, L.Ypred, L.Ydiff)
 		}
 
 		// Ydiff is dJ/dH
-		NewActivation(L.Activation).Grad(L.Z, L.Ypred, L.Hgrad)
-		// =>L.Hgrad is derivative of activation vs Z
+		if !softmaxOutput {
+			if L.Dropout == 0 {
+				// (dropout layers compute Hgrad with their mask in predictZH)
+				NewActivation(L.Activation).Grad(L.Z, L.Ypred, L.Hgrad)
+			}
+			// =>L.Hgrad is derivative of activation vs Z
 
-		// put dJ/dh*dh/dz in Ydiff
-		L.Ydiff.MulElem(L.Ydiff, L.Hgrad)
+			// put dJ/dh*dh/dz in Ydiff
+			L.Ydiff.MulElem(L.Ydiff, L.Hgrad)
+		}
+		if L.BatchNormalization {
+			L.normalizationGrad()
+		}
 
 		// put [1 X].T * (dJ/dh.*dh/dz) in L.Grad
-		if regr.UseBlas {
+		if L.XSparse != nil {
+			features, outputs := L.Grad.Dims()
+			for o := 0; o < outputs; o++ {
+				L.Grad.Set(0, o, mat.Sum(L.Ydiff.ColView(o)))
+			}
+			base.MatMul(base.MatDenseSlice(L.Grad, 1, features, 0, outputs), L.XSparse.T(), L.Ydiff)
+		} else if regr.UseBlas {
 			base.MatParallelGemm(blas.Trans, blas.NoTrans, 1., L.X1.RawMatrix(), L.Ydiff.RawMatrix(), 0., L.Grad.RawMatrix())
 
 		} else {
 			L.Grad.Mul(L.X1.T(), L.Ydiff)
 		}
 
-		Alpha := regr.Alpha
-		// Add regularization to cost and grad
-		if Alpha > 0. {
-			L1Ratio := regr.L1Ratio
+		// Add regularization to cost and grad. layer penalties add to the regressor ones
+		L1, L2 := regr.Alpha*regr.L1Ratio+L.L1, regr.Alpha*(1.-regr.L1Ratio)+L.L2
+		if L1 > 0. || L2 > 0. {
 			R := 0.
 			features, outputs := L.Theta.Dims()
 			//ThetaReg := base.MatFirstRowZeroed{Matrix: L.Theta}
 			ThetaReg := base.MatDenseSlice(L.Theta, 1, features, 0, outputs)
 			GradReg := base.MatDenseSlice(L.Grad, 1, features, 0, outputs)
-			if L1Ratio > 0. {
+			if L1 > 0. {
 				// add L1 regularization
 				//R += Alpha * L1Ratio / float64(nSamples) * mat.Sum(matApply{Matrix: ThetaReg, Func: math.Abs})
-				R += Alpha * L1Ratio / float64(nSamples) * matx{Dense: ThetaReg}.SumAbs()
+				R += L1 / float64(nSamples) * matx{Dense: ThetaReg}.SumAbs()
 				//GradReg.Add(GradReg, matScale{Matrix: matApply{Matrix: ThetaReg, Func: sgn}, Scale: Alpha / float64(nSamples)})
-				matx{Dense: GradReg}.AddScaledApplied(Alpha/float64(nSamples), ThetaReg, sgn)
+				matx{Dense: GradReg}.AddScaledApplied(L1/float64(nSamples), ThetaReg, sgn)
 			}
-			if L1Ratio < 1. {
+			if L2 > 0. {
 				// add L2 regularization
-				R += Alpha * (1. - L1Ratio) / 2. / float64(nSamples) * matx{Dense: ThetaReg}.SumSquares()
+				R += L2 / 2. / float64(nSamples) * matx{Dense: ThetaReg}.SumSquares()
 				//GradReg.Add(GradReg, matScale{Matrix: ThetaReg, Scale: Alpha / float64(nSamples)})
-				matx{Dense: GradReg}.AddScaled(Alpha/float64(nSamples), ThetaReg)
+				matx{Dense: GradReg}.AddScaled(L2/float64(nSamples), ThetaReg)
 			}
 			J += R
 		}
 
-		if regr.GradientClipping > 0. {
+		if regr.GradientClipping > 0. && !regr.gradientOnly {
 			GNorm := mat.Norm(L.Grad, 2.)
 			if GNorm > regr.GradientClipping {
 				L.Grad.Scale(regr.GradientClipping/GNorm, L.Grad)
 			}
 		}
-		//compute theta Update from Grad
-		switch regr.Solver {
-		case "lbfgs":
-		default:
+	}
+	//compute theta Update from Grad, once all layers gradients are computed with the current weights
+	switch {
+	case regr.Solver == "lbfgs", regr.gradientOnly:
+	default:
+		for _, L := range regr.Layers {
 			L.Optimizer.GetUpdate(L.Update, L.Grad)
 			L.Theta.Add(L.Theta, L.Update)
+			if L.BatchNormalization {
+				L.BNOptimizer.GetUpdate(L.BNUpdate, L.BNGrad)
+				L.BN.Add(L.BN, L.BNUpdate)
+			}
 		}
 	}
 	return J
 }
 
+// CheckGradient compares the gradient computed by a full batch backprop pass on X,Y with the central differences of its loss,
+// at the current weights (random ones if the regressor is not fitted). the weights are left unchanged.
+// step is the finite differences step, 0 for the default one
+func (regr *MLPRegressor) CheckGradient(X, Y *mat.Dense, step float64) *base.GradientCheck {
+	nSamples, nFeatures := X.Dims()
+	_, nOutputs := Y.Dims()
+	if regr.Layers == nil {
+		regr.allocLayers(nFeatures, nOutputs, nil)
+	}
+	theta := append([]float64(nil), regr.thetaSlice...)
+	regr.gradientOnly = true
+	defer func() {
+		regr.gradientOnly = false
+		copy(regr.thetaSlice, theta)
+	}()
+	J := func(thetaSlice []float64) float64 {
+		copy(regr.thetaSlice, thetaSlice)
+		regr.training = true
+		regr.predictZH(X, nil)
+		regr.training = false
+		return regr.backprop(X, Y, 1, nSamples, nSamples)
+	}
+	dJ := func(dst, thetaSlice []float64) {
+		J(thetaSlice)
+		copy(dst, regr.gradSlice)
+	}
+	return base.CheckGradient(J, dJ, theta, step)
+}
+
 func unused(...interface{}) {}
 
 // Predict return the forward result
@@ -402,6 +942,12 @@ func (regr *MLPRegressor) Predict(X, Y *mat.Dense) base.Regressor {
 	return regr
 }
 
+// PredictSparse return the forward result for a sparse X
+func (regr *MLPRegressor) PredictSparse(X *base.CSR, Y *mat.Dense) base.Regressor {
+	regr.predictZH(X, Y)
+	return regr
+}
+
 // FitTransform is for Pipeline
 func (regr *MLPRegressor) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
 	r, c := Y.Dims()
@@ -419,20 +965,59 @@ func (regr *MLPRegressor) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
 	return
 }
 
+// Clone returns a copy of regr with its own layers so that both can Predict concurrently
+func (regr *MLPRegressor) Clone() base.Transformer {
+	clone := *regr
+	clone.cloneLayers()
+	return &clone
+}
+
+func (regr *MLPRegressor) cloneLayers() {
+	layers := regr.Layers
+	regr.Layers = make([]*Layer, len(layers))
+	for l, L := range layers {
+		inputs, outputs := L.Theta.Dims()
+		c := &Layer{Activation: L.Activation,
+			Theta:   mat.DenseCopyOf(L.Theta),
+			Grad:    mat.NewDense(inputs, outputs, nil),
+			Update:  mat.NewDense(inputs, outputs, nil),
+			L1:      L.L1,
+			L2:      L.L2,
+			Dropout: L.Dropout}
+		if L.BatchNormalization {
+			c.BatchNormalization = true
+			c.BN = mat.DenseCopyOf(L.BN)
+			c.BNGrad, c.BNUpdate = mat.NewDense(2, outputs, nil), mat.NewDense(2, outputs, nil)
+			c.RunningMean = append([]float64(nil), L.RunningMean...)
+			c.RunningVar = append([]float64(nil), L.RunningVar...)
+			c.std = make([]float64, outputs)
+		}
+		regr.Layers[l] = c
+	}
+	regr.thetaSlice, regr.gradSlice, regr.updateSlice = nil, nil, nil
+}
+
 // put X dot Theta in Z and activation(X dot Theta) in Y
-// Z and Y can be nil
-func (regr *MLPRegressor) predictZH(X, Y *mat.Dense) base.Regressor {
+// X is a *mat.Dense or a *base.CSR. Z and Y can be nil
+func (regr *MLPRegressor) predictZH(X mat.Matrix, Y *mat.Dense) base.Regressor {
 	nSamples, nFeatures0 := X.Dims()
+	rnd := rand.Float64
+	if regr.RandomState != nil {
+		rnd = regr.RandomState.Float64
+	}
 	for l := 0; l < len(regr.Layers); l++ {
 		L := regr.Layers[l]
 		_, nOutputs := L.Theta.Dims()
 		L.allocOutputs(nSamples, nOutputs)
 		if l == 0 {
-			if L.X1 == nil || len(L.X1.RawMatrix().Data) != (nSamples*(1+nFeatures0)) {
-				L.X1 = mat.NewDense(nSamples, 1+nFeatures0, nil)
+			L.XSparse, _ = X.(*base.CSR)
+			if L.XSparse == nil {
+				if L.X1 == nil || len(L.X1.RawMatrix().Data) != (nSamples*(1+nFeatures0)) {
+					L.X1 = mat.NewDense(nSamples, 1+nFeatures0, nil)
+				}
+				//L.X1.Copy(onesAddedMat{Matrix: X})
+				matx{Dense: L.X1}.CopyPrependOnes(X.(*mat.Dense))
 			}
-			//L.X1.Copy(onesAddedMat{Matrix: X})
-			matx{Dense: L.X1}.CopyPrependOnes(X)
 		} else {
 			L.X1 = regr.Layers[l-1].NextX1
 		}
@@ -445,13 +1030,23 @@ func (regr *MLPRegressor) predictZH(X, Y *mat.Dense) base.Regressor {
 		}
 
 		// compute activation.F([1 X] dot theta)
-		if regr.UseBlas {
+		if L.XSparse != nil {
+			features, outputs := L.Theta.Dims()
+			base.MatMul(L.Z, L.XSparse, base.MatDenseSlice(L.Theta, 1, features, 0, outputs))
+			L.Z.Apply(func(_, o int, z float64) float64 { return z + L.Theta.At(0, o) }, L.Z)
+		} else if regr.UseBlas {
 			base.MatParallelGemm(blas.NoTrans, blas.NoTrans, 1., L.X1.RawMatrix(), L.Theta.RawMatrix(), 0., L.Z.RawMatrix())
 		} else {
 			L.Z.Mul(L.X1, L.Theta)
 		}
 
+		if L.BatchNormalization {
+			L.normalize(regr.training)
+		}
 		NewActivation(L.Activation).Func(L.Z, L.Ypred)
+		if L.Dropout > 0 && regr.training {
+			L.dropout(rnd, regr.gradientOnly)
+		}
 	}
 
 	if Y != nil {
@@ -460,24 +1055,38 @@ func (regr *MLPRegressor) predictZH(X, Y *mat.Dense) base.Regressor {
 	return regr
 }
 
-// Score returns R2Score for square loss, else accuracy. see metrics package for other scores
+// Score returns the Scorer score if set, else R2Score for square loss, else accuracy
 func (regr *MLPRegressor) Score(X, Y *mat.Dense) float64 {
+	if regr.Scorer != nil {
+		return regr.Scorer.Score(regr, X, Y)
+	}
 	nSamples, _ := X.Dims()
 	_, nOutputs := regr.Layers[len(regr.Layers)-1].Theta.Dims()
 	Ypred := mat.NewDense(nSamples, nOutputs, nil)
-	regr.Predict(X, Y)
+	regr.Predict(X, Ypred)
+	return regr.scorePredictions(Y, Ypred)
+}
+
+func (regr *MLPRegressor) scorePredictions(Y, Ypred *mat.Dense) float64 {
+	if regr.Scorer != nil {
+		return regr.Scorer.ScorePredictions(Y, Ypred)
+	}
 	if regr.Loss == "square" {
 		return metrics.R2Score(Y, Ypred, nil, "").At(0, 0)
 	}
 	return metrics.AccuracyScore(Y, Ypred, true, nil)
 }
 
-// MLPClassifier ...
-type MLPClassifier struct{ MLPRegressor }
+// MLPClassifier is an MLPRegressor whose Y columns are one-hot encoded classes, with a softmax output layer if there are several,
+// or independent binary labels with logistic outputs if Multilabel
+type MLPClassifier struct {
+	MLPRegressor
+	Multilabel bool
+}
 
 // NewMLPClassifier returns a *MLPClassifier with defaults
 // activation is one of logistic,tanh,relu
-// solver is on of agd,adagrad,rmsprop,adadelta,adam (one of the keys of base.Solvers) defaults to "adam"
+// solver is one of the keys of base.Solvers (sgd,adagrad,rmsprop,adadelta,adam,nesterov,nadam,adamw,amsgrad,adamax,lamb) defaults to "adam"
 // Alpha is the regularization parameter
 // lossName is one of square,log,cross-entropy (one of the keys of lm.LossFunctions) defaults to "log"
 func NewMLPClassifier(hiddenLayerSizes []int, activation string, solver string, Alpha float64) *MLPClassifier {
@@ -488,10 +1097,84 @@ func NewMLPClassifier(hiddenLayerSizes []int, activation string, solver string,
 	return regr
 }
 
-// Predict return the forward result for MLPClassifier
+// Fit fits an MLPClassifier
+func (regr *MLPClassifier) Fit(X, Y *mat.Dense) base.Transformer {
+	regr.multiclass = !regr.Multilabel
+	regr.MLPRegressor.Fit(X, Y)
+	return regr
+}
+
+// FitSparse fits an MLPClassifier on a sparse X
+func (regr *MLPClassifier) FitSparse(X *base.CSR, Y *mat.Dense) base.Transformer {
+	regr.multiclass = !regr.Multilabel
+	regr.MLPRegressor.FitSparse(X, Y)
+	return regr
+}
+
+// FitContext fits an MLPClassifier. see MLPRegressor.FitContext
+func (regr *MLPClassifier) FitContext(ctx context.Context, X, Y *mat.Dense) (base.Transformer, error) {
+	regr.multiclass = !regr.Multilabel
+	_, err := regr.MLPRegressor.FitContext(ctx, X, Y)
+	return regr, err
+}
+
+// FitSparseContext fits an MLPClassifier on a sparse X. see MLPRegressor.FitContext
+func (regr *MLPClassifier) FitSparseContext(ctx context.Context, X *base.CSR, Y *mat.Dense) (base.Transformer, error) {
+	regr.multiclass = !regr.Multilabel
+	_, err := regr.MLPRegressor.FitSparseContext(ctx, X, Y)
+	return regr, err
+}
+
+// Predict puts predicted classes in Y: one-hot argmax of probabilities for a softmax output layer, else probabilities thresholded at .5
 func (regr *MLPClassifier) Predict(X, Y *mat.Dense) base.Regressor {
+	return regr.predict(X, Y)
+}
+
+// PredictProba puts in Y the probability of each class (or label if Multilabel)
+func (regr *MLPClassifier) PredictProba(X, Y *mat.Dense) base.Regressor {
 	regr.predictZH(X, Y)
-	Y.Copy(regr.Layers[len(regr.Layers)-1].Ypred)
+	return regr
+}
+
+// PredictLogProba puts in Y the log of PredictProba, computed from the output layer Z without underflow
+func (regr *MLPClassifier) PredictLogProba(X, Y *mat.Dense) base.Regressor {
+	regr.predictZH(X, nil)
+	L := regr.Layers[len(regr.Layers)-1]
+	switch L.Activation {
+	case "softmax":
+		logSoftmax(L.Z, Y)
+	case "logistic":
+		// log(1/(1+exp(-z))) = -softplus(-z)
+		Y.Apply(func(_, _ int, z float64) float64 { return -base.Softplus{}.F(-z) }, L.Z)
+	default:
+		Y.Apply(func(_, _ int, h float64) float64 { return math.Log(h) }, L.Ypred)
+	}
+	return regr
+}
+
+// Score returns the Scorer score using MLPClassifier Predict if Scorer is set, else the accuracy of Predict
+func (regr *MLPClassifier) Score(X, Y *mat.Dense) float64 {
+	if regr.Scorer != nil {
+		return regr.Scorer.Score(regr, X, Y)
+	}
+	nSamples, _ := X.Dims()
+	_, nOutputs := regr.Layers[len(regr.Layers)-1].Theta.Dims()
+	Ypred := mat.NewDense(nSamples, nOutputs, nil)
+	regr.Predict(X, Ypred)
+	return regr.scorePredictions(Y, Ypred)
+}
+
+// PredictSparse return the forward result for MLPClassifier for a sparse X
+func (regr *MLPClassifier) PredictSparse(X *base.CSR, Y *mat.Dense) base.Regressor {
+	return regr.predict(X, Y)
+}
+
+func (regr *MLPClassifier) predict(X mat.Matrix, Y *mat.Dense) base.Regressor {
+	regr.predictZH(X, Y)
+	if regr.Layers[len(regr.Layers)-1].Activation == "softmax" {
+		oneHotArgmax(Y)
+		return regr
+	}
 	Y.Apply(func(i, o int, y float64) float64 {
 		if y >= .5 {
 			y = 1.
@@ -503,6 +1186,19 @@ func (regr *MLPClassifier) Predict(X, Y *mat.Dense) base.Regressor {
 	return regr
 }
 
+// FitTransform is for Pipeline
+func (regr *MLPClassifier) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
+	regr.Fit(X, Y)
+	return regr.Transform(X, Y)
+}
+
+// Clone returns a copy of regr with its own layers so that both can Predict concurrently
+func (regr *MLPClassifier) Clone() base.Transformer {
+	clone := *regr
+	clone.cloneLayers()
+	return &clone
+}
+
 // Transform for pipeline
 func (regr *MLPClassifier) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
 	nSamples, _ := X.Dims()
@@ -530,9 +1226,6 @@ func sgn(x float64) float64 {
 	return 0.
 }
 
-// rm checks arg is a *Dense and returns it
-func rm(m *mat.Dense) *mat.Dense { return m }
-
 func isGOMethodOnly(solver string) bool {
 	_, isBaseOptimCreator := base.Solvers[solver]
 	_, isGOMethodCreator := base.GOMethodCreators[solver]
@@ -1,6 +1,7 @@
 package neuralNetwork
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
@@ -130,7 +131,7 @@ func TestMLPClassifierMicrochip(t *testing.T) {
 	//regr.Loss = "cross-entropy"
 
 	// we allocate Coef here because we use it for loss and grad tests before Fit
-	regr.allocLayers(nFeatures, nOutputs, func() float64 { return 0. })
+	regr.allocLayers(nFeatures, nOutputs, NewInitializer("zeros"))
 
 	Ypred := mat.NewDense(nSamples, nOutputs, nil)
 	var J float64
@@ -251,7 +252,7 @@ func TestMnist(t *testing.T) {
 	mlp.Loss = "cross-entropy"
 	mlp.MiniBatchSize = 5000
 	mlp.Shuffle = false
-	mlp.allocLayers(400, 10, func() float64 { return 0. })
+	mlp.allocLayers(400, 10, NewInitializer("zeros"))
 	mlp.Layers[0].Theta.Copy(Theta1.T())
 	mlp.Layers[1].Theta.Copy(Theta2.T())
 	J := mlp.fitEpoch(X, Yohe, 0)
@@ -283,7 +284,7 @@ func BenchmarkMnist(b *testing.B) {
 	mlp.Loss = "cross-entropy"
 	mlp.MiniBatchSize = 5000
 	mlp.Shuffle = false
-	mlp.allocLayers(400, 10, func() float64 { return 0. })
+	mlp.allocLayers(400, 10, NewInitializer("zeros"))
 	mlp.Layers[0].Theta.Copy(Theta1.T())
 	mlp.Layers[1].Theta.Copy(Theta2.T())
 	mlp.fitEpoch(X, Yohe, 0)
@@ -349,3 +350,406 @@ func ExampleMLPClassifier() {
 	// accuracy>0.994 ? true
 
 }
+
+func TestMLPRegressorEarlyStopping(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	nSamples, nFeatures := 500, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) + .1*rnd.NormFloat64() }, Y)
+	regr := NewMLPRegressor([]int{}, "identity", "adam", 0)
+	regr.RandomState = rnd
+	regr.EarlyStopping = true
+	regr.Epochs = 2000
+	regr.Fit(X, Y)
+	if regr.NIter >= regr.Epochs {
+		t.Errorf("expected early stop before %d epochs", regr.Epochs)
+	}
+	if len(regr.LossCurve) != regr.NIter || len(regr.ValidationScores) != regr.NIter {
+		t.Errorf("expected %d loss and validation values, got %d and %d", regr.NIter, len(regr.LossCurve), len(regr.ValidationScores))
+	}
+	if regr.BestValidationScore != floats.Max(regr.ValidationScores) {
+		t.Errorf("BestValidationScore %g is not the max validation score %g", regr.BestValidationScore, floats.Max(regr.ValidationScores))
+	}
+	// the stopping rule counts epochs since the last improvement by more than Tol, which may precede the best score
+	best, lastImprovement := math.Inf(-1), 0
+	for epoch, score := range regr.ValidationScores {
+		if score > best+regr.Tol {
+			lastImprovement = epoch
+		}
+		best = math.Max(best, score)
+	}
+	if regr.NIter-1-lastImprovement < 10 {
+		t.Errorf("stopped before 10 epochs without improvement")
+	}
+	if score := regr.Score(X, Y); score < .8 {
+		t.Errorf("expected r2>.8 got %g", score)
+	}
+}
+
+func TestMLPRegressorCallbacks(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 200, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) }, Y)
+	regr := NewMLPRegressor([]int{}, "identity", "adam", 0)
+	regr.MiniBatchSize = 50
+	regr.Epochs = 100
+	checkpoint := &base.Checkpoint{}
+	var begins, ends, miniBatches int
+	regr.Callbacks = base.Callbacks{checkpoint, base.CallbackFuncs{
+		EpochBegin: func(info *base.CallbackInfo) {
+			begins++
+			if info.Epoch == 1 {
+				info.StepSize /= 2
+			}
+		},
+		EpochEnd: func(info *base.CallbackInfo) {
+			ends++
+			info.Stop = info.Epoch == 9
+		},
+		MiniBatchEnd: func(info *base.CallbackInfo) {
+			miniBatches++
+			if info.GradNorm <= 0 || math.IsInf(info.Loss, 0) {
+				t.Errorf("unexpected mini-batch info %+v", *info)
+			}
+		},
+	}}
+	regr.Fit(X, Y)
+	if begins != 10 || ends != 10 || miniBatches != 40 || regr.NIter != 10 {
+		t.Errorf("expected 10 epochs of 4 mini-batches, got %d begins, %d ends, %d mini-batches, NIter %d", begins, ends, miniBatches, regr.NIter)
+	}
+	if stepSize := regr.Layers[0].Optimizer.(base.StepSizer).GetStepSize(); stepSize != .25 {
+		t.Errorf("expected step size .25, got %g", stepSize)
+	}
+	if checkpoint.Loss != floats.Min(regr.LossCurve) || len(checkpoint.Theta) != len(regr.thetaSlice) {
+		t.Errorf("checkpoint loss %g is not the min loss %g", checkpoint.Loss, floats.Min(regr.LossCurve))
+	}
+}
+
+func TestMLPRegressorFitContext(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 200, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) }, Y)
+	for _, solver := range []string{"adam", "lbfgs"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		regr := NewMLPRegressor([]int{}, "identity", solver, 0)
+		regr.Epochs = 1000
+		regr.Callbacks = base.Callbacks{base.CallbackFuncs{EpochEnd: func(info *base.CallbackInfo) {
+			if info.Epoch == 4 {
+				cancel()
+			}
+		}}}
+		_, err := regr.FitContext(ctx, X, Y)
+		if err != context.Canceled {
+			t.Errorf("%s: expected Canceled, got %v", solver, err)
+		}
+		if solver == "adam" && regr.NIter != 5 {
+			t.Errorf("%s: expected 5 epochs, got %d", solver, regr.NIter)
+		}
+		if len(regr.Callbacks) != 1 {
+			t.Errorf("%s: Callbacks not restored", solver)
+		}
+	}
+}
+
+func TestMLPRegressorLRScheduler(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 200, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) }, Y)
+	regr := NewMLPRegressor([]int{}, "identity", "adam", 0)
+	regr.Epochs = 50
+	regr.MiniBatchSize = 20
+	regr.LRScheduler = &base.StepDecay{Steps: 100, Gamma: .5}
+	var stepSizes []float64
+	regr.Callbacks = base.Callbacks{base.CallbackFuncs{MiniBatchEnd: func(info *base.CallbackInfo) {
+		s := regr.Layers[0].Optimizer.(*base.SGDOptimizer)
+		stepSizes = append(stepSizes, s.Scheduler.LearningRate(info.StepSize, s.TimeStep))
+	}}}
+	regr.Fit(X, Y)
+	if len(stepSizes) != 500 || stepSizes[99] != .5 || stepSizes[100] != .25 || stepSizes[499] != .5/16 {
+		t.Errorf("unexpected learning rates %g %g %g", stepSizes[99], stepSizes[100], stepSizes[499])
+	}
+	if score := regr.Score(X, Y); score < .9 {
+		t.Errorf("expected r2>.9 got %g", score)
+	}
+}
+
+func TestMLPRegressorSolvers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 200, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) }, Y)
+	for _, solver := range []string{"nesterov", "nadam", "adamw", "amsgrad", "adamax", "lamb"} {
+		regr := NewMLPRegressor([]int{}, "identity", solver, 0)
+		regr.Epochs = 1000
+		regr.MiniBatchSize = 20
+		regr.Fit(X, Y)
+		if s := regr.Layers[0].Optimizer.(fmt.Stringer).String(); s != solver {
+			t.Errorf("expected %s optimizer got %s", solver, s)
+		}
+		if score := regr.Score(X, Y); score < .9 {
+			t.Errorf("%s: expected r2>.9 got %g", solver, score)
+		}
+	}
+}
+
+func TestMLPRegressorCheckGradient(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures, nOutputs := 20, 3, 2
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, nOutputs, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.NormFloat64() }, X)
+	Y.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, Y)
+	for _, loss := range []string{"square", "cross-entropy"} {
+		for _, activation := range []string{"identity", "logistic", "tanh"} {
+			regr := NewMLPRegressor([]int{4, 3}, activation, "adam", 1e-2)
+			regr.Loss = loss
+			regr.RandomState = rnd
+			regr.L1Ratio = .5
+			gc := regr.CheckGradient(X, Y, 0)
+			if gc.MaxRelErr > 1e-4 {
+				t.Errorf("%s %s parameter %d grad:%g fd:%g", activation, loss, gc.ArgMax, gc.Analytic[gc.ArgMax], gc.Numeric[gc.ArgMax])
+			}
+		}
+	}
+}
+
+func TestMLPRegressorFitSparse(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 100, 6
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 {
+		if rnd.Float64() < .7 {
+			return 0
+		}
+		return rnd.Float64()
+	}, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) }, Y)
+	fit := func(X mat.Matrix, shuffle bool) *MLPRegressor {
+		regr := NewMLPRegressor([]int{5}, "tanh", "adam", 0)
+		regr.RandomState = rand.New(rand.NewSource(3))
+		regr.Shuffle = shuffle
+		regr.MiniBatchSize = 32
+		regr.Epochs = 20
+		if Xs, ok := X.(*base.CSR); ok {
+			regr.FitSparse(Xs, Y)
+		} else {
+			regr.Fit(X.(*mat.Dense), Y)
+		}
+		return regr
+	}
+	predict := func(regr *MLPRegressor) *mat.Dense {
+		Ypred := mat.NewDense(nSamples, 1, nil)
+		regr.Predict(X, Ypred)
+		return Ypred
+	}
+	Xs := base.NewCSRFromMatrix(X)
+	if Yd, Ys := predict(fit(X, false)), predict(fit(Xs, false)); !mat.EqualApprox(Yd, Ys, 1e-9) {
+		t.Errorf("sparse fit predictions differ from dense fit: %g vs %g", Ys.At(0, 0), Yd.At(0, 0))
+	}
+	// shuffled sparse minibatches are gathered from X, which is left as is
+	Xcopy := Xs.Copy()
+	if Y1, Y2 := predict(fit(Xs, true)), predict(fit(Xs, true)); !mat.Equal(Y1, Y2) {
+		t.Errorf("shuffled sparse fits with the same RandomState differ: %g vs %g", Y1.At(0, 0), Y2.At(0, 0))
+	}
+	if !mat.Equal(Xs, Xcopy) {
+		t.Error("shuffled sparse fit modified X")
+	}
+}
+
+func TestMLPRegressorHiddenLayers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 200, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) }, Y)
+	regr := NewMLPRegressor(nil, "identity", "adam", 0)
+	regr.SetOptimizer(func() base.Optimizer {
+		s := base.NewAdamOptimizer()
+		s.StepSize = .01
+		return s
+	})
+	regr.HiddenLayers = []LayerSpec{{Size: 8, Activation: "relu", L2: 1e-2}, {Size: 4, Activation: "tanh", L1: 1e-2}}
+	regr.OutputActivation = "identity"
+	regr.Initializer = "glorot_uniform"
+	regr.RandomState = rnd
+	regr.allocLayers(nFeatures, 1, nil)
+	for l, expected := range []string{"relu", "tanh", "identity"} {
+		if regr.Layers[l].Activation != expected {
+			t.Errorf("layer %d: expected %s activation got %s", l, expected, regr.Layers[l].Activation)
+		}
+	}
+	if _, outputs := regr.Layers[1].Theta.Dims(); outputs != 4 || regr.Layers[0].L2 != 1e-2 || regr.Layers[1].L1 != 1e-2 {
+		t.Errorf("unexpected layer 1 outputs %d or layer penalties", outputs)
+	}
+	if gc := regr.CheckGradient(X, Y, 0); gc.MaxRelErr > 1e-4 {
+		t.Errorf("parameter %d grad:%g fd:%g", gc.ArgMax, gc.Analytic[gc.ArgMax], gc.Numeric[gc.ArgMax])
+	}
+	regr.Epochs = 200
+	regr.Fit(X, Y)
+	if score := regr.Score(X, Y); score < .9 {
+		t.Errorf("expected r2>.9 got %g", score)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic for a zero size layer")
+			}
+		}()
+		regr.HiddenLayers = []LayerSpec{{Activation: "relu"}}
+		regr.Fit(X, Y)
+	}()
+}
+
+func TestMLPRegressorDropoutBatchNormalization(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 200, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) }, Y)
+	regr := NewMLPRegressor(nil, "identity", "adam", 0)
+	regr.SetOptimizer(func() base.Optimizer {
+		s := base.NewAdamOptimizer()
+		s.StepSize = .01
+		return s
+	})
+	regr.HiddenLayers = []LayerSpec{{Size: 8, Activation: "tanh", BatchNormalization: true}, {Size: 6, Activation: "logistic", Dropout: .2, BatchNormalization: true}}
+	regr.OutputActivation = "identity"
+	regr.Initializer = "glorot_uniform"
+	regr.RandomState = rnd
+	regr.allocLayers(nFeatures, 1, nil)
+	if len(regr.thetaSlice) != 4*8+2*8+9*6+2*6+7*1 {
+		t.Errorf("unexpected thetaSlice length %d", len(regr.thetaSlice))
+	}
+	if gc := regr.CheckGradient(X, Y, 0); gc.MaxRelErr > 1e-4 {
+		t.Errorf("parameter %d grad:%g fd:%g", gc.ArgMax, gc.Analytic[gc.ArgMax], gc.Numeric[gc.ArgMax])
+	}
+
+	regr.Epochs = 200
+	regr.Fit(X, Y)
+	if score := regr.Score(X, Y); score < .9 {
+		t.Errorf("expected r2>.9 got %g", score)
+	}
+	// predictions use running statistics and no dropout: they don't depend on the batch
+	Ypred, Ypred1 := mat.NewDense(nSamples, 1, nil), mat.NewDense(1, 1, nil)
+	regr.Predict(X, Ypred)
+	for _, i := range []int{0, 7, 99} {
+		regr.Predict(mat.NewDense(1, nFeatures, X.RawRowView(i)), Ypred1)
+		if math.Abs(Ypred1.At(0, 0)-Ypred.At(i, 0)) > 1e-12 {
+			t.Errorf("sample %d: single sample prediction %g differs from batch prediction %g", i, Ypred1.At(0, 0), Ypred.At(i, 0))
+		}
+	}
+	L := regr.Layers[0]
+	for o, v := range L.RunningVar {
+		if v <= 0 || v == 1 || L.RunningMean[o] == 0 {
+			t.Errorf("running statistics of output %d were not updated: mean %g var %g", o, L.RunningMean[o], v)
+		}
+	}
+	clone := regr.Clone().(*MLPRegressor)
+	YpredClone := mat.NewDense(nSamples, 1, nil)
+	clone.Predict(X, YpredClone)
+	if !mat.EqualApprox(Ypred, YpredClone, 1e-12) {
+		t.Error("clone predictions differ")
+	}
+}
+
+func TestMLPRegressorBatchNormalizationEarlyStopping(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures := 200, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, 1, nil)
+	X.Apply(func(_, _ int, _ float64) float64 { return rnd.Float64() }, X)
+	Y.Apply(func(i, _ int, _ float64) float64 { return floats.Sum(X.RawRowView(i)) + .3*rnd.NormFloat64() }, Y)
+	regr := NewMLPRegressor(nil, "identity", "adam", 0)
+	regr.SetOptimizer(func() base.Optimizer {
+		s := base.NewAdamOptimizer()
+		s.StepSize = .01
+		return s
+	})
+	regr.HiddenLayers = []LayerSpec{{Size: 16, Activation: "tanh", BatchNormalization: true}}
+	regr.OutputActivation = "identity"
+	regr.RandomState = rnd
+	regr.Epochs = 200
+	regr.EarlyStopping = true
+	regr.NIterNoChange = 5
+	// predictions records the predictions at the end of each epoch
+	var predictions []*mat.Dense
+	regr.Callbacks = base.Callbacks{base.CallbackFuncs{EpochEnd: func(info *base.CallbackInfo) {
+		Ypred := mat.NewDense(nSamples, 1, nil)
+		regr.Predict(X, Ypred)
+		predictions = append(predictions, Ypred)
+	}}}
+	regr.Fit(X, Y)
+	best := floats.MaxIdx(regr.ValidationScores)
+	if best == len(regr.ValidationScores)-1 {
+		t.Fatalf("expected the best validation epoch before the last one of %d", len(regr.ValidationScores))
+	}
+	Ypred := mat.NewDense(nSamples, 1, nil)
+	regr.Predict(X, Ypred)
+	if !mat.EqualApprox(Ypred, predictions[best], 1e-12) {
+		t.Errorf("predictions of the restored model differ from those of best epoch %d", best)
+	}
+}
+
+func TestMLPClassifierSoftmax(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	nSamples, nFeatures, nClasses := 300, 2, 3
+	X, Y := mat.NewDense(nSamples, nFeatures, nil), mat.NewDense(nSamples, nClasses, nil)
+	for i := 0; i < nSamples; i++ {
+		c := i % nClasses
+		angle := 2 * math.Pi * float64(c) / float64(nClasses)
+		X.Set(i, 0, 2*math.Cos(angle)+.5*rnd.NormFloat64())
+		X.Set(i, 1, 2*math.Sin(angle)+.5*rnd.NormFloat64())
+		Y.Set(i, c, 1)
+	}
+	clf := NewMLPClassifier([]int{8}, "tanh", "adam", 0)
+	clf.SetOptimizer(func() base.Optimizer {
+		s := base.NewAdamOptimizer()
+		s.StepSize = .01
+		return s
+	})
+	clf.RandomState = rnd
+	clf.Epochs = 200
+	clf.Fit(X, Y)
+	if activation := clf.Layers[1].Activation; activation != "softmax" {
+		t.Fatalf("expected softmax output got %s", activation)
+	}
+	if gc := clf.CheckGradient(X, Y, 0); gc.MaxRelErr > 1e-4 {
+		t.Errorf("parameter %d grad:%g fd:%g", gc.ArgMax, gc.Analytic[gc.ArgMax], gc.Numeric[gc.ArgMax])
+	}
+	if score := clf.Score(X, Y); score < .95 {
+		t.Errorf("expected accuracy>.95 got %g", score)
+	}
+	P, logP, Ypred := mat.NewDense(nSamples, nClasses, nil), mat.NewDense(nSamples, nClasses, nil), mat.NewDense(nSamples, nClasses, nil)
+	clf.PredictProba(X, P)
+	clf.PredictLogProba(X, logP)
+	clf.Predict(X, Ypred)
+	for i := 0; i < nSamples; i++ {
+		p := P.RawRowView(i)
+		if sum := floats.Sum(p); math.Abs(sum-1) > 1e-12 {
+			t.Errorf("sample %d probabilities sum to %g", i, sum)
+		}
+		for o := range p {
+			if math.Abs(math.Log(p[o])-logP.At(i, o)) > 1e-9 {
+				t.Errorf("sample %d class %d: log proba %g expected %g", i, o, logP.At(i, o), math.Log(p[o]))
+			}
+		}
+		if Ypred.At(i, floats.MaxIdx(p)) != 1 || floats.Sum(Ypred.RawRowView(i)) != 1 {
+			t.Errorf("sample %d: prediction %v is not the one-hot argmax of %v", i, Ypred.RawRowView(i), p)
+		}
+	}
+
+	clf = NewMLPClassifier([]int{8}, "tanh", "adam", 0)
+	clf.Multilabel = true
+	clf.Epochs = 1
+	clf.Fit(X, Y)
+	if activation := clf.Layers[1].Activation; activation != "logistic" {
+		t.Errorf("expected logistic output for multilabel got %s", activation)
+	}
+}
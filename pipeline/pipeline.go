@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gcla/sklearn/base"
+	"github.com/gcla/sklearn/metrics"
 	"github.com/gcla/sklearn/preprocessing"
 
 	"gonum.org/v1/gonum/mat"
@@ -25,6 +26,8 @@ type NamedStep struct {
 type Pipeline struct {
 	NamedSteps []NamedStep
 	NOutputs   int
+	// Scorer is used by Score when not nil
+	Scorer *metrics.Scorer
 }
 
 // NewPipeline returns a *Pipeline
@@ -71,14 +74,22 @@ func (p *Pipeline) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
 	return
 }
 
-// Score for base.Regressor
+// Score for base.Regressor. it returns the last step Score, or the Scorer score if set.
+// Scorer "predict" responses use the pipeline Predict, other responses use the last step on transformed X
 func (p *Pipeline) Score(X, Y *mat.Dense) float64 {
+	if p.Scorer != nil && (p.Scorer.ResponseMethod == "" || p.Scorer.ResponseMethod == "predict") {
+		return p.Scorer.Score(p, X, Y)
+	}
 	Xtmp, Ytmp := X, Y
 	for _, step := range p.NamedSteps[:len(p.NamedSteps)-1] {
 		Xtmp, Ytmp = step.Step.Transform(Xtmp, Ytmp)
 
 	}
-	return p.NamedSteps[len(p.NamedSteps)-1].Step.(base.Regressor).Score(Xtmp, Y)
+	last := p.NamedSteps[len(p.NamedSteps)-1].Step
+	if p.Scorer != nil {
+		return p.Scorer.Score(last, Xtmp, Y)
+	}
+	return last.(base.Regressor).Score(Xtmp, Y)
 }
 
 // MakePipeline returns a Pipeline from unnamed steps
@@ -1,6 +1,7 @@
 package preprocessing
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 	"sort"
@@ -117,10 +118,12 @@ func (scaler *MinMaxScaler) InverseTransform(X, Y *mat.Dense) (Xout, Yout *mat.D
 	return Xout, Y
 }
 
-// StandardScaler scales data by removing Mean and dividing by stddev
+// StandardScaler scales data by removing Mean and dividing by stddev.
+// NoMean disables centering and must be set to scale sparse data, NoStd disables scaling
 type StandardScaler struct {
-	Scale, Mean, Var *mat.Dense
-	NSamplesSeen     int
+	NoMean, NoStd    bool
+	Scale, Mean, Var  *mat.Dense
+	NSamplesSeen      int
 }
 
 // NewStandardScaler creates a *StandardScaler
@@ -153,12 +156,30 @@ func (scaler *StandardScaler) PartialFit(X, Y *mat.Dense) Transformer {
 
 	}
 	scaler.Mean, scaler.Var, scaler.NSamplesSeen = IncrementalMeanAndVar(X, scaler.Mean, scaler.Var, scaler.NSamplesSeen)
+	scaler.setScale()
+	return scaler
+}
+
+func (scaler *StandardScaler) setScale() {
 	scaler.Scale.Apply(func(i int, j int, vj float64) float64 {
-		if vj == 0. {
+		if vj == 0. || scaler.NoStd {
 			vj = 1.
 		}
 		return math.Sqrt(vj)
 	}, scaler.Var)
+}
+
+// FitSparse computes Mean snd Std from a sparse X. NoMean must be set
+func (scaler *StandardScaler) FitSparse(X *base.CSR) *StandardScaler {
+	if !scaler.NoMean {
+		panic(fmt.Errorf("cannot center sparse matrices: set NoMean"))
+	}
+	mean, variance := X.ColumnsMeanAndVar()
+	scaler.Mean = mat.NewDense(1, X.Columns, mean)
+	scaler.Var = mat.NewDense(1, X.Columns, variance)
+	scaler.Scale = mat.NewDense(1, X.Columns, nil)
+	scaler.NSamplesSeen = X.Rows
+	scaler.setScale()
 	return scaler
 }
 
@@ -166,11 +187,28 @@ func (scaler *StandardScaler) PartialFit(X, Y *mat.Dense) Transformer {
 func (scaler *StandardScaler) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
 	Xout = mat.DenseCopyOf(X)
 	Xout.Apply(func(i int, j int, x float64) float64 {
-		return (x - scaler.Mean.At(0, j)) / scaler.Scale.At(0, j)
+		if !scaler.NoMean {
+			x -= scaler.Mean.At(0, j)
+		}
+		return x / scaler.Scale.At(0, j)
 	}, X)
 	return Xout, Y
 }
 
+// TransformSparse scales sparse data. NoMean must be set
+func (scaler *StandardScaler) TransformSparse(X *base.CSR) *base.CSR {
+	if !scaler.NoMean {
+		panic(fmt.Errorf("cannot center sparse matrices: set NoMean"))
+	}
+	Xout := X.Copy()
+	invScale := make([]float64, X.Columns)
+	for j := range invScale {
+		invScale[j] = 1. / scaler.Scale.At(0, j)
+	}
+	Xout.ScaleColumns(invScale)
+	return Xout
+}
+
 // FitTransform for StandardScaler
 func (scaler *StandardScaler) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
 	return scaler.Fit(X, Y).Transform(X, Y)
@@ -183,11 +221,22 @@ func (scaler *StandardScaler) InverseTransform(X, Y *mat.Dense) (Xout, Yout *mat
 	}
 	Xout = mat.DenseCopyOf(X)
 	Xout.Apply(func(i int, j int, x float64) float64 {
-		return scaler.Mean.At(0, j) + x*scaler.Scale.At(0, j)
+		x *= scaler.Scale.At(0, j)
+		if !scaler.NoMean {
+			x += scaler.Mean.At(0, j)
+		}
+		return x
 	}, X)
 	return Xout, Y
 }
 
+// InverseTransformSparse unscales sparse data
+func (scaler *StandardScaler) InverseTransformSparse(X *base.CSR) *base.CSR {
+	Xout := X.Copy()
+	Xout.ScaleColumns(scaler.Scale.RawRowView(0))
+	return Xout
+}
+
 //======================================================================
 
 // RobustScaler scales data by removing centering around the Median and
@@ -653,6 +702,28 @@ func (m *OneHotEncoder) Transform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
 	return
 }
 
+// TransformSparse transform Y labels to a sparse one hot encoded format
+func (m *OneHotEncoder) TransformSparse(X, Y *mat.Dense) (Xout *mat.Dense, Yout *base.CSR) {
+	nSamples, nOutputs := Y.Dims()
+	Xout = X
+	columns := 0
+	for output := 0; output < nOutputs; output++ {
+		columns += m.NumClasses[output]
+	}
+	Yout = base.NewCSR(0, columns, nil, nil, nil)
+	indices, data := make([]int, nOutputs), make([]float64, nOutputs)
+	for sample := 0; sample < nSamples; sample++ {
+		baseColumn := 0
+		for output := 0; output < nOutputs; output++ {
+			indices[output] = baseColumn + int(Y.At(sample, output)) - m.Min[output]
+			data[output] = 1.
+			baseColumn += m.NumClasses[output]
+		}
+		Yout.AppendRow(indices, data)
+	}
+	return
+}
+
 // FitTransform for OneHotEncoder
 func (m *OneHotEncoder) FitTransform(X, Y *mat.Dense) (Xout, Yout *mat.Dense) {
 	return m.Fit(X, Y).Transform(X, Y)
@@ -2,7 +2,7 @@ package preprocessing
 
 import (
 	"fmt"
-	_ "math"
+	"math"
 
 	"github.com/gcla/sklearn/base"
 
@@ -65,6 +65,43 @@ func TestStandardScaler(t *testing.T) {
 		t.Errorf("StandardScaler inversetransform failed %v", X2.RawRowView(0))
 		t.Fail()
 	}
+	// the zero value centers and scales
+	X = mat.NewDense(3, 3, []float64{1, 2, 3, 1, 4, 7, 9, 5, 9})
+	Y, _ = (&StandardScaler{}).FitTransform(X, nil)
+	for j := 0; j < 3; j++ {
+		col := mat.Col(nil, j, Y)
+		if mean, variance := floats.Sum(col)/3, floats.Dot(col, col)/3; math.Abs(mean) > 1e-12 || math.Abs(variance-1) > 1e-12 {
+			t.Errorf("StandardScaler{} column %d: expected mean 0 and variance 1, got %g %g", j, mean, variance)
+		}
+	}
+}
+
+func TestStandardScalerSparse(t *testing.T) {
+	X := mat.NewDense(4, 3, []float64{1, 0, 0, 0, 2, 0, 3, 0, 0, 0, 4, 5})
+	Xs := base.NewCSRFromMatrix(X)
+	dense, sparse := &StandardScaler{NoMean: true}, &StandardScaler{NoMean: true}
+	dense.Fit(X, nil)
+	sparse.FitSparse(Xs)
+	if !mat.EqualApprox(dense.Scale, sparse.Scale, 1e-12) || !mat.EqualApprox(dense.Mean, sparse.Mean, 1e-12) {
+		t.Errorf("sparse scale %v mean %v differ from dense %v %v", sparse.Scale.RawRowView(0), sparse.Mean.RawRowView(0), dense.Scale.RawRowView(0), dense.Mean.RawRowView(0))
+	}
+	Xt, _ := dense.Transform(X, nil)
+	Xst := sparse.TransformSparse(Xs)
+	if !mat.EqualApprox(Xt, Xst.ToDense(), 1e-12) || Xst.NNZ() != Xs.NNZ() {
+		t.Errorf("sparse transform %v differs from dense %v", Xst.ToDense().RawMatrix().Data, Xt.RawMatrix().Data)
+	}
+	Xi, _ := dense.InverseTransform(Xt, nil)
+	if Xsi := sparse.InverseTransformSparse(Xst); !mat.EqualApprox(Xi, Xsi.ToDense(), 1e-12) || !mat.EqualApprox(X, Xsi.ToDense(), 1e-12) {
+		t.Errorf("sparse inverse transform %v differs from dense %v", Xsi.ToDense().RawMatrix().Data, Xi.RawMatrix().Data)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic fitting a centering scaler on sparse data")
+			}
+		}()
+		NewStandardScaler().FitSparse(Xs)
+	}()
 }
 
 func TestRobustScaler(t *testing.T) {
@@ -191,6 +228,17 @@ func TestOneHotEncoder(t *testing.T) {
 	}
 }
 
+func TestOneHotEncoderSparse(t *testing.T) {
+	Y := mat.NewDense(4, 2, []float64{1, 3, 2, 3, 4, 5, 1, 4})
+	ohe := NewOneHotEncoder()
+	ohe.Fit(nil, Y)
+	_, Yd := ohe.Transform(nil, Y)
+	_, Ys := ohe.TransformSparse(nil, Y)
+	if !mat.Equal(Yd, Ys.ToDense()) || Ys.NNZ() != 8 {
+		t.Errorf("sparse one-hot %v differs from dense %v", Ys.ToDense().RawMatrix().Data, Yd.RawMatrix().Data)
+	}
+}
+
 func ExampleShuffler() {
 	X, Y := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}), mat.NewDense(2, 3, []float64{7, 8, 9, 10, 11, 12})
 	m := NewShuffler()